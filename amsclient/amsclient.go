@@ -35,6 +35,7 @@ const (
 	// strings for logging and errors
 	orgNoInternalID              = "Organization doesn't have proper internal ID"
 	orgMoreInternalOrgs          = "More than one internal organization for the given orgID"
+	orgNotFound                  = "Organization not found in AMS API"
 	orgIDRequestFailure          = "Request to get the organization info failed"
 	subscriptionListRequestError = "problem executing subscription list request"
 	orgIDTag                     = "OrgID"
@@ -46,6 +47,10 @@ const (
 	StatusArchived = "Archived"
 	// StatusReserved means the cluster has reserved resources, but isn't initialized yet.
 	StatusReserved = "Reserved"
+
+	// subscriptionFields are the subscription fields requested from AMS API
+	subscriptionFields = "external_cluster_id,display_name,cluster_id,managed,status," +
+		"metrics.openshift_version,plan.id,cloud_provider_id,region_id,created_at"
 )
 
 var (
@@ -75,6 +80,7 @@ type AMSClient interface {
 type amsClientImpl struct {
 	connection *sdk.Connection
 	pageSize   int
+	orgIDCache *orgIDCache
 }
 
 // NewAMSClient create an AMSClient from the configuration
@@ -91,6 +97,10 @@ func NewAMSClientWithTransport(conf Configuration, transport http.RoundTripper)
 		builder.TransportWrapper(func(http.RoundTripper) http.RoundTripper { return transport })
 	}
 
+	if conf.TokenURL != "" {
+		builder = builder.TokenURL(conf.TokenURL)
+	}
+
 	if conf.ClientID != "" && conf.ClientSecret != "" {
 		builder = builder.Client(conf.ClientID, conf.ClientSecret)
 	} else if conf.Token != "" {
@@ -115,9 +125,15 @@ func NewAMSClientWithTransport(conf Configuration, transport http.RoundTripper)
 	return &amsClientImpl{
 		connection: conn,
 		pageSize:   conf.PageSize,
+		orgIDCache: newOrgIDCache(conf.OrgIDCacheSize, conf.OrgIDCacheTTL, conf.OrgIDNegativeCacheTTL),
 	}, nil
 }
 
+// Close closes the connection to AMS API
+func (c *amsClientImpl) Close() error {
+	return c.connection.Close()
+}
+
 // GetClustersForOrganization retrieves the clusters for a given organization using the default client
 // it allows to filter the clusters by their status (statusNegativeFilter will exclude the clusters with status in that list)
 // If nil is passed for filters, default filters will be applied. To select empty filters, pass an empty slice.
@@ -205,8 +221,25 @@ func (c *amsClientImpl) GetSingleClusterInfoForOrganization(orgID types.OrgID, c
 	return clusterInfoList[0], nil
 }
 
-// GetInternalOrgIDFromExternal will retrieve the internal organization ID from an external one using AMS API
+// GetInternalOrgIDFromExternal will retrieve the internal organization ID from an external one using AMS API.
+// Results are cached, including organizations not known to AMS API.
 func (c *amsClientImpl) GetInternalOrgIDFromExternal(orgID types.OrgID) (string, error) {
+	if internalID, known, hit := c.orgIDCache.get(orgID); hit {
+		OrgIDCacheHits.Inc()
+		if !known {
+			log.Debug().Uint32(orgIDTag, uint32(orgID)).Msg("organization is cached as unknown to AMS API")
+			return "", fmt.Errorf(orgNotFound)
+		}
+		return internalID, nil
+	}
+
+	OrgIDCacheMisses.Inc()
+	return c.readInternalOrgIDFromAMS(orgID)
+}
+
+// readInternalOrgIDFromAMS retrieves the internal organization ID from AMS
+// API and stores the result into the cache
+func (c *amsClientImpl) readInternalOrgIDFromAMS(orgID types.OrgID) (string, error) {
 	log.Debug().Uint32(orgIDTag, uint32(orgID)).Msg(
 		"Looking for the internal organization ID for an external one",
 	)
@@ -217,10 +250,17 @@ func (c *amsClientImpl) GetInternalOrgIDFromExternal(orgID types.OrgID) (string,
 		Send()
 
 	if err != nil {
+		OrgIDLookupFailures.Inc()
 		log.Error().Err(err).Msg(orgIDRequestFailure)
 		return "", err
 	}
 
+	if response.Items().Len() == 0 {
+		log.Error().Uint32(orgIDTag, uint32(orgID)).Msg(orgNotFound)
+		c.orgIDCache.addUnknown(orgID)
+		return "", fmt.Errorf(orgNotFound)
+	}
+
 	if response.Items().Len() != 1 {
 		log.Error().Uint32(orgIDTag, uint32(orgID)).Msg(orgMoreInternalOrgs)
 		return "", fmt.Errorf(orgMoreInternalOrgs)
@@ -232,6 +272,7 @@ func (c *amsClientImpl) GetInternalOrgIDFromExternal(orgID types.OrgID) (string,
 		return "", fmt.Errorf(orgNoInternalID)
 	}
 
+	c.orgIDCache.add(orgID, internalID)
 	return internalID, nil
 }
 
@@ -247,7 +288,7 @@ func (c *amsClientImpl) executeSubscriptionListRequest(
 		subscriptionListRequest = subscriptionListRequest.
 			Size(c.pageSize).
 			Page(pageNum).
-			Fields("external_cluster_id,display_name,cluster_id,managed,status").
+			Fields(subscriptionFields).
 			Search(searchQuery)
 
 		response, err := subscriptionListRequest.Send()
@@ -296,10 +337,15 @@ func (c *amsClientImpl) executeSubscriptionListRequest(
 
 			clusterID := types.ClusterName(clusterIDstr)
 			clusterInfoList = append(clusterInfoList, types.ClusterInfo{
-				ID:          clusterID,
-				DisplayName: displayName,
-				Managed:     managed,
-				Status:      status,
+				ID:            clusterID,
+				DisplayName:   displayName,
+				Managed:       managed,
+				Status:        status,
+				Version:       subscriptionVersion(item),
+				Product:       subscriptionProduct(item),
+				CloudProvider: item.CloudProviderID(),
+				Region:        item.RegionID(),
+				CreatedAt:     subscriptionCreatedAt(item),
 			})
 		}
 	}
@@ -33,17 +33,20 @@ import (
 )
 
 const (
+	subscriptionFieldsParam = ("fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus%%2C" +
+		"metrics.openshift_version%%2Cplan.id%%2Ccloud_provider_id%%2Cregion_id%%2Ccreated_at")
+
 	organizationsSearchEndpoint = "api/accounts_mgmt/v1/organizations?fields=id%%2Cexternal_id&search=external_id+%%3D+{orgID}"
 
-	subscriptionsSearchEndpoint = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus&page={pageNum}&" +
+	subscriptionsSearchEndpoint = ("api/accounts_mgmt/v1/subscriptions?" + subscriptionFieldsParam + "&page={pageNum}&" +
 		"search=organization_id+is+%%27{orgID}%%27+and+cluster_id+%%21%%3D+%%27%%27&size={pageSize}")
-	subscriptionsSearchEndpointWithFilter = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus&page={pageNum}&" +
+	subscriptionsSearchEndpointWithFilter = ("api/accounts_mgmt/v1/subscriptions?" + subscriptionFieldsParam + "&page={pageNum}&" +
 		"search=organization_id+is+%%27{orgID}%%27+and+cluster_id+%%21%%3D+%%27%%27+and+status+in+%%28%%27{status1}%%27%%2C%%27{status2}%%27%%29&size={pageSize}")
-	subscriptionsSearchEndpointWithDefaultFilter = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus&page={pageNum}&" +
+	subscriptionsSearchEndpointWithDefaultFilter = ("api/accounts_mgmt/v1/subscriptions?" + subscriptionFieldsParam + "&page={pageNum}&" +
 		"search=organization_id+is+%%27{orgID}%%27+and+cluster_id+%%21%%3D+%%27%%27+and+status+not+in+%%28%%27{status1}%%27%%2C%%27{status2}%%27%%2C%%27{status3}%%27%%29&size={pageSize}")
-	clusterDetailsSearchEndpoint = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus&page={pageNum}&" +
+	clusterDetailsSearchEndpoint = ("api/accounts_mgmt/v1/subscriptions?" + subscriptionFieldsParam + "&page={pageNum}&" +
 		"search=external_cluster_id+%%3D+%%27{clusterID}%%27&size={pageSize}")
-	singleClusterInfoEndpoint = ("api/accounts_mgmt/v1/subscriptions?fields=external_cluster_id%%2Cdisplay_name%%2Ccluster_id%%2Cmanaged%%2Cstatus&page={pageNum}&" +
+	singleClusterInfoEndpoint = ("api/accounts_mgmt/v1/subscriptions?" + subscriptionFieldsParam + "&page={pageNum}&" +
 		"search=organization_id+%%3D+%%27{orgID}%%27+and+external_cluster_id+%%3D+%%27{clusterID}%%27&size={pageSize}")
 )
 
@@ -432,10 +435,15 @@ func TestGetClusterDetailsFromExternalClusterId(t *testing.T) {
 
 	// cluster 1 is managed (has `managed` attribute set in AMS response)
 	assert.Equal(t, clusterInfo, types.ClusterInfo{
-		ID:          testdata.ClusterName1,
-		DisplayName: testdata.ClusterDisplayName1,
-		Managed:     true,
-		Status:      testdata.ActiveStatus,
+		ID:            testdata.ClusterName1,
+		DisplayName:   testdata.ClusterDisplayName1,
+		Managed:       true,
+		Status:        testdata.ActiveStatus,
+		Version:       testdata.ClusterVersion1,
+		Product:       testdata.ClusterProduct1,
+		CloudProvider: testdata.ClusterCloudProvider1,
+		Region:        testdata.ClusterRegion1,
+		CreatedAt:     testdata.ClusterCreatedAt1,
 	})
 }
 
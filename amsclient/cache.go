@@ -0,0 +1,275 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amsclient
+
+// Caching decorator for AMSClient. Lists of clusters retrieved for
+// organizations are stored for configured time (TTL), so that repeated
+// requests coming from UI page navigation do not need to call AMS API every
+// time. Optionally, expired entries can be served (stale-while-revalidate) for
+// some time while the list is refreshed in background.
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// nilFilter represents filter not provided by the caller (nil slice). It
+// needs to be distinguished from empty filter, because default filters are
+// used in the former case.
+const nilFilter = "<nil>"
+
+// CachedClusterList represents list of clusters stored in the cache
+type CachedClusterList struct {
+	FetchedAt time.Time           `json:"fetched_at"`
+	Clusters  []types.ClusterInfo `json:"clusters"`
+}
+
+// ClusterListStore is a storage for cached cluster lists. All lists stored
+// for one organization (with different filters) can be invalidated at once.
+type ClusterListStore interface {
+	Get(orgID types.OrgID, filterKey string) (CachedClusterList, bool, error)
+	Set(orgID types.OrgID, filterKey string, entry CachedClusterList, expiration time.Duration) error
+	Invalidate(orgID types.OrgID) error
+}
+
+// ClusterListCacheInvalidator is implemented by AMS clients able to
+// invalidate cached list of clusters
+type ClusterListCacheInvalidator interface {
+	InvalidateClusterListCache(orgID types.OrgID) error
+}
+
+// cachingAMSClient is an implementation of AMSClient interface caching
+// results of GetClustersForOrganization calls
+type cachingAMSClient struct {
+	AMSClient
+	store    ClusterListStore
+	ttl      time.Duration
+	maxStale time.Duration
+
+	refreshingMutex sync.Mutex
+	refreshing      map[string]bool
+}
+
+// NewCachingAMSClient wraps given AMS client by cache for cluster lists. If
+// store is nil, in-memory store is used.
+func NewCachingAMSClient(client AMSClient, conf Configuration, store ClusterListStore) AMSClient {
+	if store == nil {
+		store = NewMemoryClusterListStore()
+	}
+
+	log.Info().
+		Dur("ttl", conf.CacheTTL).
+		Dur("max_stale", conf.CacheMaxStale).
+		Msg("AMS API cluster list cache enabled")
+
+	return &cachingAMSClient{
+		AMSClient:  client,
+		store:      store,
+		ttl:        conf.CacheTTL,
+		maxStale:   conf.CacheMaxStale,
+		refreshing: make(map[string]bool),
+	}
+}
+
+// GetClustersForOrganization returns list of clusters from the cache if it is
+// fresh enough, otherwise the list is retrieved using wrapped client
+func (c *cachingAMSClient) GetClustersForOrganization(orgID types.OrgID, statusFilter, statusNegativeFilter []string) (
+	clusterInfoList []types.ClusterInfo,
+	err error,
+) {
+	filterKey := clusterListFilterKey(statusFilter, statusNegativeFilter)
+
+	entry, found, err := c.store.Get(orgID, filterKey)
+	if err != nil {
+		// cache is not mandatory, just use AMS API directly
+		log.Error().Err(err).Uint32(orgIDTag, uint32(orgID)).Msg("unable to read cluster list from cache")
+	}
+
+	if found {
+		age := time.Since(entry.FetchedAt)
+		if age < c.ttl {
+			log.Debug().Uint32(orgIDTag, uint32(orgID)).Msg("cluster list found in cache")
+			return entry.Clusters, nil
+		}
+
+		if age < c.ttl+c.maxStale {
+			log.Debug().Uint32(orgIDTag, uint32(orgID)).Msg("stale cluster list found in cache, revalidating")
+			c.revalidate(orgID, statusFilter, statusNegativeFilter, filterKey)
+			return entry.Clusters, nil
+		}
+	}
+
+	return c.refresh(orgID, statusFilter, statusNegativeFilter, filterKey)
+}
+
+// InvalidateClusterListCache removes all cached cluster lists for given
+// organization
+func (c *cachingAMSClient) InvalidateClusterListCache(orgID types.OrgID) error {
+	log.Info().Uint32(orgIDTag, uint32(orgID)).Msg("invalidating cached cluster list")
+	return c.store.Invalidate(orgID)
+}
+
+// Close closes the wrapped client if it holds any connection
+func (c *cachingAMSClient) Close() error {
+	if closer, ok := c.AMSClient.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// refresh retrieves the list of clusters using wrapped client and stores it
+// in the cache
+func (c *cachingAMSClient) refresh(orgID types.OrgID, statusFilter, statusNegativeFilter []string, filterKey string) (
+	[]types.ClusterInfo, error,
+) {
+	clusterInfoList, err := c.AMSClient.GetClustersForOrganization(orgID, statusFilter, statusNegativeFilter)
+	if err != nil {
+		return clusterInfoList, err
+	}
+
+	entry := CachedClusterList{
+		FetchedAt: time.Now(),
+		Clusters:  clusterInfoList,
+	}
+	if err := c.store.Set(orgID, filterKey, entry, c.ttl+c.maxStale); err != nil {
+		log.Error().Err(err).Uint32(orgIDTag, uint32(orgID)).Msg("unable to store cluster list into cache")
+	}
+
+	return clusterInfoList, nil
+}
+
+// revalidate refreshes the cached list of clusters in background. Only one
+// refresh for the same organization and filters runs at a time.
+func (c *cachingAMSClient) revalidate(orgID types.OrgID, statusFilter, statusNegativeFilter []string, filterKey string) {
+	key := fmt.Sprintf("%d|%s", orgID, filterKey)
+
+	c.refreshingMutex.Lock()
+	defer c.refreshingMutex.Unlock()
+
+	if c.refreshing[key] {
+		return
+	}
+	c.refreshing[key] = true
+
+	go func() {
+		defer func() {
+			c.refreshingMutex.Lock()
+			delete(c.refreshing, key)
+			c.refreshingMutex.Unlock()
+		}()
+
+		if _, err := c.refresh(orgID, statusFilter, statusNegativeFilter, filterKey); err != nil {
+			log.Error().Err(err).Uint32(orgIDTag, uint32(orgID)).Msg("unable to revalidate cached cluster list")
+		}
+	}()
+}
+
+// clusterListFilterKey constructs key identifying the filters used to
+// retrieve list of clusters
+func clusterListFilterKey(statusFilter, statusNegativeFilter []string) string {
+	return filterToString(statusFilter) + "|" + filterToString(statusNegativeFilter)
+}
+
+func filterToString(filter []string) string {
+	if filter == nil {
+		return nilFilter
+	}
+	return strings.Join(filter, ",")
+}
+
+// memoryClusterListStore is ClusterListStore keeping cluster lists in memory
+type memoryClusterListStore struct {
+	mutex   sync.RWMutex
+	entries map[types.OrgID]map[string]memoryClusterListEntry
+}
+
+type memoryClusterListEntry struct {
+	CachedClusterList
+	expiresAt time.Time
+}
+
+// NewMemoryClusterListStore constructs new in-memory store for cluster lists
+func NewMemoryClusterListStore() ClusterListStore {
+	return &memoryClusterListStore{
+		entries: make(map[types.OrgID]map[string]memoryClusterListEntry),
+	}
+}
+
+// Get returns cluster list stored for given organization and filters
+func (s *memoryClusterListStore) Get(orgID types.OrgID, filterKey string) (CachedClusterList, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entry, found := s.entries[orgID][filterKey]
+	if !found || time.Now().After(entry.expiresAt) {
+		return CachedClusterList{}, false, nil
+	}
+	return copyClusterList(entry.CachedClusterList), true, nil
+}
+
+// Set stores cluster list for given organization and filters
+func (s *memoryClusterListStore) Set(
+	orgID types.OrgID, filterKey string, entry CachedClusterList, expiration time.Duration,
+) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	orgEntries, found := s.entries[orgID]
+	if !found {
+		orgEntries = make(map[string]memoryClusterListEntry)
+		s.entries[orgID] = orgEntries
+	}
+
+	// drop expired entries, so the map does not grow indefinitely
+	now := time.Now()
+	for key, stored := range orgEntries {
+		if now.After(stored.expiresAt) {
+			delete(orgEntries, key)
+		}
+	}
+
+	orgEntries[filterKey] = memoryClusterListEntry{
+		CachedClusterList: copyClusterList(entry),
+		expiresAt:         now.Add(expiration),
+	}
+	return nil
+}
+
+// Invalidate removes all cluster lists stored for given organization
+func (s *memoryClusterListStore) Invalidate(orgID types.OrgID) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.entries, orgID)
+	return nil
+}
+
+// copyClusterList makes a copy of cached list, so the callers are free to
+// modify it
+func copyClusterList(entry CachedClusterList) CachedClusterList {
+	if entry.Clusters != nil {
+		clusters := make([]types.ClusterInfo, len(entry.Clusters))
+		copy(clusters, entry.Clusters)
+		entry.Clusters = clusters
+	}
+	return entry
+}
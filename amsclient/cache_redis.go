@@ -0,0 +1,110 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amsclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	redisV9 "github.com/redis/go-redis/v9"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// ClusterListCacheKey is a key of Redis hash containing cached cluster lists
+// for one organization. Hash fields are filter keys. Expiration of the whole
+// hash is prolonged whenever any list is stored, so every field carries its
+// own expiration time checked on read.
+var ClusterListCacheKey = "amsclient:organization:%v:clusters"
+
+// redisClusterListEntry is the value of one field of the hash
+type redisClusterListEntry struct {
+	CachedClusterList
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// redisClusterListStore is ClusterListStore keeping cluster lists in Redis,
+// so the cache can be shared by all Smart Proxy instances
+type redisClusterListStore struct {
+	connection *redisV9.Client
+}
+
+// NewRedisClusterListStore constructs new store for cluster lists using
+// given Redis connection
+func NewRedisClusterListStore(connection *redisV9.Client) ClusterListStore {
+	return &redisClusterListStore{
+		connection: connection,
+	}
+}
+
+// Get returns cluster list stored for given organization and filters
+func (s *redisClusterListStore) Get(orgID types.OrgID, filterKey string) (CachedClusterList, bool, error) {
+	var entry redisClusterListEntry
+
+	key := fmt.Sprintf(ClusterListCacheKey, orgID)
+	ctx := context.Background()
+	value, err := s.connection.HGet(ctx, key, filterKey).Bytes()
+	if err == redisV9.Nil {
+		return entry.CachedClusterList, false, nil
+	}
+	if err != nil {
+		return entry.CachedClusterList, false, err
+	}
+
+	if err := json.Unmarshal(value, &entry); err != nil {
+		return entry.CachedClusterList, false, err
+	}
+
+	if time.Now().After(entry.ExpiresAt) {
+		// the field outlived its expiration, because other lists of the
+		// organization were stored meanwhile. It's not deleted, because
+		// other instance may have just replaced it by a fresh list, it's
+		// overwritten by Set or expires together with the key instead.
+		return CachedClusterList{}, false, nil
+	}
+
+	return entry.CachedClusterList, true, nil
+}
+
+// Set stores cluster list for given organization and filters
+func (s *redisClusterListStore) Set(
+	orgID types.OrgID, filterKey string, entry CachedClusterList, expiration time.Duration,
+) error {
+	value, err := json.Marshal(redisClusterListEntry{
+		CachedClusterList: entry,
+		ExpiresAt:         time.Now().Add(expiration),
+	})
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf(ClusterListCacheKey, orgID)
+	ctx := context.Background()
+
+	_, err = s.connection.TxPipelined(ctx, func(pipe redisV9.Pipeliner) error {
+		pipe.HSet(ctx, key, filterKey, value)
+		pipe.Expire(ctx, key, expiration)
+		return nil
+	})
+	return err
+}
+
+// Invalidate removes all cluster lists stored for given organization
+func (s *redisClusterListStore) Invalidate(orgID types.OrgID) error {
+	key := fmt.Sprintf(ClusterListCacheKey, orgID)
+	return s.connection.Del(context.Background(), key).Err()
+}
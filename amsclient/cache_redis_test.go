@@ -0,0 +1,85 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amsclient_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
+)
+
+// storedClusterList returns hash field value of cached cluster list expiring
+// at given time
+func storedClusterList(t *testing.T, expiresAt time.Time) string {
+	value, err := json.Marshal(map[string]interface{}{
+		"fetched_at": expiresAt.Add(-time.Minute),
+		"clusters":   testdata.ClusterInfoResult2Clusters,
+		"expires_at": expiresAt,
+	})
+	helpers.FailOnError(t, err)
+	return string(value)
+}
+
+func TestRedisClusterListStoreGet(t *testing.T) {
+	connection, server := redismock.NewClientMock()
+	store := amsclient.NewRedisClusterListStore(connection)
+	key := fmt.Sprintf(amsclient.ClusterListCacheKey, testdata.OrgID)
+
+	server.ExpectHGet(key, "filter").SetVal(storedClusterList(t, time.Now().Add(time.Minute)))
+
+	entry, found, err := store.Get(testdata.OrgID, "filter")
+	helpers.FailOnError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, testdata.ClusterInfoResult2Clusters, entry.Clusters)
+
+	helpers.RedisExpectationsMet(t, server)
+}
+
+func TestRedisClusterListStoreGetExpiredField(t *testing.T) {
+	connection, server := redismock.NewClientMock()
+	store := amsclient.NewRedisClusterListStore(connection)
+	key := fmt.Sprintf(amsclient.ClusterListCacheKey, testdata.OrgID)
+
+	// the hash is still stored, because other filter was written recently.
+	// The field is not deleted, so no other command is expected.
+	server.ExpectHGet(key, "filter").SetVal(storedClusterList(t, time.Now().Add(-time.Second)))
+
+	_, found, err := store.Get(testdata.OrgID, "filter")
+	helpers.FailOnError(t, err)
+	assert.False(t, found)
+
+	helpers.RedisExpectationsMet(t, server)
+}
+
+func TestRedisClusterListStoreGetMissing(t *testing.T) {
+	connection, server := redismock.NewClientMock()
+	store := amsclient.NewRedisClusterListStore(connection)
+
+	server.ExpectHGet(fmt.Sprintf(amsclient.ClusterListCacheKey, testdata.OrgID), "filter").RedisNil()
+
+	_, found, err := store.Get(testdata.OrgID, "filter")
+	helpers.FailOnError(t, err)
+	assert.False(t, found)
+
+	helpers.RedisExpectationsMet(t, server)
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amsclient_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// countingAMSClient counts calls of GetClustersForOrganization
+type countingAMSClient struct {
+	amsclient.AMSClient
+	calls int32
+	err   error
+}
+
+func (c *countingAMSClient) GetClustersForOrganization(
+	orgID types.OrgID, statusFilter, statusNegativeFilter []string,
+) ([]types.ClusterInfo, error) {
+	atomic.AddInt32(&c.calls, 1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.AMSClient.GetClustersForOrganization(orgID, statusFilter, statusNegativeFilter)
+}
+
+func (c *countingAMSClient) count() int {
+	return int(atomic.LoadInt32(&c.calls))
+}
+
+func newCountingAMSClient() *countingAMSClient {
+	return &countingAMSClient{
+		AMSClient: helpers.AMSClientWithOrgResults(testdata.OrgID, testdata.ClusterInfoResult2Clusters),
+	}
+}
+
+func TestCachingAMSClientReturnsCachedList(t *testing.T) {
+	wrapped := newCountingAMSClient()
+	client := amsclient.NewCachingAMSClient(wrapped, amsclient.Configuration{CacheTTL: time.Minute}, nil)
+
+	for i := 0; i < 3; i++ {
+		clusters, err := client.GetClustersForOrganization(testdata.OrgID, nil, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, testdata.ClusterInfoResult2Clusters, clusters)
+	}
+
+	assert.Equal(t, 1, wrapped.count())
+}
+
+func TestCachingAMSClientDistinguishesFilters(t *testing.T) {
+	wrapped := newCountingAMSClient()
+	client := amsclient.NewCachingAMSClient(wrapped, amsclient.Configuration{CacheTTL: time.Minute}, nil)
+
+	_, err := client.GetClustersForOrganization(testdata.OrgID, nil, nil)
+	assert.NoError(t, err)
+	_, err = client.GetClustersForOrganization(testdata.OrgID, nil, []string{})
+	assert.NoError(t, err)
+	_, err = client.GetClustersForOrganization(testdata.OrgID, []string{amsclient.StatusArchived}, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, wrapped.count())
+}
+
+func TestCachingAMSClientExpiredEntry(t *testing.T) {
+	wrapped := newCountingAMSClient()
+	client := amsclient.NewCachingAMSClient(wrapped, amsclient.Configuration{CacheTTL: time.Millisecond}, nil)
+
+	_, err := client.GetClustersForOrganization(testdata.OrgID, nil, nil)
+	assert.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = client.GetClustersForOrganization(testdata.OrgID, nil, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, wrapped.count())
+}
+
+func TestCachingAMSClientStaleWhileRevalidate(t *testing.T) {
+	wrapped := newCountingAMSClient()
+	conf := amsclient.Configuration{
+		CacheTTL:      time.Millisecond,
+		CacheMaxStale: time.Minute,
+	}
+	client := amsclient.NewCachingAMSClient(wrapped, conf, nil)
+
+	_, err := client.GetClustersForOrganization(testdata.OrgID, nil, nil)
+	assert.NoError(t, err)
+
+	time.Sleep(5 * time.Millisecond)
+
+	// stale entry is returned and refreshed in background
+	clusters, err := client.GetClustersForOrganization(testdata.OrgID, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, testdata.ClusterInfoResult2Clusters, clusters)
+
+	assert.Eventually(t, func() bool {
+		return wrapped.count() == 2
+	}, time.Second, time.Millisecond)
+}
+
+func TestCachingAMSClientErrorIsNotCached(t *testing.T) {
+	wrapped := newCountingAMSClient()
+	wrapped.err = errors.New("AMS API error")
+	client := amsclient.NewCachingAMSClient(wrapped, amsclient.Configuration{CacheTTL: time.Minute}, nil)
+
+	_, err := client.GetClustersForOrganization(testdata.OrgID, nil, nil)
+	assert.Error(t, err)
+
+	wrapped.err = nil
+	clusters, err := client.GetClustersForOrganization(testdata.OrgID, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, testdata.ClusterInfoResult2Clusters, clusters)
+
+	assert.Equal(t, 2, wrapped.count())
+}
+
+func TestCachingAMSClientInvalidate(t *testing.T) {
+	wrapped := newCountingAMSClient()
+	client := amsclient.NewCachingAMSClient(wrapped, amsclient.Configuration{CacheTTL: time.Minute}, nil)
+
+	_, err := client.GetClustersForOrganization(testdata.OrgID, nil, nil)
+	assert.NoError(t, err)
+
+	invalidator, ok := client.(amsclient.ClusterListCacheInvalidator)
+	assert.True(t, ok)
+	assert.NoError(t, invalidator.InvalidateClusterListCache(testdata.OrgID))
+
+	_, err = client.GetClustersForOrganization(testdata.OrgID, nil, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, wrapped.count())
+}
@@ -14,11 +14,32 @@
 
 package amsclient
 
+import "time"
+
 // Configuration represents the configuration of the AMS API client
 type Configuration struct {
 	Token        string `mapstructure:"token" toml:"token"`
 	ClientID     string `mapstructure:"client_id" toml:"client_id"`
 	ClientSecret string `mapstructure:"client_secret" toml:"client_secret"`
 	URL          string `mapstructure:"url" toml:"url"`
+	TokenURL     string `mapstructure:"token_url" toml:"token_url"`
 	PageSize     int    `mapstructure:"page_size" toml:"page_size"`
+
+	// CacheTTL is time for which list of clusters for an organization is
+	// cached. Cache is disabled if not set
+	CacheTTL time.Duration `mapstructure:"cache_ttl" toml:"cache_ttl"`
+	// CacheMaxStale is time for which expired list of clusters can be
+	// returned while it is refreshed in background
+	CacheMaxStale time.Duration `mapstructure:"cache_max_stale" toml:"cache_max_stale"`
+	// CacheRedis enables storing cached lists of clusters in Redis
+	CacheRedis bool `mapstructure:"cache_redis" toml:"cache_redis"`
+
+	// OrgIDCacheSize is the maximum number of organizations for which
+	// internal organization ID is cached
+	OrgIDCacheSize int `mapstructure:"org_id_cache_size" toml:"org_id_cache_size"`
+	// OrgIDCacheTTL is time for which internal organization ID is cached
+	OrgIDCacheTTL time.Duration `mapstructure:"org_id_cache_ttl" toml:"org_id_cache_ttl"`
+	// OrgIDNegativeCacheTTL is time for which organization not known to AMS
+	// API is cached
+	OrgIDNegativeCacheTTL time.Duration `mapstructure:"org_id_negative_cache_ttl" toml:"org_id_negative_cache_ttl"`
 }
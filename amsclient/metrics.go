@@ -0,0 +1,78 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amsclient
+
+// Metrics exposed to Prometheus by AMS client. Currently, the following
+// metrics are exposed:
+//
+// ams_org_id_cache_hits - number of internal organization ID lookups served from cache
+//
+// ams_org_id_cache_misses - number of internal organization ID lookups sent to AMS API
+//
+// ams_org_id_lookup_failures - number of failed internal organization ID lookups
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// OrgIDCacheHits is a counter of internal organization ID lookups served
+	// from cache
+	OrgIDCacheHits prometheus.Counter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ams_org_id_cache_hits",
+		Help: "The total number of internal organization ID lookups served from cache",
+	})
+
+	// OrgIDCacheMisses is a counter of internal organization ID lookups sent
+	// to AMS API
+	OrgIDCacheMisses prometheus.Counter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ams_org_id_cache_misses",
+		Help: "The total number of internal organization ID lookups sent to AMS API",
+	})
+
+	// OrgIDLookupFailures is a counter of internal organization ID lookups
+	// that failed because of AMS API error
+	OrgIDLookupFailures prometheus.Counter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ams_org_id_lookup_failures",
+		Help: "The total number of internal organization ID lookups failed on AMS API error",
+	})
+)
+
+// AddMetricsWithNamespace overwrite the defined metrics with namespaced
+// version of them
+func AddMetricsWithNamespace(namespace string) {
+	prometheus.Unregister(OrgIDCacheHits)
+	prometheus.Unregister(OrgIDCacheMisses)
+	prometheus.Unregister(OrgIDLookupFailures)
+
+	OrgIDCacheHits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ams_org_id_cache_hits",
+		Help:      "The total number of internal organization ID lookups served from cache",
+	})
+
+	OrgIDCacheMisses = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ams_org_id_cache_misses",
+		Help:      "The total number of internal organization ID lookups sent to AMS API",
+	})
+
+	OrgIDLookupFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ams_org_id_lookup_failures",
+		Help:      "The total number of internal organization ID lookups failed on AMS API error",
+	})
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amsclient
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// defaultOrgIDCacheSize is the number of organizations kept in the cache
+	// when it is not defined in the configuration
+	defaultOrgIDCacheSize = 10000
+	// defaultOrgIDCacheTTL is the time for which internal organization ID is
+	// cached when it is not defined in the configuration
+	defaultOrgIDCacheTTL = 24 * time.Hour
+	// defaultOrgIDNegativeCacheTTL is the time for which organization unknown
+	// to AMS API is cached when it is not defined in the configuration
+	defaultOrgIDNegativeCacheTTL = 5 * time.Minute
+)
+
+// orgIDCache is a bounded LRU cache mapping external organization IDs to
+// internal ones. Organizations not known to AMS API are cached too, but for
+// shorter time.
+type orgIDCache struct {
+	mutex       sync.Mutex
+	size        int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	items       map[types.OrgID]*list.Element
+	order       *list.List
+}
+
+type orgIDCacheEntry struct {
+	orgID      types.OrgID
+	internalID string
+	known      bool
+	expiresAt  time.Time
+}
+
+// newOrgIDCache constructs new cache, default values are used for settings
+// that are not configured
+func newOrgIDCache(size int, ttl, negativeTTL time.Duration) *orgIDCache {
+	if size <= 0 {
+		size = defaultOrgIDCacheSize
+	}
+	if ttl <= 0 {
+		ttl = defaultOrgIDCacheTTL
+	}
+	if negativeTTL <= 0 {
+		negativeTTL = defaultOrgIDNegativeCacheTTL
+	}
+
+	return &orgIDCache{
+		size:        size,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		items:       make(map[types.OrgID]*list.Element),
+		order:       list.New(),
+	}
+}
+
+// get returns cached internal ID for given organization. The known flag is
+// false for organizations not known to AMS API, the hit flag is false when
+// there is no valid entry for the organization in the cache.
+func (c *orgIDCache) get(orgID types.OrgID) (internalID string, known, hit bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, found := c.items[orgID]
+	if !found {
+		return "", false, false
+	}
+
+	entry := element.Value.(*orgIDCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.items, orgID)
+		return "", false, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.internalID, entry.known, true
+}
+
+// add stores internal ID for given organization
+func (c *orgIDCache) add(orgID types.OrgID, internalID string) {
+	c.store(&orgIDCacheEntry{
+		orgID:      orgID,
+		internalID: internalID,
+		known:      true,
+		expiresAt:  time.Now().Add(c.ttl),
+	})
+}
+
+// addUnknown stores information that given organization is not known to AMS
+// API
+func (c *orgIDCache) addUnknown(orgID types.OrgID) {
+	c.store(&orgIDCacheEntry{
+		orgID:     orgID,
+		known:     false,
+		expiresAt: time.Now().Add(c.negativeTTL),
+	})
+}
+
+func (c *orgIDCache) store(entry *orgIDCacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, found := c.items[entry.orgID]; found {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.items[entry.orgID] = c.order.PushFront(entry)
+
+	// evict least recently used entries
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*orgIDCacheEntry).orgID)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amsclient_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/h2non/gock.v1"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// organizationEmptyResponse is AMS response for organization that is not known
+var organizationEmptyResponse = map[string]interface{}{
+	"kind":  "OrganizationList",
+	"page":  1,
+	"size":  0,
+	"total": 0,
+	"items": []map[string]interface{}{},
+}
+
+func expectOrganizationRequest(t *testing.T, orgID types.OrgID, response interface{}) {
+	helpers.GockExpectAPIRequest(t, defaultConfig.URL, &helpers.APIRequest{
+		Method:       http.MethodGet,
+		Endpoint:     organizationsSearchEndpoint,
+		EndpointArgs: []interface{}{orgID},
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: helpers.ToJSONString(response),
+	})
+}
+
+func expectSingleClusterInfoRequest(t *testing.T) {
+	helpers.GockExpectAPIRequest(t, defaultConfig.URL, &helpers.APIRequest{
+		Method:       http.MethodGet,
+		Endpoint:     singleClusterInfoEndpoint,
+		EndpointArgs: []interface{}{1, testdata.InternalOrgID, testdata.ClusterName1, defaultConfig.PageSize},
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: helpers.ToJSONString(testdata.SubscriptionsResponse),
+	})
+	helpers.GockExpectAPIRequest(t, defaultConfig.URL, &helpers.APIRequest{
+		Method:       http.MethodGet,
+		Endpoint:     singleClusterInfoEndpoint,
+		EndpointArgs: []interface{}{2, testdata.InternalOrgID, testdata.ClusterName1, defaultConfig.PageSize},
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+		Body: helpers.ToJSONString(testdata.SubscriptionEmptyResponse),
+	})
+}
+
+// TestInternalOrgIDIsCached checks that organizations endpoint is called just
+// once for repeated requests
+func TestInternalOrgIDIsCached(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+	c, err := amsclient.NewAMSClientWithTransport(defaultConfig, gock.DefaultTransport)
+	helpers.FailOnError(t, err)
+
+	hits := testutil.ToFloat64(amsclient.OrgIDCacheHits)
+	misses := testutil.ToFloat64(amsclient.OrgIDCacheMisses)
+
+	expectOrganizationRequest(t, testdata.ExternalOrgID, testdata.OrganizationResponse)
+	expectSingleClusterInfoRequest(t)
+	expectSingleClusterInfoRequest(t)
+
+	for i := 0; i < 2; i++ {
+		clusterInfo, err := c.GetSingleClusterInfoForOrganization(testdata.ExternalOrgID, testdata.ClusterName1)
+		helpers.FailOnError(t, err)
+		assert.Equal(t, testdata.ClusterDisplayName1, clusterInfo.DisplayName)
+	}
+
+	assert.Equal(t, hits+1, testutil.ToFloat64(amsclient.OrgIDCacheHits))
+	assert.Equal(t, misses+1, testutil.ToFloat64(amsclient.OrgIDCacheMisses))
+}
+
+// TestUnknownOrgIDIsCached checks that organizations not known to AMS API
+// are cached too
+func TestUnknownOrgIDIsCached(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+	c, err := amsclient.NewAMSClientWithTransport(defaultConfig, gock.DefaultTransport)
+	helpers.FailOnError(t, err)
+
+	hits := testutil.ToFloat64(amsclient.OrgIDCacheHits)
+
+	expectOrganizationRequest(t, testdata.ExternalOrgID, organizationEmptyResponse)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.GetClustersForOrganization(testdata.ExternalOrgID, nil, nil)
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, hits+1, testutil.ToFloat64(amsclient.OrgIDCacheHits))
+}
+
+// TestInternalOrgIDCacheEviction checks that least recently used organization
+// is evicted from the cache
+func TestInternalOrgIDCacheEviction(t *testing.T) {
+	defer helpers.CleanAfterGock(t)
+	conf := defaultConfig
+	conf.OrgIDCacheSize = 1
+	c, err := amsclient.NewAMSClientWithTransport(conf, gock.DefaultTransport)
+	helpers.FailOnError(t, err)
+
+	const unknownOrgID = types.OrgID(5678)
+
+	// first organization is looked up twice as it is evicted by the second one
+	expectOrganizationRequest(t, testdata.ExternalOrgID, testdata.OrganizationResponse)
+	expectSingleClusterInfoRequest(t)
+	expectOrganizationRequest(t, unknownOrgID, organizationEmptyResponse)
+	expectOrganizationRequest(t, testdata.ExternalOrgID, testdata.OrganizationResponse)
+	expectSingleClusterInfoRequest(t)
+
+	_, err = c.GetSingleClusterInfoForOrganization(testdata.ExternalOrgID, testdata.ClusterName1)
+	helpers.FailOnError(t, err)
+
+	_, err = c.GetSingleClusterInfoForOrganization(unknownOrgID, testdata.ClusterName1)
+	assert.Error(t, err)
+
+	_, err = c.GetSingleClusterInfoForOrganization(testdata.ExternalOrgID, testdata.ClusterName1)
+	helpers.FailOnError(t, err)
+}
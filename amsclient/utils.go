@@ -17,8 +17,37 @@ package amsclient
 import (
 	"fmt"
 	"strings"
+	"time"
+
+	accMgmt "github.com/openshift-online/ocm-sdk-go/accountsmgmt/v1"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// ProductOCP is the product name of self-managed OpenShift Container Platform clusters
+	ProductOCP = "OCP"
+	// ProductOSD is the product name of OpenShift Dedicated clusters
+	ProductOSD = "OSD"
+	// ProductROSA is the product name of Red Hat OpenShift Service on AWS clusters
+	ProductROSA = "ROSA"
+	// ProductARO is the product name of Azure Red Hat OpenShift clusters
+	ProductARO = "ARO"
 )
 
+// planProducts maps AMS plan IDs to product names. Plans not listed here are
+// reported as they are
+var planProducts = map[string]string{
+	"OCP":                    ProductOCP,
+	"OCP-AssistedInstall":    ProductOCP,
+	"OSD":                    ProductOSD,
+	"OSDTrial":               ProductOSD,
+	"MOA":                    ProductROSA,
+	"MOA-HostedControlPlane": ProductROSA,
+	"ROSA":                   ProductROSA,
+	"ARO":                    ProductARO,
+}
+
 // generateSearchParameter generates a search string for given org_id and desired statuses
 func generateSearchParameter(orgID string, allowedStatuses, disallowedStatuses []string) string {
 	searchQuery := fmt.Sprintf("organization_id is '%s' and cluster_id != ''", orgID)
@@ -35,3 +64,33 @@ func generateSearchParameter(orgID string, allowedStatuses, disallowedStatuses [
 
 	return searchQuery
 }
+
+// subscriptionVersion returns OpenShift version reported in subscription
+// metrics (if any)
+func subscriptionVersion(item *accMgmt.Subscription) types.Version {
+	for _, metrics := range item.Metrics() {
+		if version, ok := metrics.GetOpenshiftVersion(); ok && version != "" {
+			return types.Version(version)
+		}
+	}
+	return ""
+}
+
+// subscriptionProduct returns product name derived from subscription plan
+func subscriptionProduct(item *accMgmt.Subscription) string {
+	planID := item.Plan().ID()
+	if product, found := planProducts[planID]; found {
+		return product
+	}
+	return planID
+}
+
+// subscriptionCreatedAt returns the subscription creation time formatted
+// according to RFC 3339, or empty string if the time is not known
+func subscriptionCreatedAt(item *accMgmt.Subscription) types.Timestamp {
+	createdAt, ok := item.GetCreatedAt()
+	if !ok || createdAt.IsZero() {
+		return ""
+	}
+	return types.Timestamp(createdAt.UTC().Format(time.RFC3339))
+}
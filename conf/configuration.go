@@ -32,12 +32,9 @@ package conf
 
 import (
 	"bytes"
-	"encoding/csv"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 
 	clowder "github.com/redhatinsights/app-common-go/pkg/api/v1"
@@ -47,7 +44,6 @@ import (
 	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
 	"github.com/RedHatInsights/insights-results-smart-proxy/server"
 	"github.com/RedHatInsights/insights-results-smart-proxy/services"
-	types "github.com/RedHatInsights/insights-results-types"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
 )
@@ -159,8 +155,6 @@ func GetServerConfiguration() server.Configuration {
 		log.Fatal().Err(err).Msg("API V2: All customer facing APIs MUST serve the current OpenAPI specification")
 	}
 
-	Config.ServerConf.InternalRulesOrganizations = getInternalRulesOrganizations()
-
 	return Config.ServerConf
 }
 
@@ -229,58 +223,3 @@ func checkIfFileExists(path string) error {
 
 	return nil
 }
-
-func getInternalRulesOrganizations() []types.OrgID {
-	if !Config.ServerConf.EnableInternalRulesOrganizations {
-		log.Debug().Msg("Internal rules request filtering disabled")
-		return nil
-	}
-
-	if Config.SetupConf.InternalRulesOrganizationsCSVFile == "" {
-		log.Fatal().Msgf("Internal organizations enabled, but none supplied")
-	}
-
-	internalRulesCSVData, err := os.ReadFile(Config.SetupConf.InternalRulesOrganizationsCSVFile)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Internal organizations file could not be opened")
-	}
-
-	internalOrganizations, err := loadOrgIDsFromCSV(bytes.NewBuffer(internalRulesCSVData))
-	if err != nil {
-		log.Fatal().Err(err).Msg("Internal organizations CSV could not be processed")
-	}
-
-	log.Info().Msgf("Internal rules request filtering enabled. Organizations allowed: %v", internalOrganizations)
-	return internalOrganizations
-}
-
-// loadOrgIDsFromCSV creates a new CSV reader and returns a list of
-// organization IDs
-func loadOrgIDsFromCSV(r io.Reader) ([]types.OrgID, error) {
-	orgIDs := make([]types.OrgID, 0)
-
-	reader := csv.NewReader(r)
-
-	lines, err := reader.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("error reading CSV file: %v", err)
-	}
-
-	for index, line := range lines {
-		if index == 0 {
-			continue // skip header
-		}
-
-		orgID, err := strconv.ParseUint(line[0], 10, 64)
-		if err != nil {
-			return nil, fmt.Errorf(
-				"organization ID on line %v in CSV is not numerical. Found value: %v",
-				index+1, line[0],
-			)
-		}
-
-		orgIDs = append(orgIDs, types.OrgID(orgID))
-	}
-
-	return orgIDs, nil
-}
@@ -18,7 +18,6 @@ package conf_test
 
 import (
 	"os"
-	"strings"
 	"testing"
 	"time"
 
@@ -122,46 +121,10 @@ func TestLoadConfigurationFromFile(t *testing.T) {
 		UseHTTPS:                         false,
 		EnableCORS:                       true,
 		EnableInternalRulesOrganizations: false,
-		InternalRulesOrganizations:       []types.OrgID(nil),
+		InternalRulesOrganizations:       []types.OrgID{},
 	}, conf.GetServerConfiguration())
 }
 
-// TestGetInternalRulesOrganizations tests if the internal organizations CSV file gets loaded properly
-func TestGetInternalRulesOrganizations(t *testing.T) {
-	os.Clearenv()
-	mustSetEnv(t, "INSIGHTS_RESULTS_SMART_PROXY__SERVER__ENABLE_INTERNAL_RULES_ORGANIZATIONS", "true")
-
-	mustLoadConfiguration("tests/config1")
-
-	assert.Equal(t, []types.OrgID{
-		types.OrgID(1),
-		types.OrgID(2),
-		types.OrgID(3),
-	}, conf.GetInternalRulesOrganizations())
-}
-
-// TestLoadOrgIDsFromCSVExtraParam tests incorrect CSV format
-func TestLoadOrgIDsFromCSVExtraParam(t *testing.T) {
-	extraParamCSV := `OrgID
-1,2
-3
-`
-	r := strings.NewReader(extraParamCSV)
-	_, err := conf.LoadOrgIDsFromCSV(r)
-	assert.EqualError(t, err, "error reading CSV file: record on line 2: wrong number of fields")
-}
-
-// TestLoadOrgIDsFromCSVNonInt tests non-integer ID in CSV
-func TestLoadOrgIDsFromCSVNonInt(t *testing.T) {
-	nonIntIDCSV := `OrgID
-str
-3
-`
-	r := strings.NewReader(nonIntIDCSV)
-	_, err := conf.LoadOrgIDsFromCSV(r)
-	assert.EqualError(t, err, "organization ID on line 2 in CSV is not numerical. Found value: str")
-}
-
 func GetTmpConfigFile(configData string) (string, error) {
 	tmpFile, err := os.CreateTemp("/tmp", "tmp_config_*.toml")
 	if err != nil {
@@ -201,7 +164,7 @@ func TestLoadConfigurationFromEnv(t *testing.T) {
 		UseHTTPS:                         false,
 		EnableCORS:                       true,
 		EnableInternalRulesOrganizations: false,
-		InternalRulesOrganizations:       []types.OrgID(nil),
+		InternalRulesOrganizations:       []types.OrgID{},
 	}, conf.GetServerConfiguration())
 
 	expectedGroupsPollTime, _ := time.ParseDuration("60s")
@@ -27,7 +27,5 @@ package conf
 // to see why this trick is needed for using package internal
 // symbols (externally invisible) in unit tests.
 var (
-	ConfigFileEnvVariableName     = configFileEnvVariableName
-	GetInternalRulesOrganizations = getInternalRulesOrganizations
-	LoadOrgIDsFromCSV             = loadOrgIDsFromCSV
+	ConfigFileEnvVariableName = configFileEnvVariableName
 )
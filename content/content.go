@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/RedHatInsights/insights-operator-utils/generators"
@@ -32,12 +33,17 @@ import (
 )
 
 var (
-	ruleContentDirectory      *ctypes.RuleContentDirectory
-	ruleContentDirectoryReady = sync.NewCond(&sync.Mutex{})
-	stopUpdateContentLoop     = make(chan struct{})
-	rulesWithContentStorage   = getEmptyRulesWithContentMap()
-	contentDirectoryTimeout   = 5 * time.Second
-	dotReport                 = ".report"
+	// ruleContentDirectory holds *ctypes.RuleContentDirectory and
+	// rulesWithContentStorage holds *RulesWithContentStorage. Both are
+	// replaced as a whole when the content is reloaded, so readers never
+	// see partially loaded content.
+	ruleContentDirectory         atomic.Value
+	ruleContentDirectoryReady    = sync.NewCond(&sync.Mutex{})
+	stopUpdateContentLoop        = make(chan struct{})
+	rulesWithContentStorage      atomic.Value
+	emptyRulesWithContentStorage = getEmptyRulesWithContentMap()
+	contentDirectoryTimeout      = 5 * time.Second
+	dotReport                    = ".report"
 )
 
 type ruleIDAndErrorKey struct {
@@ -46,7 +52,9 @@ type ruleIDAndErrorKey struct {
 }
 
 // RulesWithContentStorage is a key:value structure to store processed rules.
-// It's thread safe
+// Storage is never modified once it's published by LoadRuleContent, so it's
+// safe to read it from more goroutines and all reads from one storage are
+// consistent even when the content is reloaded in the meantime.
 type RulesWithContentStorage struct {
 	rules            map[ctypes.RuleID]*ctypes.RuleContent
 	rulesWithContent map[ruleIDAndErrorKey]*types.RuleWithContent
@@ -55,30 +63,100 @@ type RulesWithContentStorage struct {
 	recommendationsWithContent map[ctypes.RuleID]*types.RuleWithContent
 	internalRuleIDs            []ctypes.RuleID
 	externalRuleIDs            []ctypes.RuleID
+	// hash identifies the loaded rule content directory and modifiedAt is
+	// the time when the content with this hash was loaded for the first time
+	hash       string
+	modifiedAt time.Time
+	// searchIndex is an inverted index over the content of error keys
+	searchIndex searchIndex
+}
+
+// Version identifies the rule content loaded by LoadRuleContent. Hash changes
+// only when the content itself changes and ModifiedAt is the time when the
+// content with this hash was loaded for the first time.
+type Version struct {
+	Hash       string
+	ModifiedAt time.Time
 }
 
 // SetRuleContentDirectory is made for easy testing fake rules etc. from other directories
 func SetRuleContentDirectory(contentDir *ctypes.RuleContentDirectory) {
-	ruleContentDirectory = contentDir
+	ruleContentDirectory.Store(contentDir)
+}
+
+// getRuleContentDirectory returns the rule content directory set by
+// SetRuleContentDirectory or nil if it has not been set yet
+func getRuleContentDirectory() *ctypes.RuleContentDirectory {
+	contentDir, _ := ruleContentDirectory.Load().(*ctypes.RuleContentDirectory)
+	return contentDir
+}
+
+// getRulesWithContentStorage returns the storage published by the last call
+// of LoadRuleContent
+func getRulesWithContentStorage() *RulesWithContentStorage {
+	if s, ok := rulesWithContentStorage.Load().(*RulesWithContentStorage); ok {
+		return s
+	}
+	return emptyRulesWithContentStorage
 }
 
 // GetRuleWithErrorKeyContent returns content for rule with error key
 func (s *RulesWithContentStorage) GetRuleWithErrorKeyContent(
 	ruleID ctypes.RuleID, errorKey ctypes.ErrorKey,
-) (*types.RuleWithContent, bool) {
+) (*types.RuleWithContent, error) {
+	ruleID = ctypes.RuleID(strings.TrimSuffix(string(ruleID), dotReport))
+
 	res, found := s.rulesWithContent[ruleIDAndErrorKey{
 		RuleID:   ruleID,
 		ErrorKey: errorKey,
 	}]
-	return res, found
+	if !found {
+		return nil, &utypes.ItemNotFoundError{ItemID: fmt.Sprintf("%v/%v", ruleID, errorKey)}
+	}
+
+	return res, nil
 }
 
-// GetContentForRecommendation returns content for rule with error key
+// GetContentForRecommendation returns content for rule with provided composite rule ID
 func (s *RulesWithContentStorage) GetContentForRecommendation(
 	ruleID ctypes.RuleID,
-) (*types.RuleWithContent, bool) {
+) (*types.RuleWithContent, error) {
 	res, found := s.recommendationsWithContent[ruleID]
-	return res, found
+	if !found {
+		return nil, &utypes.ItemNotFoundError{ItemID: fmt.Sprintf("%v", ruleID)}
+	}
+
+	return res, nil
+}
+
+// GetRuleContentV1 returns content for rule with provided `rule id` for api v1
+func (s *RulesWithContentStorage) GetRuleContentV1(ruleID ctypes.RuleID) (*types.RuleContentV1, error) {
+	res, err := s.getRuleContent(ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	resV1 := RuleContentToV1(res)
+	return &resV1, nil
+}
+
+// GetRuleContentV2 returns content for rule with provided `rule id` for api v2
+func (s *RulesWithContentStorage) GetRuleContentV2(ruleID ctypes.RuleID) (*types.RuleContentV2, error) {
+	res, err := s.getRuleContent(ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	resV2 := RuleContentToV2(res)
+	return &resV2, nil
+}
+
+// Version returns version of the content stored in this storage
+func (s *RulesWithContentStorage) Version() Version {
+	return Version{
+		Hash:       s.hash,
+		ModifiedAt: s.modifiedAt,
+	}
 }
 
 // GetAllContentV1 returns content for rule for api v1
@@ -142,6 +220,20 @@ func (s *RulesWithContentStorage) GetRuleIDs() []string {
 	return ruleIDs
 }
 
+// GetErrorKeysTags returns tags of every error key of the rule (rule module)
+func (s *RulesWithContentStorage) GetErrorKeysTags(ruleID ctypes.RuleID) [][]string {
+	rule, found := s.rules[ruleID]
+	if !found {
+		return nil
+	}
+
+	errorKeysTags := make([][]string, 0, len(rule.ErrorKeys))
+	for _, errorKey := range rule.ErrorKeys {
+		errorKeysTags = append(errorKeysTags, errorKey.Metadata.Tags)
+	}
+	return errorKeysTags
+}
+
 // GetInternalRuleIDs returns the composite rule IDs ("| format") of internal rules
 func (s *RulesWithContentStorage) GetInternalRuleIDs() []ctypes.RuleID {
 	return s.internalRuleIDs
@@ -197,7 +289,7 @@ func (e *RuleContentDirectoryTimeoutError) Error() string {
 func WaitForContentDirectoryToBeReady() error {
 	// according to the example in the official dock,
 	// lock is required here
-	if ruleContentDirectory == nil {
+	if getRuleContentDirectory() == nil {
 		ruleContentDirectoryReady.L.Lock()
 
 		done := make(chan struct{})
@@ -220,11 +312,18 @@ func WaitForContentDirectoryToBeReady() error {
 	return nil
 }
 
-// GetRuleWithErrorKeyContent returns content for rule with provided `rule id` and `error key`.
-// Caching is done under the hood, don't worry about it.
-func GetRuleWithErrorKeyContent(
-	ruleID ctypes.RuleID, errorKey ctypes.ErrorKey,
-) (*types.RuleWithContent, error) {
+// IsContentDirectoryReady checks if the rule content directory has been
+// already loaded. Unlike WaitForContentDirectoryToBeReady it never blocks, so
+// it is suitable for health checks.
+func IsContentDirectoryReady() bool {
+	return getRuleContentDirectory() != nil
+}
+
+// GetContentSnapshot returns the currently loaded rule content. The returned
+// storage is not affected by later reloads of the content, so handlers that
+// need more pieces of content to build one response should read all of them
+// from one snapshot instead of calling package level functions repeatedly.
+func GetContentSnapshot() (*RulesWithContentStorage, error) {
 	// to be sure the data is there
 	err := WaitForContentDirectoryToBeReady()
 
@@ -232,71 +331,53 @@ func GetRuleWithErrorKeyContent(
 		return nil, err
 	}
 
-	ruleID = ctypes.RuleID(strings.TrimSuffix(string(ruleID), dotReport))
+	return getRulesWithContentStorage(), nil
+}
 
-	res, found := rulesWithContentStorage.GetRuleWithErrorKeyContent(ruleID, errorKey)
-	if !found {
-		return nil, &utypes.ItemNotFoundError{ItemID: fmt.Sprintf("%v/%v", ruleID, errorKey)}
+// GetRuleWithErrorKeyContent returns content for rule with provided `rule id` and `error key`.
+// Caching is done under the hood, don't worry about it.
+func GetRuleWithErrorKeyContent(
+	ruleID ctypes.RuleID, errorKey ctypes.ErrorKey,
+) (*types.RuleWithContent, error) {
+	snapshot, err := GetContentSnapshot()
+	if err != nil {
+		return nil, err
 	}
 
-	return res, nil
+	return snapshot.GetRuleWithErrorKeyContent(ruleID, errorKey)
 }
 
 // GetContentForRecommendation returns content for rule with provided composite rule ID
 func GetContentForRecommendation(
 	ruleID ctypes.RuleID,
 ) (*types.RuleWithContent, error) {
-	err := WaitForContentDirectoryToBeReady()
-
+	snapshot, err := GetContentSnapshot()
 	if err != nil {
 		return nil, err
 	}
 
-	res, found := rulesWithContentStorage.GetContentForRecommendation(ruleID)
-	if !found {
-		return nil, &utypes.ItemNotFoundError{ItemID: fmt.Sprintf("%v", ruleID)}
-	}
-
-	return res, nil
+	return snapshot.GetContentForRecommendation(ruleID)
 }
 
 // GetRuleContentV1 returns content for rule with provided `rule id`
 // Caching is done under the hood, don't worry about it.
 func GetRuleContentV1(ruleID ctypes.RuleID) (*types.RuleContentV1, error) {
-	res, err := getRuleContent(ruleID)
-	if err == nil {
-		resV1 := RuleContentToV1(res)
-		return &resV1, nil
+	snapshot, err := GetContentSnapshot()
+	if err != nil {
+		return nil, err
 	}
-	return nil, err
+
+	return snapshot.GetRuleContentV1(ruleID)
 }
 
 // GetRuleContentV2 provides single rule for api v2
 func GetRuleContentV2(ruleID ctypes.RuleID) (*types.RuleContentV2, error) {
-	res, err := getRuleContent(ruleID)
-	if err == nil {
-		resV2 := RuleContentToV2(res)
-		return &resV2, nil
-	}
-	return nil, err
-}
-
-func getRuleContent(ruleID ctypes.RuleID) (*ctypes.RuleContent, error) {
-	// to be sure the data is there
-	err := WaitForContentDirectoryToBeReady()
-
+	snapshot, err := GetContentSnapshot()
 	if err != nil {
 		return nil, err
 	}
 
-	ruleID = ctypes.RuleID(strings.TrimSuffix(string(ruleID), dotReport))
-
-	res, found := rulesWithContentStorage.getRuleContent(ruleID)
-	if !found {
-		return nil, &utypes.ItemNotFoundError{ItemID: ruleID}
-	}
-
-	return res, nil
+	return snapshot.GetRuleContentV2(ruleID)
 }
 
 func getEmptyRulesWithContentMap() *RulesWithContentStorage {
@@ -309,6 +390,12 @@ func getEmptyRulesWithContentMap() *RulesWithContentStorage {
 	return &s
 }
 
+// GetContentVersion returns version of currently loaded rule content. Empty
+// hash is returned when no content has been loaded yet.
+func GetContentVersion() Version {
+	return getRulesWithContentStorage().Version()
+}
+
 // GetRuleIDs returns a list of rule IDs (rule modules)
 func GetRuleIDs() ([]string, error) {
 	err := WaitForContentDirectoryToBeReady()
@@ -317,7 +404,7 @@ func GetRuleIDs() ([]string, error) {
 		return nil, err
 	}
 
-	return rulesWithContentStorage.GetRuleIDs(), nil
+	return getRulesWithContentStorage().GetRuleIDs(), nil
 }
 
 // GetInternalRuleIDs returns a list of composite rule IDs ("| format") of internal rules
@@ -328,7 +415,7 @@ func GetInternalRuleIDs() ([]ctypes.RuleID, error) {
 		return nil, err
 	}
 
-	return rulesWithContentStorage.GetInternalRuleIDs(), nil
+	return getRulesWithContentStorage().GetInternalRuleIDs(), nil
 }
 
 // GetExternalRuleIDs returns a list of composite rule IDs ("| format") of external rules
@@ -339,7 +426,7 @@ func GetExternalRuleIDs() ([]ctypes.RuleID, error) {
 		return nil, err
 	}
 
-	return rulesWithContentStorage.GetExternalRuleIDs(), nil
+	return getRulesWithContentStorage().GetExternalRuleIDs(), nil
 }
 
 // GetExternalRuleSeverities returns a map of rule IDs and their severity (total risk),
@@ -355,7 +442,7 @@ func GetExternalRuleSeverities() (
 		return nil, nil, err
 	}
 
-	severityMap, uniqueSeverities := rulesWithContentStorage.GetExternalRuleSeverities()
+	severityMap, uniqueSeverities := getRulesWithContentStorage().GetExternalRuleSeverities()
 	return severityMap, uniqueSeverities, nil
 }
 
@@ -370,7 +457,7 @@ func GetExternalRulesManagedInfo() (
 		return nil, err
 	}
 
-	managedMap := rulesWithContentStorage.GetExternalRulesManagedInfo()
+	managedMap := getRulesWithContentStorage().GetExternalRulesManagedInfo()
 	return managedMap, nil
 }
 
@@ -383,7 +470,7 @@ func GetAllContentV1() ([]types.RuleContentV1, error) {
 		return nil, err
 	}
 
-	return rulesWithContentStorage.GetAllContentV1(), nil
+	return getRulesWithContentStorage().GetAllContentV1(), nil
 }
 
 // GetAllContentV2 returns content for api v2
@@ -395,24 +482,48 @@ func GetAllContentV2() ([]types.RuleContentV2, error) {
 		return nil, err
 	}
 
-	return rulesWithContentStorage.GetAllContentV2(), nil
+	return getRulesWithContentStorage().GetAllContentV2(), nil
 }
 
-// RunUpdateContentLoop runs loop which updates rules content by ticker
+// RunUpdateContentLoop runs loop which updates rules content by ticker. When
+// the content is read from local source and watching is enabled, the content
+// is updated also as soon as the source changes.
 func RunUpdateContentLoop(servicesConf services.Configuration) {
 	ticker := time.NewTicker(servicesConf.GroupsPollingTime)
+	defer ticker.Stop()
+
+	// nil channel blocks forever, so it's safe to select on it when the
+	// content is not watched
+	var contentChanges <-chan struct{}
+	if servicesConf.ContentWatch && servicesConf.IsLocalContentSource() {
+		watcher, err := services.NewContentWatcher(servicesConf)
+		if err != nil {
+			log.Error().Err(err).Msg("unable to watch local rules content, it will be updated periodically only")
+		} else {
+			defer closeContentWatcher(watcher)
+			contentChanges = watcher.Changes()
+		}
+	}
 
 	for {
 		UpdateContent(servicesConf)
 
 		select {
 		case <-ticker.C:
+		case <-contentChanges:
+			log.Info().Msg("local rules content changed, reloading")
 		case <-stopUpdateContentLoop:
 			return
 		}
 	}
 }
 
+func closeContentWatcher(watcher *services.ContentWatcher) {
+	if err := watcher.Close(); err != nil {
+		log.Error().Err(err).Msg("unable to stop watching local rules content")
+	}
+}
+
 // SetContentDirectoryTimeout sets the maximum duration for which
 // the smart proxy waits if the content directory is empty
 func SetContentDirectoryTimeout(timeout time.Duration) {
@@ -439,7 +550,7 @@ func UpdateContent(servicesConf services.Configuration) {
 	if err != nil {
 		return
 	}
-	LoadRuleContent(ruleContentDirectory)
+	LoadRuleContent(getRuleContentDirectory())
 }
 
 // FetchRuleContent - fetching content for particular rule
@@ -451,6 +562,27 @@ func FetchRuleContent(rule *ctypes.RuleOnReport, OSDEligible bool) (
 	ruleWithContentResponse *types.RuleWithContentResponse,
 	osdFiltered bool,
 	err error,
+) {
+	snapshot, err := GetContentSnapshot()
+	if err != nil {
+		log.Error().Err(err).Msgf(
+			"unable to get content for rule with id %v and error key %v", rule.Module, rule.ErrorKey,
+		)
+		return
+	}
+
+	return snapshot.FetchRuleContent(rule, OSDEligible)
+}
+
+// FetchRuleContent - fetching content for particular rule from the storage
+// Return values:
+//   - Structure with rules and content
+//   - return true if the rule has been filtered by OSDElegible field. False otherwise
+//   - return error if the one occurred during retrieval
+func (s *RulesWithContentStorage) FetchRuleContent(rule *ctypes.RuleOnReport, OSDEligible bool) (
+	ruleWithContentResponse *types.RuleWithContentResponse,
+	osdFiltered bool,
+	err error,
 ) {
 	ruleID := rule.Module
 	errorKey := rule.ErrorKey
@@ -458,7 +590,7 @@ func FetchRuleContent(rule *ctypes.RuleOnReport, OSDEligible bool) (
 	ruleWithContentResponse = nil
 	osdFiltered = false
 
-	ruleWithContent, err := GetRuleWithErrorKeyContent(ruleID, errorKey)
+	ruleWithContent, err := s.GetRuleWithErrorKeyContent(ruleID, errorKey)
 	if err != nil {
 		log.Error().Err(err).Msgf(
 			"unable to get content for rule with id %v and error key %v", ruleID, errorKey,
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net/http"
+	"sync"
 	"testing"
 	"time"
 
@@ -207,6 +208,96 @@ func TestResetContent(t *testing.T) {
 	assert.Equal(t, 0, len(ruleIDs))
 }
 
+// TestGetContentVersion checks that content version changes only when
+// different content is loaded
+func TestGetContentVersion(t *testing.T) {
+	defer content.ResetContent()
+
+	assert.Empty(t, content.GetContentVersion().Hash)
+
+	content.LoadRuleContent(&testdata.RuleContentDirectory3Rules)
+	version1 := content.GetContentVersion()
+	assert.NotEmpty(t, version1.Hash)
+	assert.False(t, version1.ModifiedAt.IsZero())
+
+	content.LoadRuleContent(&testdata.RuleContentDirectory3Rules)
+	assert.Equal(t, version1, content.GetContentVersion())
+
+	content.LoadRuleContent(&testdata.RuleContentDirectory5Rules)
+	assert.NotEqual(t, version1.Hash, content.GetContentVersion().Hash)
+}
+
+// TestConcurrentReloadAndRead reloads the content while reports are being
+// rendered from it. It's supposed to be run with -race flag. Everything read
+// from one snapshot must come from the same content directory.
+func TestConcurrentReloadAndRead(t *testing.T) {
+	defer content.ResetContent()
+
+	const (
+		reloads = 200
+		readers = 4
+	)
+
+	directories := []*ctypes.RuleContentDirectory{
+		&testdata.RuleContentDirectory3Rules,
+		&testdata.RuleContentDirectory5Rules,
+	}
+
+	// number of rules expected for each version of the content
+	expectedRules := make(map[string]int)
+	for _, directory := range directories {
+		content.LoadRuleContent(directory)
+		expectedRules[content.GetContentVersion().Hash] = len(directory.Rules)
+	}
+	content.SetRuleContentDirectory(directories[0])
+
+	rule := ctypes.RuleOnReport{
+		Module:       testdata.Rule1.Module,
+		ErrorKey:     testdata.RuleErrorKey1.ErrorKey,
+		TemplateData: testdata.Rule1ExtraData,
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				snapshot, err := content.GetContentSnapshot()
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				version := snapshot.Version()
+				assert.Len(t, snapshot.GetRuleIDs(), expectedRules[version.Hash])
+				assert.Len(t, snapshot.GetAllContentV2(), expectedRules[version.Hash])
+
+				ruleWithContent, _, err := snapshot.FetchRuleContent(&rule, false)
+				if assert.NoError(t, err) {
+					assert.Equal(t, testdata.RuleErrorKey1.Description, ruleWithContent.Description)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < reloads; i++ {
+		directory := directories[i%len(directories)]
+		content.SetRuleContentDirectory(directory)
+		content.LoadRuleContent(directory)
+	}
+
+	close(done)
+	wg.Wait()
+}
+
 func TestGetAllContent(t *testing.T) {
 	defer content.ResetContent()
 	content.LoadRuleContent(&testdata.RuleContentDirectory3Rules)
@@ -28,7 +28,14 @@ var (
 	RuleContentDirectoryReady = ruleContentDirectoryReady
 )
 
+// ParsedTemplatesCount returns number of templates cached for the current
+// content version
+func ParsedTemplatesCount() int {
+	return parsedTemplates.size()
+}
+
 // ResetContent clear all the content cached
 func ResetContent() {
-	rulesWithContentStorage = getEmptyRulesWithContentMap()
+	rulesWithContentStorage.Store(getEmptyRulesWithContentMap())
+	resetContentHistory()
 }
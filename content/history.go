@@ -0,0 +1,295 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+// History of loaded versions of the rule content. Last N published storages
+// are kept in memory, so it's possible to find out what has changed between
+// two content releases.
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// DefaultContentHistorySize is the number of content versions kept in
+// memory when it's not configured
+const DefaultContentHistorySize = 10
+
+var (
+	contentHistoryMutex sync.Mutex
+	// contentHistory contains storages with different content, the oldest
+	// first
+	contentHistory     []*RulesWithContentStorage
+	contentHistorySize = DefaultContentHistorySize
+)
+
+// SetContentHistorySize sets the number of content versions kept in memory.
+// Non-positive size sets the default size.
+func SetContentHistorySize(size int) {
+	if size <= 0 {
+		size = DefaultContentHistorySize
+	}
+
+	contentHistoryMutex.Lock()
+	defer contentHistoryMutex.Unlock()
+
+	contentHistorySize = size
+	trimContentHistory()
+}
+
+// recordContentVersion adds the storage to the history when its content
+// differs from the last recorded one
+func recordContentVersion(s *RulesWithContentStorage) {
+	if s.hash == "" {
+		// version of the content is not known
+		return
+	}
+
+	contentHistoryMutex.Lock()
+	defer contentHistoryMutex.Unlock()
+
+	if n := len(contentHistory); n > 0 && contentHistory[n-1].hash == s.hash {
+		return
+	}
+
+	contentHistory = append(contentHistory, s)
+	trimContentHistory()
+}
+
+// trimContentHistory removes the oldest versions over the history size. It
+// must be called with contentHistoryMutex locked.
+func trimContentHistory() {
+	if excess := len(contentHistory) - contentHistorySize; excess > 0 {
+		// copy, so removed storages are not referenced by the underlying
+		// array anymore
+		contentHistory = append([]*RulesWithContentStorage(nil), contentHistory[excess:]...)
+	}
+}
+
+// resetContentHistory forgets all recorded versions
+func resetContentHistory() {
+	contentHistoryMutex.Lock()
+	defer contentHistoryMutex.Unlock()
+
+	contentHistory = nil
+}
+
+// GetContentVersions returns versions of the rule content kept in memory,
+// the newest first
+func GetContentVersions() []types.ContentVersion {
+	contentHistoryMutex.Lock()
+	defer contentHistoryMutex.Unlock()
+
+	versions := make([]types.ContentVersion, 0, len(contentHistory))
+	for i := len(contentHistory) - 1; i >= 0; i-- {
+		versions = append(versions, contentHistory[i].contentVersion())
+	}
+	return versions
+}
+
+// GetContentDiff returns differences between two versions of the rule content
+// kept in memory. When toHash is empty, the current version is used. When
+// fromHash is empty, the version loaded before the toHash one is used.
+// Rules that are not visible are omitted.
+func GetContentDiff(fromHash, toHash string, visibility services.RuleVisibility) (*types.ContentDiff, error) {
+	from, to, err := findContentVersions(fromHash, toHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffStorages(from, to, visibility), nil
+}
+
+// findContentVersions finds storages with given hashes in the history
+func findContentVersions(fromHash, toHash string) (
+	from, to *RulesWithContentStorage, err error,
+) {
+	contentHistoryMutex.Lock()
+	defer contentHistoryMutex.Unlock()
+
+	toIndex := len(contentHistory) - 1
+	if toHash != "" {
+		toIndex = findContentVersion(toHash)
+	}
+	if toIndex < 0 {
+		if toHash == "" {
+			toHash = "current"
+		}
+		return nil, nil, &utypes.ItemNotFoundError{ItemID: "content version " + toHash}
+	}
+
+	fromIndex := toIndex - 1
+	if fromHash != "" {
+		fromIndex = findContentVersion(fromHash)
+	}
+	if fromIndex < 0 {
+		if fromHash == "" {
+			fromHash = fmt.Sprintf("preceding %s", contentHistory[toIndex].hash)
+		}
+		return nil, nil, &utypes.ItemNotFoundError{ItemID: "content version " + fromHash}
+	}
+
+	return contentHistory[fromIndex], contentHistory[toIndex], nil
+}
+
+// findContentVersion returns index of the storage with given hash in the
+// history or -1 when it's not found. It must be called with
+// contentHistoryMutex locked.
+func findContentVersion(hash string) int {
+	for i, s := range contentHistory {
+		if s.hash == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// contentVersion returns version of the storage in the form used by REST API
+func (s *RulesWithContentStorage) contentVersion() types.ContentVersion {
+	return types.ContentVersion{
+		Hash:     s.hash,
+		LoadedAt: s.modifiedAt,
+	}
+}
+
+// diffStorages compares content of two storages
+func diffStorages(from, to *RulesWithContentStorage, visibility services.RuleVisibility) *types.ContentDiff {
+	diff := types.ContentDiff{
+		From:             from.contentVersion(),
+		To:               to.contentVersion(),
+		AddedRules:       []ctypes.RuleID{},
+		RemovedRules:     []ctypes.RuleID{},
+		AddedErrorKeys:   []ctypes.RuleID{},
+		RemovedErrorKeys: []ctypes.RuleID{},
+		ChangedErrorKeys: []types.ErrorKeyDiff{},
+	}
+
+	for ruleID := range to.rules {
+		if _, found := from.rules[ruleID]; !found && visibility.Allows(ruleID, to.GetErrorKeysTags(ruleID)...) {
+			diff.AddedRules = append(diff.AddedRules, ruleID)
+		}
+	}
+	for ruleID := range from.rules {
+		if _, found := to.rules[ruleID]; !found && visibility.Allows(ruleID, from.GetErrorKeysTags(ruleID)...) {
+			diff.RemovedRules = append(diff.RemovedRules, ruleID)
+		}
+	}
+
+	for ruleID, newContent := range to.recommendationsWithContent {
+		if !visibility.Allows(newContent.Module, newContent.Tags) {
+			continue
+		}
+		oldContent, found := from.recommendationsWithContent[ruleID]
+		if !found {
+			diff.AddedErrorKeys = append(diff.AddedErrorKeys, ruleID)
+			continue
+		}
+		if changes := diffRuleWithContent(oldContent, newContent); len(changes) > 0 {
+			diff.ChangedErrorKeys = append(diff.ChangedErrorKeys, types.ErrorKeyDiff{
+				RuleID:  ruleID,
+				Changes: changes,
+			})
+		}
+	}
+	for ruleID, oldContent := range from.recommendationsWithContent {
+		if !visibility.Allows(oldContent.Module, oldContent.Tags) {
+			continue
+		}
+		if _, found := to.recommendationsWithContent[ruleID]; !found {
+			diff.RemovedErrorKeys = append(diff.RemovedErrorKeys, ruleID)
+		}
+	}
+
+	sortRuleIDs(diff.AddedRules)
+	sortRuleIDs(diff.RemovedRules)
+	sortRuleIDs(diff.AddedErrorKeys)
+	sortRuleIDs(diff.RemovedErrorKeys)
+	sort.Slice(diff.ChangedErrorKeys, func(i, j int) bool {
+		return diff.ChangedErrorKeys[i].RuleID < diff.ChangedErrorKeys[j].RuleID
+	})
+
+	return &diff
+}
+
+// diffRuleWithContent compares content of one error key field by field.
+// Field names are the same as in JSON representation of the content.
+func diffRuleWithContent(oldContent, newContent *types.RuleWithContent) []types.FieldChange {
+	fields := []struct {
+		name     string
+		oldValue interface{}
+		newValue interface{}
+	}{
+		{"name", oldContent.Name, newContent.Name},
+		{"description", oldContent.Description, newContent.Description},
+		{"generic", oldContent.Generic, newContent.Generic},
+		{"summary", oldContent.Summary, newContent.Summary},
+		{"reason", oldContent.Reason, newContent.Reason},
+		{"resolution", oldContent.Resolution, newContent.Resolution},
+		{"more_info", oldContent.MoreInfo, newContent.MoreInfo},
+		{"total_risk", oldContent.TotalRisk, newContent.TotalRisk},
+		{"resolution_risk", oldContent.ResolutionRisk, newContent.ResolutionRisk},
+		{"impact", oldContent.Impact, newContent.Impact},
+		{"likelihood", oldContent.Likelihood, newContent.Likelihood},
+		{"publish_date", formatPublishDate(oldContent.PublishDate), formatPublishDate(newContent.PublishDate)},
+		{"active", oldContent.Active, newContent.Active},
+		{"internal", oldContent.Internal, newContent.Internal},
+		{"tags", nonNilTags(oldContent.Tags), nonNilTags(newContent.Tags)},
+		{"osd_customer", oldContent.OSDCustomer, newContent.OSDCustomer},
+	}
+
+	var changes []types.FieldChange
+	for _, field := range fields {
+		if !reflect.DeepEqual(field.oldValue, field.newValue) {
+			changes = append(changes, types.FieldChange{
+				Field:    field.name,
+				OldValue: field.oldValue,
+				NewValue: field.newValue,
+			})
+		}
+	}
+	return changes
+}
+
+// formatPublishDate converts publish date to string, so dates are compared
+// regardless of their location and monotonic clock reading
+func formatPublishDate(publishDate time.Time) string {
+	if publishDate.IsZero() {
+		return ""
+	}
+	return publishDate.UTC().Format(time.RFC3339)
+}
+
+// nonNilTags makes missing and empty list of tags equal
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+func sortRuleIDs(ruleIDs []ctypes.RuleID) {
+	sort.Slice(ruleIDs, func(i, j int) bool {
+		return ruleIDs[i] < ruleIDs[j]
+	})
+}
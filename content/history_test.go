@@ -0,0 +1,172 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content_test
+
+import (
+	"testing"
+
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// modifiedRuleContentDirectory returns content directory with 3 rules where
+// the first error key of the first rule is changed
+func modifiedRuleContentDirectory() *ctypes.RuleContentDirectory {
+	errorKey := testdata.RuleContent1.ErrorKeys[testdata.ErrorKey1]
+	errorKey.Metadata.Impact.Impact = 3
+	errorKey.Metadata.Status = "active"
+	errorKey.Metadata.Tags = []string{"openshift", "security"}
+
+	rule := testdata.RuleContent1
+	rule.ErrorKeys = map[string]ctypes.RuleErrorKeyContent{
+		testdata.ErrorKey1: errorKey,
+	}
+
+	return &ctypes.RuleContentDirectory{
+		Config: testdata.RuleContentDirectory3Rules.Config,
+		Rules: map[string]ctypes.RuleContent{
+			"rc1": rule,
+			"rc2": testdata.RuleContent2,
+			"rc3": testdata.RuleContent3,
+		},
+	}
+}
+
+func TestGetContentVersions(t *testing.T) {
+	defer content.ResetContent()
+
+	assert.Empty(t, content.GetContentVersions())
+
+	content.LoadRuleContent(&testdata.RuleContentDirectory3Rules)
+	content.LoadRuleContent(&testdata.RuleContentDirectory3Rules)
+	version1 := content.GetContentVersion()
+	content.LoadRuleContent(&testdata.RuleContentDirectory5Rules)
+	version2 := content.GetContentVersion()
+
+	// the same content is recorded only once, the newest version first
+	versions := content.GetContentVersions()
+	assert.Len(t, versions, 2)
+	assert.Equal(t, version2.Hash, versions[0].Hash)
+	assert.Equal(t, version1.Hash, versions[1].Hash)
+	assert.Equal(t, version1.ModifiedAt, versions[1].LoadedAt)
+}
+
+func TestContentHistorySize(t *testing.T) {
+	defer content.ResetContent()
+	defer content.SetContentHistorySize(0)
+
+	content.SetContentHistorySize(2)
+
+	content.LoadRuleContent(&testdata.RuleContentDirectory3Rules)
+	version1 := content.GetContentVersion()
+	content.LoadRuleContent(&testdata.RuleContentDirectory5Rules)
+	content.LoadRuleContent(modifiedRuleContentDirectory())
+
+	versions := content.GetContentVersions()
+	assert.Len(t, versions, 2)
+	assert.Equal(t, content.GetContentVersion().Hash, versions[0].Hash)
+
+	// the oldest version has been forgotten
+	_, err := content.GetContentDiff(version1.Hash, "", allRules)
+	assert.Equal(t, &utypes.ItemNotFoundError{ItemID: "content version " + version1.Hash}, err)
+}
+
+func TestGetContentDiffAddedRules(t *testing.T) {
+	defer content.ResetContent()
+
+	content.LoadRuleContent(&testdata.RuleContentDirectory3Rules)
+	version1 := content.GetContentVersion()
+	content.LoadRuleContent(&testdata.RuleContentDirectory5Rules)
+	version2 := content.GetContentVersion()
+
+	// the current version is compared with the previous one by default
+	diff, err := content.GetContentDiff("", "", allRules)
+	helpers.FailOnError(t, err)
+
+	assert.Equal(t, version1.Hash, diff.From.Hash)
+	assert.Equal(t, version2.Hash, diff.To.Hash)
+	assert.Equal(t, []ctypes.RuleID{testdata.Rule4ID, testdata.Rule5ID}, diff.AddedRules)
+	assert.Empty(t, diff.RemovedRules)
+	assert.Equal(t, []ctypes.RuleID{testdata.Rule4CompositeID, testdata.Rule5CompositeID}, diff.AddedErrorKeys)
+	assert.Empty(t, diff.RemovedErrorKeys)
+	assert.Empty(t, diff.ChangedErrorKeys)
+
+	// reversed order of versions
+	diff, err = content.GetContentDiff(version2.Hash, version1.Hash, allRules)
+	helpers.FailOnError(t, err)
+
+	assert.Empty(t, diff.AddedRules)
+	assert.Equal(t, []ctypes.RuleID{testdata.Rule4ID, testdata.Rule5ID}, diff.RemovedRules)
+	assert.Empty(t, diff.AddedErrorKeys)
+	assert.Equal(t, []ctypes.RuleID{testdata.Rule4CompositeID, testdata.Rule5CompositeID}, diff.RemovedErrorKeys)
+}
+
+func TestGetContentDiffChangedErrorKey(t *testing.T) {
+	defer content.ResetContent()
+
+	content.LoadRuleContent(&testdata.RuleContentDirectory3Rules)
+	content.LoadRuleContent(modifiedRuleContentDirectory())
+
+	diff, err := content.GetContentDiff("", "", allRules)
+	helpers.FailOnError(t, err)
+
+	assert.Empty(t, diff.AddedRules)
+	assert.Empty(t, diff.RemovedRules)
+	assert.Empty(t, diff.AddedErrorKeys)
+	assert.Empty(t, diff.RemovedErrorKeys)
+	assert.Equal(t, []types.ErrorKeyDiff{
+		{
+			RuleID: testdata.Rule1CompositeID,
+			Changes: []types.FieldChange{
+				{Field: "total_risk", OldValue: 1, NewValue: 2},
+				{Field: "impact", OldValue: 1, NewValue: 3},
+				{Field: "active", OldValue: false, NewValue: true},
+				{
+					Field:    "tags",
+					OldValue: testdata.RuleErrorKey1.Tags,
+					NewValue: []string{"openshift", "security"},
+				},
+				{Field: "osd_customer", OldValue: true, NewValue: false},
+			},
+		},
+	}, diff.ChangedErrorKeys)
+}
+
+func TestGetContentDiffUnknownVersion(t *testing.T) {
+	defer content.ResetContent()
+
+	// nothing to compare
+	_, err := content.GetContentDiff("", "", allRules)
+	assert.Equal(t, &utypes.ItemNotFoundError{ItemID: "content version current"}, err)
+
+	content.LoadRuleContent(&testdata.RuleContentDirectory3Rules)
+	version := content.GetContentVersion()
+
+	// there's no version before the first one
+	_, err = content.GetContentDiff("", "", allRules)
+	assert.Equal(t, &utypes.ItemNotFoundError{ItemID: "content version preceding " + version.Hash}, err)
+
+	_, err = content.GetContentDiff("unknown", version.Hash, allRules)
+	assert.Equal(t, &utypes.ItemNotFoundError{ItemID: "content version unknown"}, err)
+
+	_, err = content.GetContentDiff(version.Hash, "unknown", allRules)
+	assert.Equal(t, &utypes.ItemNotFoundError{ItemID: "content version unknown"}, err)
+}
@@ -15,7 +15,11 @@
 package content
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -46,30 +50,25 @@ func LoadRuleContent(contentDir *ctypes.RuleContentDirectory) {
 	for i, rule := range contentDir.Rules {
 		ruleID := ctypes.RuleID(rule.Plugin.PythonModule)
 
+		// error keys are copied, so the published storage does not share
+		// any map with contentDir or with previously published storages
+		ruleTmp := contentDir.Rules[i]
+		ruleTmp.ErrorKeys = make(map[string]ctypes.RuleErrorKeyContent, len(rule.ErrorKeys))
 		for errorKey, errorProperties := range rule.ErrorKeys {
-			impact := errorProperties.Metadata.Impact
+			ruleTmp.ErrorKeys[errorKey] = errorProperties
+		}
 
-			// we allow empty/missing, but not incorrect
-			active, success, missing := getActiveStatus(errorProperties.Metadata.Status)
-			if !success {
-				log.Error().Msgf(`rule ID %v with key %v has invalid status attribute`, ruleID, errorKey)
-				continue
-			} else if missing {
-				log.Debug().Msgf(`rule ID %v with key %v has missing status attribute`, ruleID, errorKey)
-			}
+		for errorKey, errorProperties := range rule.ErrorKeys {
+			impact := errorProperties.Metadata.Impact
 
-			// we allow empty/missing, but not incorrect format
-			publishDate, missing, err := timeParse(errorProperties.Metadata.PublishDate)
+			active, publishDate, err := parseErrorKeyMetadata(ruleID, errorKey, errorProperties.Metadata)
 			if err != nil {
-				log.Error().Err(err).Msgf(`rule ID %v with key %v has improper publish_date attribute`, ruleID, errorKey)
+				log.Error().Err(err).Msgf(`rule ID %v with key %v is skipped`, ruleID, errorKey)
 				continue
-			} else if missing {
-				log.Debug().Msgf(`rule ID %v with key %v has missing publish_date attribute`, ruleID, errorKey)
 			}
 
 			totalRisk := calculateTotalRisk(impact.Impact, errorProperties.Metadata.Likelihood)
 
-			ruleTmp := contentDir.Rules[i]
 			if ruleTmpErrorKey, ok := ruleTmp.ErrorKeys[errorKey]; ok {
 				ruleTmpErrorKey.TotalRisk = totalRisk
 				ruleTmp.ErrorKeys[errorKey] = ruleTmpErrorKey
@@ -95,11 +94,77 @@ func LoadRuleContent(contentDir *ctypes.RuleContentDirectory) {
 				Active:         active,
 				Internal:       IsRuleInternal(ruleID),
 				Tags:           errorProperties.Metadata.Tags,
-				OSDCustomer:    collections.StringInSlice("osd_customer", errorProperties.Metadata.Tags),
+				OSDCustomer:    collections.StringInSlice(osdCustomerTag, errorProperties.Metadata.Tags),
 			})
 		}
 	}
-	rulesWithContentStorage = s
+
+	s.searchIndex = buildSearchIndex(s.recommendationsWithContent)
+
+	// contentDir is not modified above, so the hash does not depend on
+	// whether the directory has been loaded before
+	s.hash = ruleContentHash(contentDir)
+	s.modifiedAt = time.Now().UTC().Truncate(time.Second)
+
+	// content is reloaded periodically, keep the modification time if the
+	// content has not changed since the last load
+	if previous := getRulesWithContentStorage(); s.hash != "" && previous.hash == s.hash {
+		s.modifiedAt = previous.modifiedAt
+	}
+
+	// storage is published at once, so readers see either the previous or
+	// the new content, never a mix of them
+	rulesWithContentStorage.Store(s)
+	recordContentVersion(s)
+}
+
+// errorKeyMetadataError is returned by parseErrorKeyMetadata when the error
+// key can't be loaded
+type errorKeyMetadataError struct {
+	attribute string
+	err       error
+}
+
+func (e *errorKeyMetadataError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("improper %s attribute: %v", e.attribute, e.err)
+	}
+	return fmt.Sprintf("invalid %s attribute", e.attribute)
+}
+
+// parseErrorKeyMetadata parses status and publish date of the error key.
+// Both of them can be empty or missing, but not incorrect. Error keys with
+// incorrect metadata are skipped by LoadRuleContent.
+func parseErrorKeyMetadata(ruleID ctypes.RuleID, errorKey string, metadata ctypes.ErrorKeyMetadata) (
+	active bool, publishDate time.Time, err error,
+) {
+	active, success, missing := getActiveStatus(metadata.Status)
+	if !success {
+		return false, publishDate, &errorKeyMetadataError{attribute: "status"}
+	} else if missing {
+		log.Debug().Msgf(`rule ID %v with key %v has missing status attribute`, ruleID, errorKey)
+	}
+
+	publishDate, missing, err = timeParse(metadata.PublishDate)
+	if err != nil {
+		return false, publishDate, &errorKeyMetadataError{attribute: "publish_date", err: err}
+	} else if missing {
+		log.Debug().Msgf(`rule ID %v with key %v has missing publish_date attribute`, ruleID, errorKey)
+	}
+
+	return active, publishDate, nil
+}
+
+// ruleContentHash computes hash of the rule content directory. JSON encoding
+// is used as maps are serialized with sorted keys, so the hash is stable.
+func ruleContentHash(contentDir *ctypes.RuleContentDirectory) string {
+	encoded, err := json.Marshal(contentDir)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to compute hash of rule content")
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
 }
 
 // According to rule content specification, it's explicitly defined as floor((impact + likelihood) / 2), which
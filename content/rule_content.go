@@ -17,13 +17,23 @@
 package content
 
 import (
-	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+	"strings"
+
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
 	ctypes "github.com/RedHatInsights/insights-results-types"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
 )
 
-func (s *RulesWithContentStorage) getRuleContent(ruleID ctypes.RuleID) (*ctypes.RuleContent, bool) {
+func (s *RulesWithContentStorage) getRuleContent(ruleID ctypes.RuleID) (*ctypes.RuleContent, error) {
+	ruleID = ctypes.RuleID(strings.TrimSuffix(string(ruleID), dotReport))
+
 	res, found := s.rules[ruleID]
-	return res, found
+	if !found {
+		return nil, &utypes.ItemNotFoundError{ItemID: ruleID}
+	}
+
+	return res, nil
 }
 
 // RuleContentToV1 parses insights-results-types.RuleContent to RuleContentV1
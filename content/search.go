@@ -0,0 +1,283 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+// Full-text search over the rule content. Inverted index is built by
+// LoadRuleContent together with the rest of the storage, so it's replaced
+// atomically when the content is reloaded.
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/RedHatInsights/insights-operator-utils/collections"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// highlightStart and highlightEnd surround matched words in snippets
+	highlightStart = "<em>"
+	highlightEnd   = "</em>"
+	// snippetContext is the number of bytes preceding the first match that
+	// are included in the snippet
+	snippetContext = 60
+	// snippetLength is the maximal length of the snippet in bytes, not
+	// counting highlighting and ellipsis
+	snippetLength = 200
+	ellipsis      = "..."
+)
+
+// searchField is one indexed field of the error key content. Matches in
+// fields with higher weight are ranked higher.
+type searchField struct {
+	name   string
+	weight float64
+	value  func(*types.RuleWithContent) string
+}
+
+// searchFields are indexed fields ordered by their weight. Field names are
+// the same as in JSON representation of the content.
+var searchFields = []searchField{
+	{"description", 3, func(r *types.RuleWithContent) string { return r.Description }},
+	{"tags", 2, func(r *types.RuleWithContent) string { return strings.Join(r.Tags, ", ") }},
+	{"summary", 2, func(r *types.RuleWithContent) string { return r.Summary }},
+	{"generic", 1, func(r *types.RuleWithContent) string { return r.Generic }},
+	{"reason", 1, func(r *types.RuleWithContent) string { return r.Reason }},
+	{"resolution", 1, func(r *types.RuleWithContent) string { return r.Resolution }},
+}
+
+// searchIndex is an inverted index mapping terms to error keys (composite
+// rule IDs) containing them. Weight of the term in the error key is the sum
+// of weights of all fields the term occurs in, counting every occurrence.
+type searchIndex struct {
+	postings map[string]map[ctypes.RuleID]float64
+	size     int
+}
+
+// buildSearchIndex indexes content of given error keys
+func buildSearchIndex(recommendations map[ctypes.RuleID]*types.RuleWithContent) searchIndex {
+	index := searchIndex{
+		postings: make(map[string]map[ctypes.RuleID]float64),
+		size:     len(recommendations),
+	}
+
+	for ruleID, ruleWithContent := range recommendations {
+		for _, field := range searchFields {
+			for _, term := range tokenize(field.value(ruleWithContent)) {
+				documents, found := index.postings[term]
+				if !found {
+					documents = make(map[ctypes.RuleID]float64)
+					index.postings[term] = documents
+				}
+				documents[ruleID] += field.weight
+			}
+		}
+	}
+
+	return index
+}
+
+// isWordRune returns true for characters words consist of
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// tokenize splits text to lowercase words
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
+
+// queryTerms returns unique terms of the search query
+func queryTerms(query string) []string {
+	var terms []string
+	for _, term := range tokenize(query) {
+		if !collections.StringInSlice(term, terms) {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Search returns error keys containing all words of the query, the most
+// relevant first. Every term is scored by its weight in the error key
+// multiplied by its inverse document frequency. Rules that are not visible
+// are omitted.
+func (s *RulesWithContentStorage) Search(query string, visibility services.RuleVisibility) []types.ContentSearchResult {
+	results := []types.ContentSearchResult{}
+
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return results
+	}
+
+	scores := make(map[ctypes.RuleID]float64)
+	for i, term := range terms {
+		documents := s.searchIndex.postings[term]
+		idf := math.Log(1 + float64(s.searchIndex.size)/float64(len(documents)+1))
+
+		if i == 0 {
+			for ruleID, weight := range documents {
+				scores[ruleID] = weight * idf
+			}
+			continue
+		}
+
+		// error key needs to contain all terms
+		for ruleID, score := range scores {
+			if weight, found := documents[ruleID]; found {
+				scores[ruleID] = score + weight*idf
+			} else {
+				delete(scores, ruleID)
+			}
+		}
+	}
+
+	for ruleID, score := range scores {
+		ruleWithContent := s.recommendationsWithContent[ruleID]
+		if !visibility.Allows(ruleWithContent.Module, ruleWithContent.Tags) {
+			continue
+		}
+
+		results = append(results, types.ContentSearchResult{
+			RuleID:      ruleID,
+			Description: ruleWithContent.Description,
+			TotalRisk:   ruleWithContent.TotalRisk,
+			Score:       math.Round(score*1000) / 1000,
+			Snippets:    searchSnippets(ruleWithContent, terms),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].RuleID < results[j].RuleID
+	})
+
+	return results
+}
+
+// searchSnippets returns highlighted snippets of all fields containing any of
+// the terms
+func searchSnippets(ruleWithContent *types.RuleWithContent, terms []string) []types.ContentSearchSnippet {
+	snippets := []types.ContentSearchSnippet{}
+	for _, field := range searchFields {
+		if text, found := highlight(field.value(ruleWithContent), terms); found {
+			snippets = append(snippets, types.ContentSearchSnippet{
+				Field: field.name,
+				Text:  text,
+			})
+		}
+	}
+	return snippets
+}
+
+// wordBounds represents start and end byte offsets of a word in text
+type wordBounds struct {
+	start int
+	end   int
+}
+
+// matchingWords returns bounds of all words in text that are equal to any of
+// the terms
+func matchingWords(text string, terms []string) []wordBounds {
+	var matches []wordBounds
+
+	start := -1
+	checkWord := func(end int) {
+		if start >= 0 && collections.StringInSlice(strings.ToLower(text[start:end]), terms) {
+			matches = append(matches, wordBounds{start, end})
+		}
+		start = -1
+	}
+
+	for i, r := range text {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+		} else {
+			checkWord(i)
+		}
+	}
+	checkWord(len(text))
+
+	return matches
+}
+
+// highlight returns part of the text around the first word equal to any of
+// the terms with all such words highlighted. The text is HTML escaped, so
+// only the highlight tags are interpreted by the client. False is returned
+// when none of the terms is found.
+func highlight(text string, terms []string) (string, bool) {
+	matches := matchingWords(text, terms)
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	// cut the snippet on word boundaries
+	start := matches[0].start - snippetContext
+	if start <= 0 {
+		start = 0
+	} else if space := strings.IndexAny(text[start:matches[0].start], " \t\n"); space >= 0 {
+		start += space + 1
+	} else {
+		start = matches[0].start
+	}
+
+	end := start + snippetLength
+	if end >= len(text) {
+		end = len(text)
+	} else if space := strings.LastIndexAny(text[start:end], " \t\n"); space > matches[0].end-start {
+		end = start + space
+	} else {
+		end = matches[0].end
+	}
+
+	var snippet strings.Builder
+	if start > 0 {
+		snippet.WriteString(ellipsis)
+	}
+
+	position := start
+	for _, match := range matches {
+		if match.start < start {
+			continue
+		}
+		if match.end > end {
+			break
+		}
+		snippet.WriteString(html.EscapeString(text[position:match.start]))
+		snippet.WriteString(highlightStart)
+		snippet.WriteString(html.EscapeString(text[match.start:match.end]))
+		snippet.WriteString(highlightEnd)
+		position = match.end
+	}
+	snippet.WriteString(html.EscapeString(text[position:end]))
+
+	if end < len(text) {
+		snippet.WriteString(ellipsis)
+	}
+
+	return strings.TrimSpace(snippet.String()), true
+}
@@ -0,0 +1,220 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	etcdBackupRuleID   = "ccx_rules_ocp.external.rules.etcd_backup|ETCD_BACKUP"
+	nodeMemoryRuleID   = "ccx_rules_ocp.external.rules.node_memory|NODE_MEMORY"
+	etcdInternalRuleID = "ccx_rules_ocp.internal.rules.etcd_debug|ETCD_DEBUG"
+)
+
+var (
+	// allRules allows all rules to be visible
+	allRules = services.RuleVisibility{}
+	// publicRules allows only rules that don't belong to any rule class
+	publicRules = services.NewOrganizationsVisibilityPolicy(nil).Visibility(1, "")
+)
+
+// searchRuleContent returns rule content with one error key
+func searchRuleContent(module, errorKey, description, reason string, tags []string) ctypes.RuleContent {
+	return ctypes.RuleContent{
+		Plugin: ctypes.RulePluginInfo{
+			Name:         module,
+			PythonModule: module,
+		},
+		ErrorKeys: map[string]ctypes.RuleErrorKeyContent{
+			errorKey: {
+				Summary: "Rule summary",
+				Reason:  reason,
+				Metadata: ctypes.ErrorKeyMetadata{
+					Description: description,
+					Impact:      ctypes.Impact{Impact: 2},
+					Likelihood:  2,
+					PublishDate: "2023-01-01 10:00:00",
+					Status:      "active",
+					Tags:        tags,
+				},
+			},
+		},
+	}
+}
+
+// setAndLoadRuleContent loads the content and marks it as ready, so
+// snapshot of the content can be retrieved
+func setAndLoadRuleContent(contentDir *ctypes.RuleContentDirectory) {
+	content.SetRuleContentDirectory(contentDir)
+	content.LoadRuleContent(contentDir)
+}
+
+func loadSearchContent() {
+	setAndLoadRuleContent(&ctypes.RuleContentDirectory{
+		Config: testdata.RuleContentDirectory3Rules.Config,
+		Rules: map[string]ctypes.RuleContent{
+			"etcd_backup": searchRuleContent(
+				"ccx_rules_ocp.external.rules.etcd_backup", "ETCD_BACKUP",
+				"Etcd backup is missing", "No backup of etcd found.",
+				[]string{"etcd", "security"},
+			),
+			"node_memory": searchRuleContent(
+				"ccx_rules_ocp.external.rules.node_memory", "NODE_MEMORY",
+				"Node has low memory", "The etcd member running on the node might be killed.",
+				[]string{"performance"},
+			),
+			"etcd_debug": searchRuleContent(
+				"ccx_rules_ocp.internal.rules.etcd_debug", "ETCD_DEBUG",
+				"Etcd debug logging is enabled", "",
+				[]string{"etcd"},
+			),
+		},
+	})
+}
+
+func searchResultIDs(results []types.ContentSearchResult) []ctypes.RuleID {
+	ruleIDs := []ctypes.RuleID{}
+	for _, result := range results {
+		ruleIDs = append(ruleIDs, result.RuleID)
+	}
+	return ruleIDs
+}
+
+func TestSearchRanking(t *testing.T) {
+	defer content.ResetContent()
+	loadSearchContent()
+
+	snapshot, err := content.GetContentSnapshot()
+	helpers.FailOnError(t, err)
+
+	// match in description and tags is ranked higher than match in reason,
+	// internal rule is omitted
+	results := snapshot.Search("ETCD", publicRules)
+	assert.Equal(t, []ctypes.RuleID{etcdBackupRuleID, nodeMemoryRuleID}, searchResultIDs(results))
+	assert.Greater(t, results[0].Score, results[1].Score)
+	assert.Equal(t, "Etcd backup is missing", results[0].Description)
+
+	results = snapshot.Search("etcd", allRules)
+	assert.ElementsMatch(t,
+		[]ctypes.RuleID{etcdBackupRuleID, nodeMemoryRuleID, etcdInternalRuleID},
+		searchResultIDs(results),
+	)
+
+	// all words are required
+	results = snapshot.Search("etcd, backup!", publicRules)
+	assert.Equal(t, []ctypes.RuleID{etcdBackupRuleID}, searchResultIDs(results))
+
+	assert.Empty(t, snapshot.Search("etcd kubelet", allRules))
+	assert.Empty(t, snapshot.Search("  ", allRules))
+}
+
+func TestSearchSnippets(t *testing.T) {
+	defer content.ResetContent()
+	loadSearchContent()
+
+	snapshot, err := content.GetContentSnapshot()
+	helpers.FailOnError(t, err)
+
+	results := snapshot.Search("etcd", publicRules)
+	assert.Equal(t, []types.ContentSearchSnippet{
+		{Field: "description", Text: "<em>Etcd</em> backup is missing"},
+		{Field: "tags", Text: "<em>etcd</em>, security"},
+		{Field: "reason", Text: "No backup of <em>etcd</em> found."},
+	}, results[0].Snippets)
+}
+
+func TestSearchLongSnippet(t *testing.T) {
+	defer content.ResetContent()
+
+	reason := strings.Repeat("lorem ipsum ", 20) + "etcd " + strings.Repeat("dolor sit amet ", 30)
+	setAndLoadRuleContent(&ctypes.RuleContentDirectory{
+		Config: testdata.RuleContentDirectory3Rules.Config,
+		Rules: map[string]ctypes.RuleContent{
+			"long": searchRuleContent(
+				"ccx_rules_ocp.external.rules.long", "LONG", "Long reason", reason, nil,
+			),
+		},
+	})
+
+	snapshot, err := content.GetContentSnapshot()
+	helpers.FailOnError(t, err)
+
+	results := snapshot.Search("etcd", publicRules)
+	assert.Len(t, results, 1)
+	assert.Len(t, results[0].Snippets, 1)
+
+	snippet := results[0].Snippets[0].Text
+	assert.True(t, strings.HasPrefix(snippet, "..."), snippet)
+	assert.True(t, strings.HasSuffix(snippet, "..."), snippet)
+	assert.Contains(t, snippet, "ipsum <em>etcd</em> dolor")
+	assert.Less(t, len(snippet), len(reason))
+}
+
+// TestSearchSnippetEscaping checks that only the highlight tags aren't
+// escaped in the snippet
+func TestSearchSnippetEscaping(t *testing.T) {
+	defer content.ResetContent()
+
+	reason := "Value of <b>etcd</b> & \"quota\" is < 2"
+	setAndLoadRuleContent(&ctypes.RuleContentDirectory{
+		Config: testdata.RuleContentDirectory3Rules.Config,
+		Rules: map[string]ctypes.RuleContent{
+			"html": searchRuleContent(
+				"ccx_rules_ocp.external.rules.html", "HTML", "Html reason", reason, nil,
+			),
+		},
+	})
+
+	snapshot, err := content.GetContentSnapshot()
+	helpers.FailOnError(t, err)
+
+	results := snapshot.Search("etcd", publicRules)
+	assert.Len(t, results, 1)
+	assert.Equal(t, []types.ContentSearchSnippet{
+		{
+			Field: "reason",
+			Text:  "Value of &lt;b&gt;<em>etcd</em>&lt;/b&gt; &amp; &#34;quota&#34; is &lt; 2",
+		},
+	}, results[0].Snippets)
+}
+
+// TestSearchAfterReload checks that the index is replaced together with the
+// content
+func TestSearchAfterReload(t *testing.T) {
+	defer content.ResetContent()
+	loadSearchContent()
+
+	setAndLoadRuleContent(&testdata.RuleContentDirectory3Rules)
+
+	snapshot, err := content.GetContentSnapshot()
+	helpers.FailOnError(t, err)
+
+	assert.Empty(t, snapshot.Search("etcd", allRules))
+	assert.Equal(t,
+		[]ctypes.RuleID{testdata.Rule1CompositeID},
+		searchResultIDs(snapshot.Search("description1", allRules)),
+	)
+}
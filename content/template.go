@@ -0,0 +1,1108 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+// Rendering of rule content templates. Generic, reason and resolution of the
+// rules are doT templates (https://olado.github.io/doT/) filled by extra_data
+// of the rule hit, available as "pydata" variable. The subset of doT used by
+// the rules is supported:
+//
+//	{{= expression }}                 interpolation
+//	{{! expression }}                 interpolation with HTML encoding
+//	{{? cond }} ... {{?? cond }} ... {{??}} ... {{?}}   conditionals
+//	{{~ array :item:index }} ... {{~}}                   iteration
+//
+// Expressions can contain property access (a.b, a["b"], a[0], a.length),
+// string, number and boolean literals, comparison, logical and + - operators
+// with JavaScript-like semantics.
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// templateDataVariable is the name of the variable containing
+	// extra_data of the rule hit
+	templateDataVariable = "pydata"
+
+	tagStart = "{{"
+	tagEnd   = "}}"
+)
+
+// TemplateError is returned when the template can not be parsed or rendered
+type TemplateError struct {
+	ErrString string
+}
+
+func (e *TemplateError) Error() string {
+	return e.ErrString
+}
+
+func templateErrorf(format string, args ...interface{}) error {
+	return &TemplateError{ErrString: fmt.Sprintf(format, args...)}
+}
+
+// RenderTemplate fills the template by given data (extra_data of the rule
+// hit). Rendered text is markdown, the same as the template.
+func RenderTemplate(template string, data interface{}) (string, error) {
+	if !strings.Contains(template, tagStart) {
+		return template, nil
+	}
+
+	nodes, err := parsedTemplates.parse(getRulesWithContentStorage().hash, template)
+	if err != nil {
+		return "", err
+	}
+
+	data, err = normalizeTemplateData(data)
+	if err != nil {
+		return "", err
+	}
+
+	var output strings.Builder
+	err = renderNodes(&output, nodes, map[string]interface{}{templateDataVariable: data})
+	if err != nil {
+		return "", err
+	}
+	return output.String(), nil
+}
+
+// templateCache keeps templates parsed for one version of the rule content,
+// so they are not parsed again on every request. Templates parsed for
+// previous versions are dropped when the version changes.
+type templateCache struct {
+	mutex     sync.RWMutex
+	version   string
+	templates map[string]parsedTemplate
+}
+
+// parsedTemplate is the result of parsing of one template
+type parsedTemplate struct {
+	nodes []templateNode
+	err   error
+}
+
+// parsedTemplates caches templates of the currently loaded rule content
+var parsedTemplates templateCache
+
+// parse returns parsed template from the cache, the template is parsed and
+// stored into the cache when it's not found. Parsed nodes are never
+// modified, so they can be shared by more renderings.
+func (c *templateCache) parse(version, template string) ([]templateNode, error) {
+	c.mutex.RLock()
+	parsed, found := c.templates[template]
+	found = found && c.version == version
+	c.mutex.RUnlock()
+	if found {
+		return parsed.nodes, parsed.err
+	}
+
+	nodes, err := parseTemplate(template)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.version != version || c.templates == nil {
+		c.version = version
+		c.templates = make(map[string]parsedTemplate)
+	}
+	c.templates[template] = parsedTemplate{nodes: nodes, err: err}
+	return nodes, err
+}
+
+// size returns number of cached templates
+func (c *templateCache) size() int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return len(c.templates)
+}
+
+// normalizeTemplateData converts data to the form produced by
+// json.Unmarshal into interface{}, so only maps, slices, strings, float64
+// numbers, booleans and nils need to be handled when rendering
+func normalizeTemplateData(data interface{}) (interface{}, error) {
+	var encoded []byte
+	switch value := data.(type) {
+	case nil:
+		return nil, nil
+	case json.RawMessage:
+		encoded = value
+	case []byte:
+		encoded = value
+	default:
+		var err error
+		encoded, err = json.Marshal(value)
+		if err != nil {
+			return nil, templateErrorf("invalid template data: %v", err)
+		}
+	}
+
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return nil, templateErrorf("invalid template data: %v", err)
+	}
+	return normalized, nil
+}
+
+// template nodes
+
+type templateNode interface{}
+
+type textNode struct {
+	text string
+}
+
+type interpolationNode struct {
+	expression expression
+	encode     bool
+}
+
+type conditionalBranch struct {
+	// condition is nil for the else branch
+	condition expression
+	body      []templateNode
+}
+
+type conditionalNode struct {
+	branches []conditionalBranch
+}
+
+type iterationNode struct {
+	array     expression
+	itemName  string
+	indexName string
+	body      []templateNode
+}
+
+// templateTag is one {{...}} tag of the template
+type templateTag struct {
+	kind    string
+	content string
+}
+
+var iterationRegexp = regexp.MustCompile(`^\s*(.+?)\s*:\s*([A-Za-z_$][\w$]*)\s*(?::\s*([A-Za-z_$][\w$]*)\s*)?$`)
+
+// templateParser builds the tree of nodes from the template
+type templateParser struct {
+	template string
+	position int
+}
+
+func parseTemplate(template string) ([]templateNode, error) {
+	parser := templateParser{template: template}
+	nodes, closing, err := parser.parseNodes()
+	if err != nil {
+		return nil, err
+	}
+	if closing != nil {
+		return nil, templateErrorf("unexpected {{%s%s}}", closing.kind, closing.content)
+	}
+	return nodes, nil
+}
+
+// nextTag returns text preceding the next tag and the tag itself. Nil tag is
+// returned at the end of the template.
+func (p *templateParser) nextTag() (string, *templateTag, error) {
+	start := strings.Index(p.template[p.position:], tagStart)
+	if start < 0 {
+		text := p.template[p.position:]
+		p.position = len(p.template)
+		return text, nil, nil
+	}
+	start += p.position
+
+	end := strings.Index(p.template[start:], tagEnd)
+	if end < 0 {
+		return "", nil, templateErrorf("unterminated tag at offset %d", start)
+	}
+	end += start
+
+	text := p.template[p.position:start]
+	body := p.template[start+len(tagStart) : end]
+	p.position = end + len(tagEnd)
+
+	tag := templateTag{}
+	switch {
+	case strings.HasPrefix(body, "??"):
+		tag.kind, tag.content = "??", body[2:]
+	case body != "" && strings.ContainsRune("=!?~", rune(body[0])):
+		tag.kind, tag.content = body[:1], body[1:]
+	default:
+		return "", nil, templateErrorf("unsupported template tag {{%s}}", body)
+	}
+	tag.content = strings.TrimSpace(tag.content)
+	return text, &tag, nil
+}
+
+// parseNodes parses nodes until the end of the template or until a tag that
+// closes the current block ({{?}}, {{??...}} or {{~}}), which is returned
+func (p *templateParser) parseNodes() ([]templateNode, *templateTag, error) {
+	var nodes []templateNode
+	for {
+		text, tag, err := p.nextTag()
+		if err != nil {
+			return nil, nil, err
+		}
+		if text != "" {
+			nodes = append(nodes, textNode{text: text})
+		}
+		if tag == nil {
+			return nodes, nil, nil
+		}
+
+		switch {
+		case tag.kind == "=" || tag.kind == "!":
+			expr, err := parseExpression(tag.content)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, interpolationNode{expression: expr, encode: tag.kind == "!"})
+		case tag.kind == "?" && tag.content != "":
+			node, err := p.parseConditional(tag.content)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, node)
+		case tag.kind == "~" && tag.content != "":
+			node, err := p.parseIteration(tag.content)
+			if err != nil {
+				return nil, nil, err
+			}
+			nodes = append(nodes, node)
+		default:
+			// closing tag of the current block
+			return nodes, tag, nil
+		}
+	}
+}
+
+func (p *templateParser) parseConditional(condition string) (templateNode, error) {
+	node := conditionalNode{}
+	for {
+		expr, err := parseExpression(condition)
+		if err != nil {
+			return nil, err
+		}
+
+		body, closing, err := p.parseNodes()
+		if err != nil {
+			return nil, err
+		}
+		node.branches = append(node.branches, conditionalBranch{condition: expr, body: body})
+
+		switch {
+		case closing == nil:
+			return nil, templateErrorf("missing {{?}}")
+		case closing.kind == "?":
+			return node, nil
+		case closing.kind == "??" && closing.content != "":
+			condition = closing.content
+		case closing.kind == "??":
+			body, closing, err := p.parseNodes()
+			if err != nil {
+				return nil, err
+			}
+			if closing == nil || closing.kind != "?" {
+				return nil, templateErrorf("missing {{?}}")
+			}
+			node.branches = append(node.branches, conditionalBranch{body: body})
+			return node, nil
+		default:
+			return nil, templateErrorf("unexpected {{%s}} in conditional", closing.kind)
+		}
+	}
+}
+
+func (p *templateParser) parseIteration(content string) (templateNode, error) {
+	match := iterationRegexp.FindStringSubmatch(content)
+	if match == nil {
+		return nil, templateErrorf("invalid iteration {{~%s}}", content)
+	}
+
+	array, err := parseExpression(match[1])
+	if err != nil {
+		return nil, err
+	}
+
+	body, closing, err := p.parseNodes()
+	if err != nil {
+		return nil, err
+	}
+	if closing == nil || closing.kind != "~" {
+		return nil, templateErrorf("missing {{~}}")
+	}
+
+	return iterationNode{
+		array:     array,
+		itemName:  match[2],
+		indexName: match[3],
+		body:      body,
+	}, nil
+}
+
+func renderNodes(output *strings.Builder, nodes []templateNode, scope map[string]interface{}) error {
+	for _, node := range nodes {
+		if err := renderNode(output, node, scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderNode(output *strings.Builder, node templateNode, scope map[string]interface{}) error {
+	switch node := node.(type) {
+	case textNode:
+		output.WriteString(node.text)
+	case interpolationNode:
+		value, err := node.expression.evaluate(scope)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			return templateErrorf("value of %s is undefined", node.expression)
+		}
+		text := valueToString(value)
+		if node.encode {
+			text = html.EscapeString(text)
+		}
+		output.WriteString(text)
+	case conditionalNode:
+		for _, branch := range node.branches {
+			if branch.condition != nil {
+				value, err := branch.condition.evaluate(scope)
+				if err != nil {
+					return err
+				}
+				if !isTruthy(value) {
+					continue
+				}
+			}
+			return renderNodes(output, branch.body, scope)
+		}
+	case iterationNode:
+		value, err := node.array.evaluate(scope)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			// doT skips iteration over undefined arrays
+			return nil
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return templateErrorf("%s is not an array", node.array)
+		}
+		for index, item := range items {
+			itemScope := make(map[string]interface{}, len(scope)+2)
+			for name, value := range scope {
+				itemScope[name] = value
+			}
+			itemScope[node.itemName] = item
+			if node.indexName != "" {
+				itemScope[node.indexName] = float64(index)
+			}
+			if err := renderNodes(output, node.body, itemScope); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expressions
+
+type expression interface {
+	evaluate(scope map[string]interface{}) (interface{}, error)
+	String() string
+}
+
+type literalExpression struct {
+	value interface{}
+	text  string
+}
+
+type variableExpression struct {
+	name string
+}
+
+type memberExpression struct {
+	object   expression
+	property expression
+}
+
+type unaryExpression struct {
+	operator string
+	operand  expression
+}
+
+type binaryExpression struct {
+	operator string
+	left     expression
+	right    expression
+}
+
+func (e literalExpression) String() string  { return e.text }
+func (e variableExpression) String() string { return e.name }
+func (e memberExpression) String() string {
+	return fmt.Sprintf("%s[%s]", e.object, e.property)
+}
+func (e unaryExpression) String() string {
+	return e.operator + e.operand.String()
+}
+func (e binaryExpression) String() string {
+	return fmt.Sprintf("%s %s %s", e.left, e.operator, e.right)
+}
+
+func (e literalExpression) evaluate(map[string]interface{}) (interface{}, error) {
+	return e.value, nil
+}
+
+func (e variableExpression) evaluate(scope map[string]interface{}) (interface{}, error) {
+	value, found := scope[e.name]
+	if !found {
+		return nil, templateErrorf("%s is not defined", e.name)
+	}
+	return value, nil
+}
+
+func (e memberExpression) evaluate(scope map[string]interface{}) (interface{}, error) {
+	object, err := e.object.evaluate(scope)
+	if err != nil {
+		return nil, err
+	}
+	property, err := e.property.evaluate(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	switch object := object.(type) {
+	case nil:
+		return nil, templateErrorf("cannot read property %s of undefined %s", valueToString(property), e.object)
+	case map[string]interface{}:
+		return object[valueToString(property)], nil
+	case []interface{}:
+		if property == "length" {
+			return float64(len(object)), nil
+		}
+		if index, ok := property.(float64); ok && index >= 0 && int(index) < len(object) {
+			return object[int(index)], nil
+		}
+	case string:
+		if property == "length" {
+			return float64(len([]rune(object))), nil
+		}
+	}
+	// non-existing property is undefined
+	return nil, nil
+}
+
+func (e unaryExpression) evaluate(scope map[string]interface{}) (interface{}, error) {
+	value, err := e.operand.evaluate(scope)
+	if err != nil {
+		return nil, err
+	}
+	if e.operator == "!" {
+		return !isTruthy(value), nil
+	}
+	// unary minus
+	return -toNumber(value), nil
+}
+
+func (e binaryExpression) evaluate(scope map[string]interface{}) (interface{}, error) {
+	left, err := e.left.evaluate(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// logical operators are short-circuited and return one of the operands
+	switch e.operator {
+	case "&&":
+		if !isTruthy(left) {
+			return left, nil
+		}
+		return e.right.evaluate(scope)
+	case "||":
+		if isTruthy(left) {
+			return left, nil
+		}
+		return e.right.evaluate(scope)
+	}
+
+	right, err := e.right.evaluate(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.operator {
+	case "==":
+		return looseEquals(left, right), nil
+	case "!=":
+		return !looseEquals(left, right), nil
+	case "===":
+		return strictEquals(left, right), nil
+	case "!==":
+		return !strictEquals(left, right), nil
+	case "+":
+		_, leftIsString := left.(string)
+		_, rightIsString := right.(string)
+		if leftIsString || rightIsString {
+			return valueToString(left) + valueToString(right), nil
+		}
+		return toNumber(left) + toNumber(right), nil
+	case "-":
+		return toNumber(left) - toNumber(right), nil
+	default:
+		return compareValues(e.operator, left, right), nil
+	}
+}
+
+// isTruthy converts value to boolean the same way as JavaScript does
+func isTruthy(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case float64:
+		return value != 0 && !math.IsNaN(value)
+	case string:
+		return value != ""
+	default:
+		return true
+	}
+}
+
+// toNumber converts value to number the same way as JavaScript does
+func toNumber(value interface{}) float64 {
+	switch value := value.(type) {
+	case float64:
+		return value
+	case bool:
+		if value {
+			return 1
+		}
+		return 0
+	case string:
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return 0
+		}
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return math.NaN()
+		}
+		return number
+	default:
+		return math.NaN()
+	}
+}
+
+func strictEquals(left, right interface{}) bool {
+	switch left := left.(type) {
+	case nil, bool, float64, string:
+		return left == right
+	default:
+		// objects are equal only when they are identical, which can't be
+		// checked for decoded JSON
+		return false
+	}
+}
+
+func looseEquals(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == nil && right == nil
+	}
+	_, leftIsString := left.(string)
+	_, rightIsString := right.(string)
+	if leftIsString && rightIsString {
+		return left == right
+	}
+	if isPrimitive(left) && isPrimitive(right) {
+		return toNumber(left) == toNumber(right)
+	}
+	return strictEquals(left, right)
+}
+
+func isPrimitive(value interface{}) bool {
+	switch value.(type) {
+	case bool, float64, string:
+		return true
+	default:
+		return false
+	}
+}
+
+func compareValues(operator string, left, right interface{}) bool {
+	var cmp int
+	leftString, leftIsString := left.(string)
+	rightString, rightIsString := right.(string)
+	if leftIsString && rightIsString {
+		cmp = strings.Compare(leftString, rightString)
+	} else {
+		leftNumber, rightNumber := toNumber(left), toNumber(right)
+		if math.IsNaN(leftNumber) || math.IsNaN(rightNumber) {
+			return false
+		}
+		switch {
+		case leftNumber < rightNumber:
+			cmp = -1
+		case leftNumber > rightNumber:
+			cmp = 1
+		}
+	}
+
+	switch operator {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		// ">="
+		return cmp >= 0
+	}
+}
+
+// valueToString converts value to string the same way as JavaScript does
+func valueToString(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "undefined"
+	case string:
+		return value
+	case bool:
+		return strconv.FormatBool(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			if item != nil {
+				items[i] = valueToString(item)
+			}
+		}
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		// JSON is more useful than "[object Object]", keys are sorted by
+		// the encoder
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return ""
+		}
+		return string(encoded)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// expression parsing
+
+// expressionOperators are ordered, so longer operators are matched first
+var expressionOperators = []string{
+	"===", "!==", "==", "!=", "<=", ">=", "&&", "||",
+	"<", ">", "!", "+", "-", ".", "[", "]", "(", ")",
+}
+
+// binaryOperatorPrecedence of supported binary operators, higher binds
+// tighter
+var binaryOperatorPrecedence = map[string]int{
+	"||":  1,
+	"&&":  2,
+	"==":  3,
+	"!=":  3,
+	"===": 3,
+	"!==": 3,
+	"<":   4,
+	"<=":  4,
+	">":   4,
+	">=":  4,
+	"+":   5,
+	"-":   5,
+}
+
+type expressionToken struct {
+	// kind is "number", "string", "identifier" or "operator"
+	kind  string
+	text  string
+	value interface{}
+}
+
+func tokenizeExpression(text string) ([]expressionToken, error) {
+	var tokens []expressionToken
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			number, err := strconv.ParseFloat(string(runes[start:i]), 64)
+			if err != nil {
+				return nil, templateErrorf("invalid number %s in %s", string(runes[start:i]), text)
+			}
+			tokens = append(tokens, expressionToken{kind: "number", text: string(runes[start:i]), value: number})
+		case r == '"' || r == '\'':
+			var value strings.Builder
+			start := i
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, templateErrorf("unterminated string in %s", text)
+			}
+			i++
+			tokens = append(tokens, expressionToken{kind: "string", text: string(runes[start:i]), value: value.String()})
+		case unicode.IsLetter(r) || r == '_' || r == '$':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '$') {
+				i++
+			}
+			tokens = append(tokens, expressionToken{kind: "identifier", text: string(runes[start:i])})
+		default:
+			matched := false
+			for _, operator := range expressionOperators {
+				if strings.HasPrefix(string(runes[i:]), operator) {
+					tokens = append(tokens, expressionToken{kind: "operator", text: operator})
+					i += len([]rune(operator))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, templateErrorf("unsupported character %q in %s", r, text)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// expressionParser is a precedence climbing parser of expressions
+type expressionParser struct {
+	text     string
+	tokens   []expressionToken
+	position int
+}
+
+func parseExpression(text string) (expression, error) {
+	tokens, err := tokenizeExpression(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, templateErrorf("empty expression")
+	}
+
+	parser := expressionParser{text: text, tokens: tokens}
+	expr, err := parser.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+	if parser.position < len(tokens) {
+		return nil, templateErrorf("unexpected %s in %s", tokens[parser.position].text, text)
+	}
+	return expr, nil
+}
+
+func (p *expressionParser) peek() *expressionToken {
+	if p.position >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.position]
+}
+
+func (p *expressionParser) isOperator(operator string) bool {
+	token := p.peek()
+	return token != nil && token.kind == "operator" && token.text == operator
+}
+
+func (p *expressionParser) expect(operator string) error {
+	if !p.isOperator(operator) {
+		return templateErrorf("missing %s in %s", operator, p.text)
+	}
+	p.position++
+	return nil
+}
+
+func (p *expressionParser) parseBinary(minPrecedence int) (expression, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		token := p.peek()
+		if token == nil || token.kind != "operator" {
+			return left, nil
+		}
+		precedence, isBinary := binaryOperatorPrecedence[token.text]
+		if !isBinary || precedence < minPrecedence {
+			return left, nil
+		}
+		p.position++
+
+		right, err := p.parseBinary(precedence + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpression{operator: token.text, left: left, right: right}
+	}
+}
+
+func (p *expressionParser) parseUnary() (expression, error) {
+	if p.isOperator("!") || p.isOperator("-") {
+		operator := p.peek().text
+		p.position++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryExpression{operator: operator, operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *expressionParser) parsePostfix() (expression, error) {
+	expr, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.isOperator("."):
+			p.position++
+			token := p.peek()
+			if token == nil || token.kind != "identifier" {
+				return nil, templateErrorf("missing property name in %s", p.text)
+			}
+			p.position++
+			expr = memberExpression{
+				object:   expr,
+				property: literalExpression{value: token.text, text: strconv.Quote(token.text)},
+			}
+		case p.isOperator("["):
+			p.position++
+			property, err := p.parseBinary(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			expr = memberExpression{object: expr, property: property}
+		default:
+			return expr, nil
+		}
+	}
+}
+
+// expressionKeywords are literals that look like identifiers
+var expressionKeywords = map[string]interface{}{
+	"true":      true,
+	"false":     false,
+	"null":      nil,
+	"undefined": nil,
+}
+
+func (p *expressionParser) parsePrimary() (expression, error) {
+	token := p.peek()
+	if token == nil {
+		return nil, templateErrorf("unexpected end of %s", p.text)
+	}
+	p.position++
+
+	switch token.kind {
+	case "number", "string":
+		return literalExpression{value: token.value, text: token.text}, nil
+	case "identifier":
+		if value, isKeyword := expressionKeywords[token.text]; isKeyword {
+			return literalExpression{value: value, text: token.text}, nil
+		}
+		return variableExpression{name: token.text}, nil
+	}
+
+	if token.text == "(" {
+		expr, err := p.parseBinary(1)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+	return nil, templateErrorf("unexpected %s in %s", token.text, p.text)
+}
+
+// markdown to plain text conversion
+
+var (
+	markdownLinkRegexp   = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]*)\)`)
+	markdownHeaderRegexp = regexp.MustCompile(`(?m)^[ \t]*#{1,6}[ \t]+`)
+	markdownListRegexp   = regexp.MustCompile(`(?m)^([ \t]*)[*+][ \t]+`)
+	markdownCodeRegexp   = regexp.MustCompile("`{1,3}")
+)
+
+// markdownEmphasisDelimiters are delimiters of emphasis, the longer ones
+// first
+var markdownEmphasisDelimiters = []string{"**", "__", "~~", "*", "_"}
+
+// MarkdownToText removes the most common markdown formatting from the text.
+// Links are replaced by their text followed by URL in parentheses.
+func MarkdownToText(markdown string) string {
+	text := markdownCodeRegexp.ReplaceAllString(markdown, "")
+	text = markdownLinkRegexp.ReplaceAllStringFunc(text, func(link string) string {
+		parts := markdownLinkRegexp.FindStringSubmatch(link)
+		if parts[1] == "" || parts[1] == parts[2] {
+			return parts[2]
+		}
+		return parts[1] + " (" + parts[2] + ")"
+	})
+	text = markdownHeaderRegexp.ReplaceAllString(text, "")
+	text = markdownListRegexp.ReplaceAllString(text, "${1}- ")
+	return stripEmphasis(text)
+}
+
+// stripEmphasis removes delimiters of emphasis from the text. Only delimiters
+// at word boundaries are removed, so text like "a * b * c" or
+// "snake_case_name" is kept as it is.
+func stripEmphasis(text string) string {
+	var output strings.Builder
+	for i := 0; i < len(text); {
+		if delimiter, end, found := matchEmphasis(text, i); found {
+			output.WriteString(stripEmphasis(text[i+len(delimiter) : end-len(delimiter)]))
+			i = end
+			continue
+		}
+		output.WriteByte(text[i])
+		i++
+	}
+	return output.String()
+}
+
+// matchEmphasis checks if emphasis starts at given position of the text. The
+// opening delimiter has to follow a non-word character and precede a
+// non-space one, the closing delimiter the other way round, and both of them
+// have to be on the same line. The delimiter and end of the emphasis are
+// returned.
+func matchEmphasis(text string, start int) (delimiter string, end int, found bool) {
+	if previous, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(previous) {
+		return "", 0, false
+	}
+
+	for _, delimiter := range markdownEmphasisDelimiters {
+		if !strings.HasPrefix(text[start:], delimiter) {
+			continue
+		}
+
+		contentStart := start + len(delimiter)
+		if first, _ := utf8.DecodeRuneInString(text[contentStart:]); contentStart == len(text) || unicode.IsSpace(first) {
+			continue
+		}
+
+		line := text[contentStart:]
+		if newline := strings.IndexByte(line, '\n'); newline >= 0 {
+			line = line[:newline]
+		}
+
+		// the closing delimiter is searched after the first character
+		for offset := 1; offset < len(line); {
+			index := strings.Index(line[offset:], delimiter)
+			if index < 0 {
+				break
+			}
+			closing := offset + index
+			offset = closing + 1
+
+			// closing delimiter can't be a part of longer run of the same
+			// characters, like "*" in "**"
+			last, _ := utf8.DecodeLastRuneInString(line[:closing])
+			next, _ := utf8.DecodeRuneInString(line[closing+len(delimiter):])
+			marker := rune(delimiter[0])
+			if unicode.IsSpace(last) || last == marker || next == marker ||
+				(closing+len(delimiter) < len(line) && isWordRune(next)) {
+				continue
+			}
+			return delimiter, contentStart + closing + len(delimiter), true
+		}
+	}
+	return "", 0, false
+}
+
+// Formats of rendered rule content
+const (
+	RenderFormatMarkdown = "markdown"
+	RenderFormatText     = "text"
+)
+
+// RenderRuleTemplates fills templates of the rule content (generic, reason
+// and resolution) by extra_data of the rule hit. Rendered markdown is
+// converted to plain text when plainText is true. Fields whose template
+// can't be rendered keep the raw template and the problem is described in
+// the render warning of the rule.
+func RenderRuleTemplates(rule *types.RuleWithContentResponse, plainText bool) {
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"details", &rule.Generic},
+		{"reason", &rule.Reason},
+		{"resolution", &rule.Resolution},
+	}
+
+	var warnings []string
+	for _, field := range fields {
+		rendered, err := RenderTemplate(*field.value, rule.TemplateData)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", field.name, err))
+			continue
+		}
+		if plainText {
+			rendered = MarkdownToText(rendered)
+		}
+		*field.value = rendered
+	}
+
+	if len(warnings) > 0 {
+		rule.RenderWarning = "unable to render template, raw template returned for " +
+			strings.Join(warnings, "; ")
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const nodesTemplate = `Nodes with insufficient resources:
+{{~ pydata.nodes :node }}
+* **{{=node["name"]}}** ({{=node.role}}){{?node.cpu}}: {{=node["cpu"]}} CPUs{{?}}
+{{~}}{{?pydata.nodes.length>1}}
+{{=pydata.nodes.length}} nodes are affected.{{??}}
+One node is affected.{{?}}`
+
+const nodesExtraData = `{
+	"nodes": [
+		{"name": "master-0", "role": "master", "cpu": 2},
+		{"name": "worker-0", "role": "worker"}
+	],
+	"type": "rule"
+}`
+
+func TestRenderTemplate(t *testing.T) {
+	rendered, err := content.RenderTemplate(nodesTemplate, json.RawMessage(nodesExtraData))
+	helpers.FailOnError(t, err)
+
+	assert.Equal(t, `Nodes with insufficient resources:
+
+* **master-0** (master): 2 CPUs
+
+* **worker-0** (worker)
+
+2 nodes are affected.`, rendered)
+}
+
+func TestRenderTemplateCache(t *testing.T) {
+	defer content.ResetContent()
+	content.ResetContent()
+
+	for i := 0; i < 2; i++ {
+		_, err := content.RenderTemplate(nodesTemplate, json.RawMessage(nodesExtraData))
+		helpers.FailOnError(t, err)
+		_, err = content.RenderTemplate("{{=pydata.name", nil)
+		assert.Error(t, err)
+	}
+	// both templates are parsed only once, including the invalid one
+	assert.Equal(t, 2, content.ParsedTemplatesCount())
+
+	// templates of the previous content version are dropped
+	content.LoadRuleContent(&ctypes.RuleContentDirectory{
+		Config: ctypes.GlobalRuleConfig{Impact: testdata.ImpactStrToInt},
+		Rules:  map[string]ctypes.RuleContent{"rc1": testdata.RuleContent1},
+	})
+	_, err := content.RenderTemplate(nodesTemplate, json.RawMessage(nodesExtraData))
+	helpers.FailOnError(t, err)
+	assert.Equal(t, 1, content.ParsedTemplatesCount())
+}
+
+func TestRenderTemplateExpressions(t *testing.T) {
+	extraData := map[string]interface{}{
+		"name":      "kube-apiserver",
+		"count":     3,
+		"enabled":   false,
+		"items":     []string{"a", "b"},
+		"html":      "<b>",
+		"nested":    map[string]interface{}{"key": "value"},
+		"available": "1",
+	}
+
+	testCases := []struct {
+		template string
+		expected string
+	}{
+		{`{{? pydata.name == "kube-apiserver"}}api{{?}}`, "api"},
+		{`{{? pydata.name != 'kube-apiserver'}}other{{??}}api{{?}}`, "api"},
+		{`{{? pydata.count >= 3 && !pydata.enabled}}yes{{?}}`, "yes"},
+		{`{{? pydata.count < 2 || pydata.missing}}yes{{??pydata.count==3}}three{{?}}`, "three"},
+		{`{{? pydata.available == 1}}loose{{?}}{{? pydata.available === 1}}strict{{?}}`, "loose"},
+		{`{{=pydata.count + 1}} {{=pydata.name + "!"}} {{=-pydata.count}}`, "4 kube-apiserver! -3"},
+		{`{{=pydata.items}} {{=pydata.items[1]}} {{=pydata.items.length}}`, "a,b b 2"},
+		{`{{=pydata.nested}} {{=pydata["nested"]["key"]}}`, `{"key":"value"} value`},
+		{`{{!pydata.html}} {{=pydata.html}}`, "&lt;b&gt; <b>"},
+		{`{{~ pydata.items :item:index}}{{=index}}={{=item}};{{~}}`, "0=a;1=b;"},
+		{`{{~ pydata.missing :item}}{{=item}}{{~}}empty`, "empty"},
+		{`{{? (pydata.count - 1) > 1 }}parentheses{{?}}`, "parentheses"},
+		{"no template", "no template"},
+	}
+
+	for _, testCase := range testCases {
+		rendered, err := content.RenderTemplate(testCase.template, extraData)
+		helpers.FailOnError(t, err)
+		assert.Equal(t, testCase.expected, rendered, testCase.template)
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	for _, template := range []string{
+		`{{=pydata.missing}}`,
+		`{{=pydata.missing.key}}`,
+		`{{=other}}`,
+		`{{? pydata.name}}unterminated`,
+		`{{~ pydata.items}}{{~}}`,
+		`{{~ pydata.name :item}}{{~}}`,
+		`{{?}}`,
+		`{{= pydata.name`,
+		`{{# def }}`,
+		`{{= pydata.name + }}`,
+		`{{= "unterminated }}`,
+		// multiplication is not supported
+		`{{= pydata.items.length * 2 }}`,
+	} {
+		_, err := content.RenderTemplate(template, map[string]interface{}{"name": "x", "items": []int{1}})
+		assert.Error(t, err, template)
+	}
+}
+
+func TestMarkdownToText(t *testing.T) {
+	assert.Equal(t,
+		"Title\n- **not bold\n- item with link (https://example.com)\nbold and italic and code\nhttps://example.com",
+		content.MarkdownToText(
+			"## Title\n* **not bold\n* item with [link](https://example.com)\n**bold** and *italic* and `code`\n[https://example.com](https://example.com)",
+		),
+	)
+}
+
+func TestMarkdownToTextEmphasis(t *testing.T) {
+	testCases := map[string]string{
+		"*italic* and _italic_":              "italic and italic",
+		"**bold** and __bold__":              "bold and bold",
+		"~~strike~~ text":                    "strike text",
+		"(*emphasis*).":                      "(emphasis).",
+		"*a **nested** emphasis*":            "a nested emphasis",
+		"a * b * c":                          "a * b * c",
+		"snake_case_name and MAX_NODE_COUNT": "snake_case_name and MAX_NODE_COUNT",
+		"2*3*4 = 24":                         "2*3*4 = 24",
+		"file_*.log and *.conf":              "file_*.log and *.conf",
+		"*not closed\non the same line*":     "*not closed\non the same line*",
+		"*Résumé* ok":                        "Résumé ok",
+	}
+
+	for markdown, expected := range testCases {
+		assert.Equal(t, expected, content.MarkdownToText(markdown), markdown)
+	}
+}
+
+func TestRenderRuleTemplates(t *testing.T) {
+	rule := types.RuleWithContentResponse{
+		Generic:      "Generic {{=pydata.name}}",
+		Reason:       "**Reason** {{=pydata.missing}}",
+		Resolution:   "* Resolution for {{=pydata.name}}",
+		TemplateData: map[string]interface{}{"name": "node-1"},
+	}
+
+	content.RenderRuleTemplates(&rule, true)
+
+	assert.Equal(t, "Generic node-1", rule.Generic)
+	// raw template is kept when it can't be rendered
+	assert.Equal(t, "**Reason** {{=pydata.missing}}", rule.Reason)
+	assert.Equal(t, "- Resolution for node-1", rule.Resolution)
+	assert.Equal(t,
+		"unable to render template, raw template returned for reason: value of pydata[\"missing\"] is undefined",
+		rule.RenderWarning,
+	)
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+// Validation of the rule content. Problems that make LoadRuleContent skip a
+// rule or an error key are reported as errors, so the content can be checked
+// before it's released. Problems that don't prevent loading of the content
+// are reported as warnings.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/RedHatInsights/insights-content-service/groups"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+)
+
+const (
+	// ValidationError is severity of problems that prevent the content
+	// from being loaded or used correctly
+	ValidationError = "error"
+	// ValidationWarning is severity of problems that don't prevent the
+	// content from being loaded
+	ValidationWarning = "warning"
+
+	// osdCustomerTag is handled by smart proxy itself, so it does not need
+	// to be part of any group
+	osdCustomerTag = "osd_customer"
+)
+
+// ValidationIssue represents one problem found in the rule content. ErrorKey
+// is empty for problems of the whole rule.
+type ValidationIssue struct {
+	Severity string
+	RuleID   ctypes.RuleID
+	ErrorKey ctypes.ErrorKey
+	Message  string
+}
+
+// String returns human readable description of the issue
+func (i ValidationIssue) String() string {
+	if i.ErrorKey == "" {
+		return fmt.Sprintf("%s: rule %s: %s", i.Severity, i.RuleID, i.Message)
+	}
+	return fmt.Sprintf("%s: rule %s with key %s: %s", i.Severity, i.RuleID, i.ErrorKey, i.Message)
+}
+
+// ValidateRuleContent checks the rule content directory and returns all
+// problems found in it, sorted by rule ID and error key. Tags of the error
+// keys are checked against tags of the given groups.
+func ValidateRuleContent(contentDir *ctypes.RuleContentDirectory, groupsList []groups.Group) []ValidationIssue {
+	knownTags := map[string]bool{osdCustomerTag: true}
+	for _, group := range groupsList {
+		for _, tag := range group.Tags {
+			knownTags[tag] = true
+		}
+	}
+
+	issues := []ValidationIssue{}
+	for name, rule := range contentDir.Rules {
+		ruleID := ctypes.RuleID(rule.Plugin.PythonModule)
+		addIssue := func(severity string, errorKey string, format string, args ...interface{}) {
+			issues = append(issues, ValidationIssue{
+				Severity: severity,
+				RuleID:   ruleID,
+				ErrorKey: ctypes.ErrorKey(errorKey),
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+
+		if ruleID == "" {
+			ruleID = ctypes.RuleID(name)
+			addIssue(ValidationError, "", "python module is not set")
+		} else if segment, found := internalSegment(ruleID); found && !IsRuleInternal(ruleID) {
+			addIssue(ValidationError, "",
+				"module contains '%s', but it's not recognized as internal rule, "+
+					"'%s' needs to be the second part of the module", segment, segment)
+		}
+
+		loadedErrorKeys := 0
+		for errorKey, errorProperties := range rule.ErrorKeys {
+			metadata := errorProperties.Metadata
+
+			if _, _, err := parseErrorKeyMetadata(ruleID, errorKey, metadata); err != nil {
+				addIssue(ValidationError, errorKey, "error key is skipped: %v", err)
+				continue
+			}
+			loadedErrorKeys++
+
+			if metadata.Impact.Impact == 0 {
+				addIssue(ValidationError, errorKey, "impact attribute is missing")
+			}
+			if metadata.Likelihood == 0 {
+				addIssue(ValidationError, errorKey, "likelihood attribute is missing")
+			}
+
+			for _, tag := range metadata.Tags {
+				if !knownTags[tag] {
+					addIssue(ValidationWarning, errorKey, "tag '%s' is not present in any group", tag)
+				}
+			}
+		}
+
+		if loadedErrorKeys == 0 {
+			addIssue(ValidationError, "", "rule is skipped, it has no error key that can be loaded")
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].RuleID != issues[j].RuleID {
+			return issues[i].RuleID < issues[j].RuleID
+		}
+		if issues[i].ErrorKey != issues[j].ErrorKey {
+			return issues[i].ErrorKey < issues[j].ErrorKey
+		}
+		return issues[i].Message < issues[j].Message
+	})
+
+	return issues
+}
+
+// internalSegment returns the part of the rule module marking internal rules,
+// wherever it's placed in the module
+func internalSegment(ruleID ctypes.RuleID) (string, bool) {
+	for _, segment := range strings.Split(string(ruleID), ".") {
+		if segment == internalRuleStr || segment == ocsRuleStr {
+			return segment, true
+		}
+	}
+	return "", false
+}
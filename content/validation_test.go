@@ -0,0 +1,152 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content_test
+
+import (
+	"testing"
+
+	"github.com/RedHatInsights/insights-content-service/groups"
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+)
+
+var validationGroups = []groups.Group{
+	{Name: "Performance", Tags: []string{"performance"}},
+	{Name: "Security", Tags: []string{"security"}},
+}
+
+func TestValidateRuleContentValid(t *testing.T) {
+	contentDir := ctypes.RuleContentDirectory{
+		Config: testdata.RuleContentDirectory3Rules.Config,
+		Rules: map[string]ctypes.RuleContent{
+			"valid": searchRuleContent(
+				"ccx_rules_ocp.external.rules.valid", "VALID", "Valid rule", "",
+				[]string{"security", "osd_customer"},
+			),
+			"internal": searchRuleContent(
+				"ccx_rules_ocp.internal.rules.valid", "VALID", "Valid internal rule", "",
+				[]string{"performance"},
+			),
+		},
+	}
+
+	assert.Empty(t, content.ValidateRuleContent(&contentDir, validationGroups))
+}
+
+func TestValidateRuleContentIssues(t *testing.T) {
+	invalidStatus := searchRuleContent(
+		"ccx_rules_ocp.external.rules.status", "STATUS", "Invalid status", "", nil,
+	)
+	errorKey := invalidStatus.ErrorKeys["STATUS"]
+	errorKey.Metadata.Status = "unknown"
+	invalidStatus.ErrorKeys["STATUS"] = errorKey
+	// the other error key can be loaded, so the rule is not skipped
+	invalidStatus.ErrorKeys["VALID"] = searchRuleContent("", "VALID", "", "", nil).ErrorKeys["VALID"]
+
+	invalidDate := searchRuleContent(
+		"ccx_rules_ocp.external.rules.date", "DATE", "Invalid publish date", "", nil,
+	)
+	errorKey = invalidDate.ErrorKeys["DATE"]
+	errorKey.Metadata.PublishDate = "yesterday"
+	errorKey.Metadata.Impact.Impact = 0
+	invalidDate.ErrorKeys["DATE"] = errorKey
+
+	missingRisk := searchRuleContent(
+		"ccx_rules_ocp.external.rules.risk", "RISK", "Missing risk", "", []string{"unknown_tag"},
+	)
+	errorKey = missingRisk.ErrorKeys["RISK"]
+	errorKey.Metadata.Impact.Impact = 0
+	errorKey.Metadata.Likelihood = 0
+	missingRisk.ErrorKeys["RISK"] = errorKey
+
+	contentDir := ctypes.RuleContentDirectory{
+		Config: testdata.RuleContentDirectory3Rules.Config,
+		Rules: map[string]ctypes.RuleContent{
+			"status": invalidStatus,
+			"date":   invalidDate,
+			"risk":   missingRisk,
+			"naming": searchRuleContent(
+				"ccx_rules_ocp.external.internal.naming", "NAMING", "Naming", "", nil,
+			),
+		},
+	}
+
+	assert.Equal(t, []content.ValidationIssue{
+		{
+			Severity: content.ValidationError,
+			RuleID:   "ccx_rules_ocp.external.internal.naming",
+			Message: "module contains 'internal', but it's not recognized as internal rule, " +
+				"'internal' needs to be the second part of the module",
+		},
+		{
+			Severity: content.ValidationError,
+			RuleID:   "ccx_rules_ocp.external.rules.date",
+			Message:  "rule is skipped, it has no error key that can be loaded",
+		},
+		{
+			Severity: content.ValidationError,
+			RuleID:   "ccx_rules_ocp.external.rules.date",
+			ErrorKey: "DATE",
+			Message:  "error key is skipped: improper publish_date attribute: invalid format of publish_date",
+		},
+		{
+			Severity: content.ValidationError,
+			RuleID:   "ccx_rules_ocp.external.rules.risk",
+			ErrorKey: "RISK",
+			Message:  "impact attribute is missing",
+		},
+		{
+			Severity: content.ValidationError,
+			RuleID:   "ccx_rules_ocp.external.rules.risk",
+			ErrorKey: "RISK",
+			Message:  "likelihood attribute is missing",
+		},
+		{
+			Severity: content.ValidationWarning,
+			RuleID:   "ccx_rules_ocp.external.rules.risk",
+			ErrorKey: "RISK",
+			Message:  "tag 'unknown_tag' is not present in any group",
+		},
+		{
+			Severity: content.ValidationError,
+			RuleID:   "ccx_rules_ocp.external.rules.status",
+			ErrorKey: "STATUS",
+			Message:  "error key is skipped: invalid status attribute",
+		},
+	}, content.ValidateRuleContent(&contentDir, validationGroups))
+}
+
+func TestValidationIssueString(t *testing.T) {
+	assert.Equal(t,
+		"error: rule ccx_rules_ocp.external.rules.risk with key RISK: impact attribute is missing",
+		content.ValidationIssue{
+			Severity: content.ValidationError,
+			RuleID:   "ccx_rules_ocp.external.rules.risk",
+			ErrorKey: "RISK",
+			Message:  "impact attribute is missing",
+		}.String(),
+	)
+	assert.Equal(t,
+		"warning: rule ccx_rules_ocp.external.rules.risk: problem",
+		content.ValidationIssue{
+			Severity: content.ValidationWarning,
+			RuleID:   "ccx_rules_ocp.external.rules.risk",
+			Message:  "problem",
+		}.String(),
+	)
+}
@@ -35,4 +35,6 @@ var (
 	Main             = main
 	FillInInfoParams = fillInInfoParams
 	HandleCommand    = handleCommand
+	ValidateContent  = validateContent
+	IndexRequests    = indexRequests
 )
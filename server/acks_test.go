@@ -63,7 +63,7 @@ func TestHTTPServer_TestReadAckListNoResult(t *testing.T) {
 		}
 	}
 	`
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodGet,
 		Endpoint:    server.AckListEndpoint,
 		XRHIdentity: goodXRHAuthToken,
@@ -138,7 +138,7 @@ func TestHTTPServer_TestReadAckList1Result(t *testing.T) {
 	`
 	ackListResponse = fmt.Sprintf(ackListResponse, testdata.Rule1CompositeID, justificationNote, disabledAtRFC, disabledAtRFC)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodGet,
 		Endpoint:    server.AckListEndpoint,
 		XRHIdentity: goodXRHAuthToken,
@@ -239,7 +239,7 @@ func TestHTTPServer_TestReadAckList2Results(t *testing.T) {
 		testdata.Rule2CompositeID, justificationNote2, disabledAtRFC, disabledAtRFC,
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodGet,
 		Endpoint:    server.AckListEndpoint,
 		XRHIdentity: goodXRHAuthToken,
@@ -282,7 +282,7 @@ func TestHTTPServer_TestReadAckListInvalidToken(t *testing.T) {
 		"status": "Malformed authentication token"
 	}
 	`
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodGet,
 		Endpoint:    server.AckListEndpoint,
 		XRHIdentity: invalidXRHAuthToken,
@@ -311,7 +311,7 @@ func TestHTTPServer_TestReadAckListAggregatorError(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodGet,
 		Endpoint:    server.AckListEndpoint,
 		XRHIdentity: goodXRHAuthToken,
@@ -340,7 +340,7 @@ func TestHTTPServer_TestReadAckListUnparsableAggregatorJSON(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodGet,
 		Endpoint:    server.AckListEndpoint,
 		XRHIdentity: goodXRHAuthToken,
@@ -377,7 +377,7 @@ func TestHTTPServer_TestGetAcknowledgeNotFound(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodGet,
 		Endpoint:     server.AckGetEndpoint,
 		XRHIdentity:  goodXRHAuthToken,
@@ -406,7 +406,7 @@ func TestHTTPServer_TestGetAcknowledgeAggregatorError(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodGet,
 		Endpoint:     server.AckGetEndpoint,
 		XRHIdentity:  goodXRHAuthToken,
@@ -436,7 +436,7 @@ func TestHTTPServer_TestGetAcknowledgeUnparsableAggregatorJSON(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodGet,
 		Endpoint:     server.AckGetEndpoint,
 		XRHIdentity:  goodXRHAuthToken,
@@ -507,7 +507,7 @@ func TestHTTPServer_TestGetAcknowledgeFound(t *testing.T) {
 		testdata.Rule1CompositeID, justificationNote, disabledAtRFC, disabledAtRFC,
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodGet,
 		Endpoint:     server.AckGetEndpoint,
 		XRHIdentity:  goodXRHAuthToken,
@@ -565,7 +565,7 @@ func TestHTTPServer_TestGetAcknowledgeInvalidRuleIDBadRequest(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodGet,
 		Endpoint:    server.AckGetEndpoint,
 		XRHIdentity: goodXRHAuthToken,
@@ -595,7 +595,7 @@ func TestHTTPServer_TestGetAcknowledgeInvalidToken(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodGet,
 		Endpoint:     server.AckGetEndpoint,
 		XRHIdentity:  invalidXRHAuthToken,
@@ -688,7 +688,7 @@ func TestHTTPServer_TestAcknowledgePostFound(t *testing.T) {
 		testdata.Rule1CompositeID, justificationNote, disabledAtRFC, disabledAtRFC,
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodPost,
 		Endpoint:    server.AckAcknowledgePostEndpoint,
 		XRHIdentity: goodXRHAuthToken,
@@ -807,7 +807,7 @@ func TestHTTPServer_TestAcknowledgePostNewAck(t *testing.T) {
 		testdata.Rule1CompositeID, justificationNote, disabledAtRFC, disabledAtRFC,
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodPost,
 		Endpoint:    server.AckAcknowledgePostEndpoint,
 		XRHIdentity: goodXRHAuthToken,
@@ -835,7 +835,7 @@ func TestHTTPServer_TestAcknowledgePostMissingParam(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, justificationNote)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodPost,
 		Endpoint:    server.AckAcknowledgePostEndpoint,
 		XRHIdentity: goodXRHAuthToken,
@@ -861,7 +861,7 @@ func TestHTTPServer_TestAcknowledgePostBadCompositeRuleID(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, justificationNote)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodPost,
 		Endpoint:    server.AckAcknowledgePostEndpoint,
 		XRHIdentity: goodXRHAuthToken,
@@ -900,7 +900,7 @@ func TestHTTPServer_TestAcknowledgePostAggregatorError1stCall(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, testdata.Rule1CompositeID, justificationNote)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodPost,
 		Endpoint:    server.AckAcknowledgePostEndpoint,
 		XRHIdentity: goodXRHAuthToken,
@@ -986,7 +986,7 @@ func TestHTTPServer_TestAcknowledgePostAggregatorError2ndCall(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, testdata.Rule1CompositeID, justificationNote)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodPost,
 		Endpoint:    server.AckAcknowledgePostEndpoint,
 		XRHIdentity: goodXRHAuthToken,
@@ -1010,7 +1010,7 @@ func TestHTTPServer_TestAcknowledgePostInvalidToken(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, testdata.Rule1CompositeID, "justification")
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:      http.MethodPost,
 		Endpoint:    server.AckAcknowledgePostEndpoint,
 		XRHIdentity: invalidXRHAuthToken,
@@ -1057,7 +1057,7 @@ func TestHTTPServer_TestAcknowledgeUpdateNotFound(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, justificationNote)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodPut,
 		Endpoint:     server.AckUpdateEndpoint,
 		EndpointArgs: []interface{}{testdata.Rule1CompositeID},
@@ -1192,7 +1192,7 @@ func TestHTTPServer_TestAcknowledgeUpdateFound(t *testing.T) {
 		testdata.Rule1CompositeID, justificationUpdated, disabledAtRFC, disabledAtRFC,
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodPut,
 		Endpoint:     server.AckUpdateEndpoint,
 		EndpointArgs: []interface{}{testdata.Rule1CompositeID},
@@ -1220,7 +1220,7 @@ func TestHTTPServer_TestAcknowledgeUpdateBadCompositeRuleID(t *testing.T) {
 
 	reqBody = fmt.Sprintf(reqBody, justificationNote)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodPut,
 		Endpoint:     server.AckUpdateEndpoint,
 		EndpointArgs: []interface{}{"invalid rule id"},
@@ -1259,7 +1259,7 @@ func TestHTTPServer_TestAcknowledgeUpdateAggregatorError1st(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, justificationUpdated)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodPut,
 		Endpoint:     server.AckUpdateEndpoint,
 		EndpointArgs: []interface{}{testdata.Rule1CompositeID},
@@ -1343,7 +1343,7 @@ func TestHTTPServer_TestAcknowledgeUpdateAggregatorError2nd(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, justificationUpdated)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodPut,
 		Endpoint:     server.AckUpdateEndpoint,
 		EndpointArgs: []interface{}{testdata.Rule1CompositeID},
@@ -1441,7 +1441,7 @@ func TestHTTPServer_TestAcknowledgeUpdateAggregatorError3rd(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, justificationUpdated)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodPut,
 		Endpoint:     server.AckUpdateEndpoint,
 		EndpointArgs: []interface{}{testdata.Rule1CompositeID},
@@ -1465,7 +1465,7 @@ func TestHTTPServer_TestAcknowledgeUpdateInvalidToken(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, "justification")
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodPut,
 		Endpoint:     server.AckUpdateEndpoint,
 		EndpointArgs: []interface{}{testdata.Rule1CompositeID},
@@ -1537,7 +1537,7 @@ func TestHTTPServer_TestAcknowledgeDeleteFound(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodDelete,
 		Endpoint:     server.AckDeleteEndpoint,
 		EndpointArgs: []interface{}{testdata.Rule1CompositeID},
@@ -1573,7 +1573,7 @@ func TestHTTPServer_TestAcknowledgeDeleteNotFound(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodDelete,
 		Endpoint:     server.AckDeleteEndpoint,
 		EndpointArgs: []interface{}{testdata.Rule1CompositeID},
@@ -1589,7 +1589,7 @@ func TestHTTPServer_TestAcknowledgeDeleteBadRequest(t *testing.T) {
 	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
 	assert.Nil(t, err)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodDelete,
 		Endpoint:     server.AckDeleteEndpoint,
 		EndpointArgs: []interface{}{"invalid rule id"},
@@ -1612,7 +1612,7 @@ func TestHTTPServer_TestAcknowledgeDeleteInvalidToken(t *testing.T) {
 	`
 	reqBody = fmt.Sprintf(reqBody, "justification")
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodDelete,
 		Endpoint:     server.AckDeleteEndpoint,
 		EndpointArgs: []interface{}{testdata.Rule1CompositeID},
@@ -1649,7 +1649,7 @@ func TestHTTPServer_TestAcknowledgeDeleteAggregatorError1st(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodDelete,
 		Endpoint:     server.AckDeleteEndpoint,
 		EndpointArgs: []interface{}{testdata.Rule1CompositeID},
@@ -1720,7 +1720,7 @@ func TestHTTPServer_TestAcknowledgeDeleteAggregatorError2nd(t *testing.T) {
 		},
 	)
 
-	helpers.AssertAPIv2Request(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIv2Request(t, nil, nil, nil, &helpers.APIRequest{
 		Method:       http.MethodDelete,
 		Endpoint:     server.AckDeleteEndpoint,
 		EndpointArgs: []interface{}{testdata.Rule1CompositeID},
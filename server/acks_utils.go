@@ -125,8 +125,7 @@ func (server *HTTPServer) ackRuleSystemWide(
 	}
 
 	req.Header.Set(contentTypeHeader, JSONContentType)
-	client := &http.Client{}
-	response, err := client.Do(req) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
+	response, err := server.ServicesConfig.AggregatorClient().Do(req) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
 	if err != nil {
 		return err
 	}
@@ -166,7 +165,7 @@ func (server *HTTPServer) updateAckRuleSystemWide(
 
 	// do POST request and read response from Insights Aggregator
 	// nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
-	response, err := http.Post(aggregatorURL, JSONContentType,
+	response, err := server.ServicesConfig.AggregatorClient().Post(aggregatorURL, JSONContentType,
 		bytes.NewBuffer(jsonData)) // #nosec G107
 	if err != nil {
 		return err
@@ -204,8 +203,7 @@ func (server *HTTPServer) deleteAckRuleSystemWide(
 	}
 
 	req.Header.Set(contentTypeHeader, JSONContentType)
-	client := &http.Client{}
-	response, err := client.Do(req) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
+	response, err := server.ServicesConfig.AggregatorClient().Do(req) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
 	if err != nil {
 		return err
 	}
@@ -239,7 +237,7 @@ func (server *HTTPServer) readListOfAckedRules(
 	)
 
 	// #nosec G107
-	response, err := http.Get(aggregatorURL) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
+	response, err := server.ServicesConfig.AggregatorClient().Get(aggregatorURL) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
 	if err != nil {
 		return nil, err
 	}
@@ -287,7 +285,7 @@ func (server *HTTPServer) readRuleDisableStatus(
 	)
 
 	// #nosec G107
-	response, err := http.Get(aggregatorURL) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
+	response, err := server.ServicesConfig.AggregatorClient().Get(aggregatorURL) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
 	if err != nil {
 		return acknowledgement, false, err
 	}
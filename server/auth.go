@@ -78,6 +78,7 @@ func (server *HTTPServer) Authentication(next http.Handler, noAuthURLs []string)
 		}
 
 		tk := &types.Token{}
+		accountType := ""
 		// if we took JWT token, it has different structure than x-rh-identity
 		// JWT isn't/can't used in any real environment
 		if server.Config.AuthType == "jwt" {
@@ -106,6 +107,7 @@ func (server *HTTPServer) Authentication(next http.Handler, noAuthURLs []string)
 				handleServerError(w, &AuthenticationError{ErrString: malformedTokenMessage})
 				return
 			}
+			accountType = readAccountType(decoded)
 		}
 
 		if tk.Identity.AccountNumber == "" || tk.Identity.AccountNumber == "0" {
@@ -131,6 +133,7 @@ func (server *HTTPServer) Authentication(next http.Handler, noAuthURLs []string)
 		// Everything went well, proceed with the request and set the
 		// caller to the user retrieved from the parsed token
 		ctx := context.WithValue(r.Context(), types.ContextKeyUser, tk.Identity)
+		ctx = context.WithValue(ctx, contextKeyAccountType, accountType)
 		r = r.WithContext(ctx)
 
 		next.ServeHTTP(w, r)
@@ -209,7 +209,7 @@ func TestGetAuthTokenJWTHeaderMalformed(t *testing.T) {
 	s := helpers.CreateHTTPServer(
 		&helpers.DefaultServerConfig,
 		&helpers.DefaultServicesConfig,
-		nil, nil, nil, nil, nil,
+		nil, nil, nil,
 	)
 
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -231,7 +231,7 @@ func TestGetAuthTokenXRHHeader(t *testing.T) {
 	s := helpers.CreateHTTPServer(
 		&helpers.DefaultServerConfigXRH,
 		&helpers.DefaultServicesConfig,
-		nil, nil, nil, nil, nil,
+		nil, nil, nil,
 	)
 
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -253,7 +253,7 @@ func TestGetXRHAuthTokenEmpty(t *testing.T) {
 	s := helpers.CreateHTTPServer(
 		&helpers.DefaultServerConfigXRH,
 		&helpers.DefaultServicesConfig,
-		nil, nil, nil, nil, nil,
+		nil, nil, nil,
 	)
 
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -275,7 +275,7 @@ func TestGetAuthTokenHeaderMissing(t *testing.T) {
 	s := helpers.CreateHTTPServer(
 		&helpers.DefaultServerConfig,
 		&helpers.DefaultServicesConfig,
-		nil, nil, nil, nil, nil,
+		nil, nil, nil,
 	)
 
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
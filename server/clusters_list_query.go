@@ -0,0 +1,229 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+// filtering, sorting and pagination of the clusters list view
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// ManagedParam parameter used to filter managed or non-managed clusters
+	ManagedParam = "managed"
+	// NameParam parameter used to filter clusters by (part of) their name or ID
+	NameParam = "name"
+	// MinTotalRiskParam parameter used to filter clusters hit by recommendation with
+	// given total risk or higher
+	MinTotalRiskParam = "min_total_risk"
+	// VersionParam parameter used to filter clusters by their version
+	VersionParam = "version"
+
+	// hitsByTotalRiskSortPrefix is the prefix of sort fields selecting
+	// a bucket of hits_by_total_risk, for example hits_by_total_risk.4
+	hitsByTotalRiskSortPrefix = "hits_by_total_risk."
+	minTotalRisk              = 1
+	maxTotalRisk              = 4
+)
+
+// clustersListQuery represents filters, sorting and pagination requested for
+// the clusters list view
+type clustersListQuery struct {
+	managed      *bool
+	name         string
+	minTotalRisk int
+	version      string
+	sort         sortParam
+	pagination   paginationParams
+}
+
+// readClustersListQuery reads filters, sorting and pagination parameters of
+// the clusters list view from the request query
+func readClustersListQuery(request *http.Request) (query clustersListQuery, err error) {
+	query.pagination, err = readPaginationParams(request)
+	if err != nil {
+		return
+	}
+
+	query.sort, err = readSortParam(request, isValidClustersListSortField)
+	if err != nil {
+		return
+	}
+
+	query.managed, err = readOptionalBoolParam(ManagedParam, request)
+	if err != nil {
+		return
+	}
+
+	query.minTotalRisk, err = readTotalRiskParam(request, MinTotalRiskParam)
+	if err != nil {
+		return
+	}
+
+	query.name = strings.ToLower(request.URL.Query().Get(NameParam))
+	query.version = request.URL.Query().Get(VersionParam)
+	return
+}
+
+// readTotalRiskParam reads optional total risk parameter. Zero is returned
+// when the parameter is not provided
+func readTotalRiskParam(request *http.Request, name string) (int, error) {
+	value := request.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	totalRisk, err := strconv.Atoi(value)
+	if err != nil || totalRisk < minTotalRisk || totalRisk > maxTotalRisk {
+		return 0, &RouterParsingError{
+			ParamName:  name,
+			ParamValue: value,
+			ErrString:  fmt.Sprintf("total risk must be an integer between %d and %d", minTotalRisk, maxTotalRisk),
+		}
+	}
+	return totalRisk, nil
+}
+
+// isValidClustersListSortField checks if the clusters list view can be
+// sorted by given field
+func isValidClustersListSortField(field string) bool {
+	switch field {
+	case "cluster_name", "last_checked_at", "total_hit_count":
+		return true
+	}
+
+	if strings.HasPrefix(field, hitsByTotalRiskSortPrefix) {
+		totalRisk, err := strconv.Atoi(strings.TrimPrefix(field, hitsByTotalRiskSortPrefix))
+		return err == nil && totalRisk >= minTotalRisk && totalRisk <= maxTotalRisk
+	}
+	return false
+}
+
+// matches checks if given cluster satisfies all filters of the query
+func (query *clustersListQuery) matches(cluster *types.ClusterListView) bool {
+	if query.managed != nil && cluster.Managed != *query.managed {
+		return false
+	}
+
+	if query.name != "" &&
+		!strings.Contains(strings.ToLower(cluster.ClusterName), query.name) &&
+		!strings.Contains(strings.ToLower(string(cluster.ClusterID)), query.name) {
+		return false
+	}
+
+	if query.version != "" && !versionMatches(string(cluster.Version), query.version) {
+		return false
+	}
+
+	if query.minTotalRisk > 0 {
+		for totalRisk, hits := range cluster.HitsByTotalRisk {
+			if totalRisk >= query.minTotalRisk && hits > 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// versionMatches checks if the version is equal to the requested one or if
+// the requested one is its prefix, so for example 4.13 matches 4.13.2
+func versionMatches(version, requested string) bool {
+	return version == requested || strings.HasPrefix(version, requested+".")
+}
+
+// apply filters, sorts and paginates the clusters list. Filtered list is
+// returned together with number of clusters satisfying the filters.
+func (query *clustersListQuery) apply(clusters []types.ClusterListView) (
+	page []types.ClusterListView, filteredCount int,
+) {
+	filtered := make([]types.ClusterListView, 0, len(clusters))
+	for i := range clusters {
+		if query.matches(&clusters[i]) {
+			filtered = append(filtered, clusters[i])
+		}
+	}
+
+	if query.sort.field != "" {
+		sortClustersList(filtered, query.sort)
+	}
+
+	start, end := query.pagination.bounds(len(filtered))
+	return filtered[start:end], len(filtered)
+}
+
+// sortClustersList sorts clusters by requested field. Cluster ID is used as a
+// tie-breaker, so the order is stable between requests.
+func sortClustersList(clusters []types.ClusterListView, param sortParam) {
+	compare := clustersListComparator(param.field)
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		a, b := &clusters[i], &clusters[j]
+		if cmp := compare(a, b); cmp != 0 {
+			return (cmp < 0) != param.descending
+		}
+		return a.ClusterID < b.ClusterID
+	})
+}
+
+// clustersListComparator returns function comparing two clusters by given
+// field. The field needs to be validated by isValidClustersListSortField.
+func clustersListComparator(field string) func(a, b *types.ClusterListView) int {
+	switch field {
+	case "cluster_name":
+		return func(a, b *types.ClusterListView) int {
+			return strings.Compare(strings.ToLower(a.ClusterName), strings.ToLower(b.ClusterName))
+		}
+	case "last_checked_at":
+		return func(a, b *types.ClusterListView) int {
+			return compareTimestamps(a.LastCheckedAt, b.LastCheckedAt)
+		}
+	case "total_hit_count":
+		return func(a, b *types.ClusterListView) int {
+			return compareInts(int(a.TotalHitCount), int(b.TotalHitCount))
+		}
+	default:
+		totalRisk, _ := strconv.Atoi(strings.TrimPrefix(field, hitsByTotalRiskSortPrefix))
+		return func(a, b *types.ClusterListView) int {
+			return compareInts(a.HitsByTotalRisk[totalRisk], b.HitsByTotalRisk[totalRisk])
+		}
+	}
+}
+
+// compareTimestamps compares two RFC3339 timestamps. Missing or invalid
+// timestamps are treated as the oldest ones.
+func compareTimestamps(a, b types.Timestamp) int {
+	timeA, _ := time.Parse(time.RFC3339, string(a))
+	timeB, _ := time.Parse(time.RFC3339, string(b))
+	return compareTimes(timeA, timeB)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,163 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+var clustersListViewForQuery = []types.ClusterListView{
+	{
+		ClusterID:       "cluster-a",
+		ClusterName:     "Alpha",
+		Managed:         true,
+		LastCheckedAt:   "2023-01-02T00:00:00Z",
+		TotalHitCount:   1,
+		HitsByTotalRisk: map[int]int{1: 1, 2: 0, 3: 0, 4: 0},
+		Version:         "4.12.5",
+	},
+	{
+		ClusterID:       "cluster-b",
+		ClusterName:     "beta",
+		Managed:         false,
+		LastCheckedAt:   "2023-01-03T00:00:00Z",
+		TotalHitCount:   3,
+		HitsByTotalRisk: map[int]int{1: 0, 2: 1, 3: 0, 4: 2},
+		Version:         "4.13.0",
+	},
+	{
+		ClusterID:       "cluster-c",
+		ClusterName:     "Gamma",
+		Managed:         false,
+		TotalHitCount:   2,
+		HitsByTotalRisk: map[int]int{1: 0, 2: 0, 3: 2, 4: 0},
+		Version:         "4.13.10",
+	},
+}
+
+func filterClustersList(t *testing.T, query string) ([]types.ClusterListView, int) {
+	request := httptest.NewRequest(http.MethodGet, "/clusters?"+query, http.NoBody)
+	page, filtered, err := server.FilterClustersList(request, clustersListViewForQuery)
+	helpers.FailOnError(t, err)
+	return page, filtered
+}
+
+func clusterIDs(clusters []types.ClusterListView) []types.ClusterName {
+	ids := make([]types.ClusterName, len(clusters))
+	for i := range clusters {
+		ids[i] = clusters[i].ClusterID
+	}
+	return ids
+}
+
+func TestClustersListNoQuery(t *testing.T) {
+	page, filtered := filterClustersList(t, "")
+	assert.Equal(t, clustersListViewForQuery, page)
+	assert.Equal(t, 3, filtered)
+}
+
+func TestClustersListFilters(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected []types.ClusterName
+	}{
+		{"managed=true", []types.ClusterName{"cluster-a"}},
+		{"managed=false", []types.ClusterName{"cluster-b", "cluster-c"}},
+		{"name=ETA", []types.ClusterName{"cluster-b"}},
+		{"name=cluster-c", []types.ClusterName{"cluster-c"}},
+		{"min_total_risk=3", []types.ClusterName{"cluster-b", "cluster-c"}},
+		{"min_total_risk=4", []types.ClusterName{"cluster-b"}},
+		{"version=4.13", []types.ClusterName{"cluster-b", "cluster-c"}},
+		{"version=4.13.1", []types.ClusterName{}},
+		{"version=4.12.5", []types.ClusterName{"cluster-a"}},
+		{"managed=false&min_total_risk=4", []types.ClusterName{"cluster-b"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			page, filtered := filterClustersList(t, tc.query)
+			assert.Equal(t, tc.expected, clusterIDs(page))
+			assert.Equal(t, len(tc.expected), filtered)
+		})
+	}
+}
+
+func TestClustersListSorting(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected []types.ClusterName
+	}{
+		{"sort=cluster_name", []types.ClusterName{"cluster-a", "cluster-b", "cluster-c"}},
+		{"sort=-cluster_name", []types.ClusterName{"cluster-c", "cluster-b", "cluster-a"}},
+		{"sort=last_checked_at", []types.ClusterName{"cluster-c", "cluster-a", "cluster-b"}},
+		{"sort=-total_hit_count", []types.ClusterName{"cluster-b", "cluster-c", "cluster-a"}},
+		{"sort=-hits_by_total_risk.3", []types.ClusterName{"cluster-c", "cluster-a", "cluster-b"}},
+		{"sort=hits_by_total_risk.4", []types.ClusterName{"cluster-a", "cluster-c", "cluster-b"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			page, _ := filterClustersList(t, tc.query)
+			assert.Equal(t, tc.expected, clusterIDs(page))
+		})
+	}
+}
+
+func TestClustersListPagination(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected []types.ClusterName
+	}{
+		{"limit=2", []types.ClusterName{"cluster-a", "cluster-b"}},
+		{"limit=2&offset=2", []types.ClusterName{"cluster-c"}},
+		{"offset=5", []types.ClusterName{}},
+		{"sort=-total_hit_count&limit=1&offset=1", []types.ClusterName{"cluster-c"}},
+		{fmt.Sprintf("offset=1&limit=%d", math.MaxInt), []types.ClusterName{"cluster-b", "cluster-c"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			page, filtered := filterClustersList(t, tc.query)
+			assert.Equal(t, tc.expected, clusterIDs(page))
+			assert.Equal(t, 3, filtered)
+		})
+	}
+}
+
+func TestClustersListInvalidQuery(t *testing.T) {
+	for _, query := range []string{
+		"limit=x", "offset=-1", "sort=unknown", "sort=hits_by_total_risk.5",
+		"managed=maybe", "min_total_risk=0", "min_total_risk=high",
+	} {
+		t.Run(query, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/clusters?"+query, http.NoBody)
+			_, _, err := server.FilterClustersList(request, clustersListViewForQuery)
+			assert.Error(t, err)
+
+			var parsingError *server.RouterParsingError
+			assert.ErrorAs(t, err, &parsingError)
+		})
+	}
+}
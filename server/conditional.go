@@ -0,0 +1,172 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+// Conditional GET support for endpoints returning static content. Responses
+// are tagged by ETag derived from the version of loaded rule content, so
+// clients can revalidate cached content using If-None-Match (or
+// If-Modified-Since) header and get 304 Not Modified response.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+)
+
+const (
+	etagHeader            = "ETag"
+	lastModifiedHeader    = "Last-Modified"
+	ifNoneMatchHeader     = "If-None-Match"
+	ifModifiedSinceHeader = "If-Modified-Since"
+	cacheControlHeader    = "Cache-Control"
+)
+
+// contentETag constructs strong ETag from the version of loaded rule content
+// and other parts of the response (for example groups). Empty string is
+// returned when no content is loaded.
+func contentETag(version content.Version, parts ...string) string {
+	if version.Hash == "" {
+		return ""
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(version.Hash))
+	for _, part := range parts {
+		hash.Write([]byte{0})
+		hash.Write([]byte(part))
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil))[:32] + `"`
+}
+
+// jsonHash returns hash of given value encoded as JSON, to be used as a part
+// of ETag
+func jsonHash(value interface{}) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to compute hash of response part")
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// visibilityPart returns ETag part distinguishing responses filtered by
+// different rule visibility, because some rules are visible to some
+// organizations only
+func visibilityPart(visibility services.RuleVisibility) string {
+	return visibility.Key()
+}
+
+// lastModified returns the latest of given modification times of the inputs
+// the response is made of, so Last-Modified changes whenever any of them
+// changes. Zero time is returned when the content modification time is not
+// known.
+func lastModified(contentModifiedAt time.Time, modifiedAt ...time.Time) time.Time {
+	if contentModifiedAt.IsZero() {
+		return contentModifiedAt
+	}
+	latest := contentModifiedAt
+	for _, t := range modifiedAt {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// groupsModifiedAt returns the time the current groups have been retrieved,
+// or zero time when groups are not known
+func (server *HTTPServer) groupsModifiedAt() time.Time {
+	if server.GroupsStore == nil {
+		return time.Time{}
+	}
+	return server.GroupsStore.ModifiedAt()
+}
+
+// visibilityModifiedAt returns the time the active rule visibility policy has
+// been loaded, or zero time when it's given by the configuration
+func (server *HTTPServer) visibilityModifiedAt() time.Time {
+	switch {
+	case server.VisibilityPolicy != nil:
+		return server.VisibilityPolicy.LoadedAt()
+	case server.InternalOrganizations != nil:
+		return server.InternalOrganizations.LoadedAt()
+	}
+	return time.Time{}
+}
+
+// checkNotModified sets ETag and Last-Modified headers of the response. When
+// the client already has the current representation, 304 Not Modified is
+// sent and true is returned, so the handler must not write anything else.
+func checkNotModified(
+	writer http.ResponseWriter, request *http.Request, etag string, modifiedAt time.Time,
+) bool {
+	if etag == "" {
+		// content is not known, nothing to compare with
+		return false
+	}
+
+	writer.Header().Set(etagHeader, etag)
+	writer.Header().Set(cacheControlHeader, "no-cache")
+	if !modifiedAt.IsZero() {
+		writer.Header().Set(lastModifiedHeader, modifiedAt.UTC().Format(http.TimeFormat))
+	}
+
+	if !isNotModified(request, etag, modifiedAt) {
+		return false
+	}
+
+	writer.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// isNotModified evaluates conditional headers of the request according to
+// RFC 7232. If-Modified-Since is ignored when If-None-Match is present.
+func isNotModified(request *http.Request, etag string, modifiedAt time.Time) bool {
+	if ifNoneMatch := request.Header.Get(ifNoneMatchHeader); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+
+	ifModifiedSince := request.Header.Get(ifModifiedSinceHeader)
+	if ifModifiedSince == "" || modifiedAt.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	return !modifiedAt.Truncate(time.Second).After(since)
+}
+
+// etagMatches checks if the ETag is listed in the If-None-Match header value.
+// Weak comparison is used as required for If-None-Match.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
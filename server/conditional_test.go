@@ -0,0 +1,168 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-content-service/groups"
+	iou_helpers "github.com/RedHatInsights/insights-operator-utils/tests/helpers"
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+func getRuleIDs(
+	t *testing.T, serverConfig *server.Configuration, headers map[string]string,
+) *http.Response {
+	testServer := helpers.CreateHTTPServer(serverConfig, nil, nil, nil, nil)
+
+	request := httptest.NewRequest(http.MethodGet, serverConfig.APIv1Prefix+server.RuleIDs, http.NoBody)
+	request.Header.Set("x-rh-identity", goodXRHAuthToken)
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	response := iou_helpers.ExecuteRequest(testServer, request).Result()
+	t.Cleanup(func() {
+		assert.NoError(t, response.Body.Close())
+	})
+	return response
+}
+
+// TestRuleNamesConditionalGet checks that rule IDs are not sent again when
+// the client has the current version
+func TestRuleNamesConditionalGet(t *testing.T) {
+	err := loadMockRuleContentDir(
+		createRuleContentDirectoryFromRuleContent(
+			[]ctypes.RuleContent{RuleContentInternal1, testdata.RuleContent1},
+		),
+	)
+	assert.Nil(t, err)
+
+	response := getRuleIDs(t, &serverConfigInternalOrganizations1, nil)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	etag := response.Header.Get("ETag")
+	assert.NotEmpty(t, etag)
+	lastModified := response.Header.Get("Last-Modified")
+	assert.NotEmpty(t, lastModified)
+
+	t.Run("matching ETag", func(t *testing.T) {
+		response := getRuleIDs(t, &serverConfigInternalOrganizations1, map[string]string{
+			"If-None-Match": etag,
+		})
+		assert.Equal(t, http.StatusNotModified, response.StatusCode)
+		assert.Equal(t, etag, response.Header.Get("ETag"))
+	})
+
+	t.Run("weak ETag in list", func(t *testing.T) {
+		response := getRuleIDs(t, &serverConfigInternalOrganizations1, map[string]string{
+			"If-None-Match": `"other", W/` + etag,
+		})
+		assert.Equal(t, http.StatusNotModified, response.StatusCode)
+	})
+
+	t.Run("different ETag", func(t *testing.T) {
+		response := getRuleIDs(t, &serverConfigInternalOrganizations1, map[string]string{
+			"If-None-Match": `"other"`,
+		})
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	})
+
+	t.Run("not modified since", func(t *testing.T) {
+		response := getRuleIDs(t, &serverConfigInternalOrganizations1, map[string]string{
+			"If-Modified-Since": lastModified,
+		})
+		assert.Equal(t, http.StatusNotModified, response.StatusCode)
+	})
+
+	t.Run("modified since", func(t *testing.T) {
+		response := getRuleIDs(t, &serverConfigInternalOrganizations1, map[string]string{
+			"If-Modified-Since": time.Unix(0, 0).UTC().Format(http.TimeFormat),
+		})
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	})
+
+	t.Run("internal rules not visible", func(t *testing.T) {
+		// organization not allowed to see internal rules gets different list
+		response := getRuleIDs(t, &serverConfigInternalOrganizations2, map[string]string{
+			"If-None-Match": etag,
+		})
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.NotEqual(t, etag, response.Header.Get("ETag"))
+	})
+
+	t.Run("content changed", func(t *testing.T) {
+		err := loadMockRuleContentDir(
+			createRuleContentDirectoryFromRuleContent(
+				[]ctypes.RuleContent{testdata.RuleContent1},
+			),
+		)
+		assert.Nil(t, err)
+
+		response := getRuleIDs(t, &serverConfigInternalOrganizations1, map[string]string{
+			"If-None-Match": etag,
+		})
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	})
+}
+
+// TestGroupsConditionalGetAfterGroupsChange checks that Last-Modified follows
+// the groups, not only the rule content
+func TestGroupsConditionalGetAfterGroupsChange(t *testing.T) {
+	err := loadMockRuleContentDir(
+		createRuleContentDirectoryFromRuleContent([]ctypes.RuleContent{testdata.RuleContent1}),
+	)
+	assert.Nil(t, err)
+
+	groupsStore := services.NewGroupsStore()
+	groupsStore.Update([]groups.Group{{Name: "Performance", Tags: []string{"performance"}}}, nil)
+	testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, nil, groupsStore)
+
+	getGroups := func(headers map[string]string) *http.Response {
+		request := httptest.NewRequest(
+			http.MethodGet, helpers.DefaultServerConfigXRH.APIv1Prefix+server.RuleGroupsEndpoint, http.NoBody,
+		)
+		request.Header.Set("x-rh-identity", goodXRHAuthToken)
+		for name, value := range headers {
+			request.Header.Set(name, value)
+		}
+		response := iou_helpers.ExecuteRequest(testServer, request).Result()
+		t.Cleanup(func() {
+			assert.NoError(t, response.Body.Close())
+		})
+		return response
+	}
+
+	lastModified := getGroups(nil).Header.Get("Last-Modified")
+	assert.NotEmpty(t, lastModified)
+	assert.Equal(t, http.StatusNotModified, getGroups(map[string]string{"If-Modified-Since": lastModified}).StatusCode)
+
+	// Last-Modified has resolution of one second
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	groupsStore.Update([]groups.Group{{Name: "Security", Tags: []string{"security"}}}, nil)
+
+	response := getGroups(map[string]string{"If-Modified-Since": lastModified})
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NotEqual(t, lastModified, response.Header.Get("Last-Modified"))
+}
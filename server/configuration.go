@@ -17,6 +17,8 @@ limitations under the License.
 package server
 
 import (
+	"time"
+
 	types "github.com/RedHatInsights/insights-results-types"
 )
 
@@ -35,6 +37,14 @@ type Configuration struct {
 	EnableCORS                       bool          `mapstructure:"enable_cors" toml:"enable_cors"`
 	EnableInternalRulesOrganizations bool          `mapstructure:"enable_internal_rules_organizations" toml:"enable_internal_rules_organizations"`
 	InternalRulesOrganizations       []types.OrgID `mapstructure:"internal_rules_organizations" toml:"internal_rules_organizations"`
+	VisibilityPolicyFile             string        `mapstructure:"visibility_policy_file" toml:"visibility_policy_file"`
 	LogAuthToken                     bool          `mapstructure:"log_auth_token" toml:"log_auth_token"`
 	UseOrgClustersFallback           bool          `mapstructure:"org_clusters_fallback" toml:"org_clusters_fallback"`
+	ReadinessDependencies            []string      `mapstructure:"readiness_dependencies" toml:"readiness_dependencies"`
+	ShutdownTimeout                  time.Duration `mapstructure:"shutdown_timeout" toml:"shutdown_timeout"`
+	ShutdownDrainDelay               time.Duration `mapstructure:"shutdown_drain_delay" toml:"shutdown_drain_delay"`
+	EventsMaxWait                    time.Duration `mapstructure:"events_max_wait" toml:"events_max_wait"`
+	EventsHeartbeatInterval          time.Duration `mapstructure:"events_heartbeat_interval" toml:"events_heartbeat_interval"`
+	EventsPollInterval               time.Duration `mapstructure:"events_poll_interval" toml:"events_poll_interval"`
+	EventsMaxStreams                 int           `mapstructure:"events_max_streams" toml:"events_max_streams"`
 }
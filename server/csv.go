@@ -0,0 +1,253 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+// CSV export of list views. CSV format is selected either by "format=csv"
+// query parameter or by "Accept: text/csv" header. Rows are written in
+// RFC 4180 format (CRLF line endings) with stable column order.
+
+import (
+	"encoding/csv"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// FormatParam parameter used to select format of the response
+	FormatParam = "format"
+	// CSVFormat is the value of FormatParam selecting CSV format
+	CSVFormat = "csv"
+	// JSONFormat is the value of FormatParam selecting JSON format (default)
+	JSONFormat = "json"
+	// CSVContentType is the media type of CSV responses
+	CSVContentType = "text/csv"
+
+	jsonContentType    = "application/json"
+	csvFilenameTimeFmt = "20060102T150405Z"
+
+	// csvFormulaPrefixes are the first characters making spreadsheets
+	// interpret the cell as a formula
+	csvFormulaPrefixes = "=+-@\t\r"
+)
+
+// clustersListCSVHeader is the header of CSV export of clusters list view
+var clustersListCSVHeader = []string{
+	"cluster_id", "cluster_name", "managed", "cluster_version", "product",
+	"cloud_provider", "region", "created_at", "last_checked_at", "total_hit_count",
+	"hits_total_risk_1", "hits_total_risk_2", "hits_total_risk_3", "hits_total_risk_4",
+}
+
+// recommendationsListCSVHeader is the header of CSV export of
+// recommendations list view
+var recommendationsListCSVHeader = []string{
+	"rule_id", "description", "generic", "publish_date", "total_risk",
+	"resolution_risk", "impact", "likelihood", "tags", "disabled",
+	"impacted_clusters_count",
+}
+
+// clustersDetailCSVHeader is the header of CSV export of clusters hit by
+// a rule. Enabled and disabled clusters are distinguished by status column.
+var clustersDetailCSVHeader = []string{
+	"status", "cluster_id", "cluster_name", "cluster_version", "last_checked_at",
+	"impacted", "disabled_at", "justification",
+}
+
+// readCSVFormat checks if the client requested CSV format of the response
+func readCSVFormat(request *http.Request) (bool, error) {
+	switch format := request.URL.Query().Get(FormatParam); format {
+	case CSVFormat:
+		return true, nil
+	case JSONFormat:
+		return false, nil
+	case "":
+		return acceptsCSV(request), nil
+	default:
+		return false, &RouterParsingError{
+			ParamName:  FormatParam,
+			ParamValue: format,
+			ErrString:  fmt.Sprintf("supported formats are %s and %s", JSONFormat, CSVFormat),
+		}
+	}
+}
+
+// acceptsCSV checks if CSV is preferred over JSON in Accept header. The
+// first of these two media types listed in the header wins.
+func acceptsCSV(request *http.Request) bool {
+	for _, accept := range request.Header.Values("Accept") {
+		for _, item := range strings.Split(accept, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(item))
+			if err != nil {
+				continue
+			}
+			switch mediaType {
+			case CSVContentType:
+				return true
+			case jsonContentType:
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// csvFilename constructs name of exported file containing organization ID
+// and time of the export
+func csvFilename(name string, orgID types.OrgID) string {
+	return fmt.Sprintf("%s-%d-%s.csv", name, orgID, time.Now().UTC().Format(csvFilenameTimeFmt))
+}
+
+// sanitizeCSVCell prefixes the cell by apostrophe when it starts with
+// a character making spreadsheets interpret it as a formula, because the
+// exported values (cluster names for example) are controlled by users
+func sanitizeCSVCell(cell string) string {
+	if cell != "" && strings.ContainsRune(csvFormulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// sendCSV writes CSV response with given header. Rows are generated by the
+// writeRows callback and streamed to the client.
+func sendCSV(
+	writer http.ResponseWriter, filename string, header []string,
+	writeRows func(csvWriter *csv.Writer) error,
+) error {
+	writer.Header().Set("Content-Type", CSVContentType+"; charset=utf-8")
+	writer.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	writer.WriteHeader(http.StatusOK)
+
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.UseCRLF = true
+
+	if err := csvWriter.Write(header); err != nil {
+		return err
+	}
+	if err := writeRows(csvWriter); err != nil {
+		return err
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// sendClustersListCSV writes clusters list view as CSV
+func sendClustersListCSV(writer http.ResponseWriter, orgID types.OrgID, clusters []types.ClusterListView) error {
+	return sendCSV(writer, csvFilename("clusters", orgID), clustersListCSVHeader, func(csvWriter *csv.Writer) error {
+		for i := range clusters {
+			cluster := &clusters[i]
+			row := []string{
+				sanitizeCSVCell(string(cluster.ClusterID)),
+				sanitizeCSVCell(cluster.ClusterName),
+				strconv.FormatBool(cluster.Managed),
+				sanitizeCSVCell(string(cluster.Version)),
+				sanitizeCSVCell(cluster.Product),
+				sanitizeCSVCell(cluster.CloudProvider),
+				sanitizeCSVCell(cluster.Region),
+				sanitizeCSVCell(string(cluster.CreatedAt)),
+				sanitizeCSVCell(string(cluster.LastCheckedAt)),
+				strconv.FormatUint(uint64(cluster.TotalHitCount), 10),
+			}
+			for totalRisk := minTotalRisk; totalRisk <= maxTotalRisk; totalRisk++ {
+				row = append(row, strconv.Itoa(cluster.HitsByTotalRisk[totalRisk]))
+			}
+			if err := csvWriter.Write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// sendRecommendationsListCSV writes recommendations list view as CSV
+func sendRecommendationsListCSV(
+	writer http.ResponseWriter, orgID types.OrgID, recommendations []types.RecommendationListView,
+) error {
+	return sendCSV(writer, csvFilename("recommendations", orgID), recommendationsListCSVHeader,
+		func(csvWriter *csv.Writer) error {
+			for i := range recommendations {
+				recommendation := &recommendations[i]
+				row := []string{
+					sanitizeCSVCell(string(recommendation.RuleID)),
+					sanitizeCSVCell(recommendation.Description),
+					sanitizeCSVCell(recommendation.Generic),
+					recommendation.PublishDate.UTC().Format(time.RFC3339),
+					strconv.Itoa(int(recommendation.TotalRisk)),
+					strconv.Itoa(int(recommendation.ResolutionRisk)),
+					strconv.Itoa(int(recommendation.Impact)),
+					strconv.Itoa(int(recommendation.Likelihood)),
+					sanitizeCSVCell(strings.Join(recommendation.Tags, ",")),
+					strconv.FormatBool(recommendation.Disabled),
+					strconv.FormatUint(uint64(recommendation.ImpactedClustersCnt), 10),
+				}
+				if err := csvWriter.Write(row); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+// sendClustersDetailCSV writes list of enabled and disabled clusters hit by
+// a rule as CSV
+func sendClustersDetailCSV(writer http.ResponseWriter, orgID types.OrgID, data types.ClustersDetailData) error {
+	return sendCSV(writer, csvFilename("clusters_detail", orgID), clustersDetailCSVHeader,
+		func(csvWriter *csv.Writer) error {
+			for _, cluster := range data.EnabledClusters {
+				if err := csvWriter.Write(enabledClusterCSVRow(cluster)); err != nil {
+					return err
+				}
+			}
+			for _, cluster := range data.DisabledClusters {
+				if err := csvWriter.Write(disabledClusterCSVRow(cluster)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+}
+
+func enabledClusterCSVRow(cluster ctypes.HittingClustersData) []string {
+	return []string{
+		"enabled",
+		sanitizeCSVCell(string(cluster.Cluster)),
+		sanitizeCSVCell(cluster.Name),
+		sanitizeCSVCell(string(cluster.Meta.Version)),
+		sanitizeCSVCell(cluster.LastSeen),
+		sanitizeCSVCell(cluster.ImpactedSince),
+		"",
+		"",
+	}
+}
+
+func disabledClusterCSVRow(cluster ctypes.DisabledClusterInfo) []string {
+	return []string{
+		"disabled",
+		sanitizeCSVCell(string(cluster.ClusterID)),
+		sanitizeCSVCell(cluster.ClusterName),
+		"",
+		"",
+		"",
+		cluster.DisabledAt.UTC().Format(time.RFC3339),
+		sanitizeCSVCell(cluster.Justification),
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+func TestReadCSVFormat(t *testing.T) {
+	testCases := []struct {
+		name     string
+		query    string
+		accept   string
+		expected bool
+	}{
+		{"no format", "", "", false},
+		{"format param", "format=csv", "", true},
+		{"json format param", "format=json", "text/csv", false},
+		{"accept CSV", "", "text/csv", true},
+		{"accept CSV with parameters", "", "text/csv; charset=utf-8", true},
+		{"accept JSON", "", "application/json", false},
+		{"JSON preferred", "", "application/json, text/csv", false},
+		{"CSV preferred", "", "text/csv, application/json", true},
+		{"accept anything", "", "*/*", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/clusters?"+tc.query, http.NoBody)
+			if tc.accept != "" {
+				request.Header.Set("Accept", tc.accept)
+			}
+			asCSV, err := server.ReadCSVFormat(request)
+			helpers.FailOnError(t, err)
+			assert.Equal(t, tc.expected, asCSV)
+		})
+	}
+}
+
+func TestReadCSVFormatUnsupported(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/clusters?format=xml", http.NoBody)
+	_, err := server.ReadCSVFormat(request)
+
+	var parsingError *server.RouterParsingError
+	assert.ErrorAs(t, err, &parsingError)
+}
+
+func TestSendRecommendationsListCSV(t *testing.T) {
+	recommendations := []types.RecommendationListView{
+		{
+			RuleID:              "rule.a|KEY",
+			Description:         `Description with "quotes", and comma`,
+			Generic:             "Multi\nline",
+			PublishDate:         time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC),
+			TotalRisk:           3,
+			ResolutionRisk:      1,
+			Impact:              2,
+			Likelihood:          4,
+			Tags:                []string{"security", "upgrade"},
+			Disabled:            true,
+			ImpactedClustersCnt: 5,
+		},
+	}
+
+	recorder := httptest.NewRecorder()
+	err := server.SendRecommendationsListCSV(recorder, types.OrgID(42), recommendations)
+	helpers.FailOnError(t, err)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Regexp(t,
+		regexp.MustCompile(`^attachment; filename="recommendations-42-\d{8}T\d{6}Z\.csv"$`),
+		recorder.Header().Get("Content-Disposition"),
+	)
+	assert.Equal(t,
+		"rule_id,description,generic,publish_date,total_risk,resolution_risk,impact,likelihood,"+
+			"tags,disabled,impacted_clusters_count\r\n"+
+			`rule.a|KEY,"Description with ""quotes"", and comma","Multi`+"\r\n"+`line",`+
+			`2021-05-01T00:00:00Z,3,1,2,4,"security,upgrade",true,5`+"\r\n",
+		recorder.Body.String(),
+	)
+}
+
+func TestSanitizeCSVCell(t *testing.T) {
+	testCases := []struct {
+		cell     string
+		expected string
+	}{
+		{"", ""},
+		{"cluster", "cluster"},
+		{"a=b", "a=b"},
+		{"=HYPERLINK(\"http://example.com\")", "'=HYPERLINK(\"http://example.com\")"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tcluster", "'\tcluster"},
+		{"\rcluster", "'\rcluster"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, server.SanitizeCSVCell(tc.cell))
+	}
+}
+
+func TestSendClustersListCSVSanitizesCells(t *testing.T) {
+	clusters := []types.ClusterListView{
+		{
+			ClusterID:       "34c3ecc5-624a-49a5-bab8-4fdc5e51a266",
+			ClusterName:     "=1+2",
+			HitsByTotalRisk: map[int]int{},
+		},
+	}
+
+	recorder := httptest.NewRecorder()
+	helpers.FailOnError(t, server.SendClustersListCSV(recorder, types.OrgID(42), clusters))
+
+	assert.Contains(t, recorder.Body.String(), "\r\n34c3ecc5-624a-49a5-bab8-4fdc5e51a266,'=1+2,false,")
+}
@@ -30,6 +30,10 @@ const (
 	DbgDeleteClustersEndpoint = ira_server.DeleteClustersEndpoint
 	// DbgGetVoteOnRuleEndpoint is an endpoint to get vote on rule. DEBUG only
 	DbgGetVoteOnRuleEndpoint = "clusters/{cluster}/rules/{rule_id}/error_key/{error_key}/get_vote"
+	// DbgInvalidateClusterListCacheEndpoint invalidates cached list of clusters for {organization}. DEBUG only
+	DbgInvalidateClusterListCacheEndpoint = "organizations/{organization}/clusters/cache"
+	// DbgInternalOrganizationsEndpoint returns the active list of internal rules organizations. DEBUG only
+	DbgInternalOrganizationsEndpoint = "internal_organizations"
 )
 
 // adddbgEndpointsToRouter adds API dbg specific endpoints to the router
@@ -48,6 +52,9 @@ func (server *HTTPServer) adddbgEndpointsToRouter(router *mux.Router) {
 		}},
 	)).Methods(http.MethodGet)
 
+	router.HandleFunc(apiPrefix+DbgInvalidateClusterListCacheEndpoint, server.invalidateClusterListCache).Methods(http.MethodDelete)
+	router.HandleFunc(apiPrefix+DbgInternalOrganizationsEndpoint, server.getInternalOrganizations).Methods(http.MethodGet)
+
 	// endpoints for pprof - needed for profiling, ie. usually in debug mode
 	router.PathPrefix("/debug/pprof/").Handler(http.DefaultServeMux)
 }
@@ -71,7 +71,7 @@ func TestHTTPServer_ProxyTo_VoteEndpointsExtractUserID(t *testing.T) {
 					Body:       `{"status": "ok"}`,
 				})
 
-				helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+				helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 					Method:       testCase.method,
 					Endpoint:     testCase.endpoint,
 					EndpointArgs: []interface{}{testdata.ClusterName, testdata.Rule1ID, testdata.ErrorKey1},
@@ -91,7 +91,7 @@ func TestHTTPServer_ProxyTo_VoteEndpointsExtractUserID(t *testing.T) {
 
 func TestHTTPServer_ProxyTo_VoteEndpointBadCharacter(t *testing.T) {
 	badClusterName := "00000000000000000000000000000000000%1F"
-	helpers.AssertAPIRequest(t, &helpers.DefaultServerConfigXRH, &helpers.DefaultServicesConfig, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIRequest(t, &helpers.DefaultServerConfigXRH, &helpers.DefaultServicesConfig, nil, &helpers.APIRequest{
 		Method:       http.MethodPut,
 		Endpoint:     server.LikeRuleEndpoint,
 		EndpointArgs: []interface{}{badClusterName, testdata.Rule1ID, testdata.ErrorKey1},
@@ -55,6 +55,18 @@ const (
 	// ContentV2 returns all the static content available for the user
 	ContentV2 = "content"
 
+	// ContentVersionsV2 returns versions of the static content kept in
+	// memory, the newest first
+	ContentVersionsV2 = "content/versions"
+
+	// ContentDiffV2 returns differences between two versions of the static
+	// content identified by hashes in "from" and "to" query parameters
+	ContentDiffV2 = "content/diff"
+
+	// ContentSearchV2 returns recommendations whose static content matches
+	// the full-text query in "q" query parameter
+	ContentSearchV2 = "content/search"
+
 	// Endpoints to manipulate with simplified rule results stored
 	// independently under "tracker_id" identifier in Redis
 
@@ -71,6 +83,10 @@ const (
 	// cluster and requestID
 	RuleHitsForRequestID = "cluster/{cluster}/request/{request_id}/report"
 
+	// EventsForRequestID should stream server-sent events about progress
+	// of processing one given request ID
+	EventsForRequestID = "cluster/{cluster}/request/{request_id}/events"
+
 	// Endpoints to acknowledge rule and to manipulate with
 	// acknowledgements.
 
@@ -142,6 +158,7 @@ func (server *HTTPServer) addV2RedisEndpointsToRouter(router *mux.Router, apiPre
 	router.HandleFunc(apiPrefix+ListAllRequestIDs, server.getRequestsForClusterPostVariant).Methods(http.MethodPost)
 	router.HandleFunc(apiPrefix+StatusOfRequestID, server.getRequestStatusForCluster).Methods(http.MethodGet)
 	router.HandleFunc(apiPrefix+RuleHitsForRequestID, server.getReportForRequest).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+EventsForRequestID, server.getEventsForRequest).Methods(http.MethodGet)
 }
 
 // addV2ReportsEndpointsToRouter method registers handlers for endpoints that
@@ -175,4 +192,7 @@ func (server HTTPServer) addV2ContentEndpointsToRouter(router *mux.Router, apiPr
 	router.HandleFunc(apiPrefix+RuleContentV2, server.getRecommendationContent).Methods(http.MethodGet)
 	router.HandleFunc(apiPrefix+RuleContentWithUserData, server.getRecommendationContentWithUserData).Methods(http.MethodGet)
 	router.HandleFunc(apiPrefix+ContentV2, server.getContentWithGroups).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+ContentVersionsV2, server.getContentVersions).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+ContentDiffV2, server.getContentDiff).Methods(http.MethodGet)
+	router.HandleFunc(apiPrefix+ContentSearchV2, server.searchContent).Methods(http.MethodGet)
 }
@@ -115,6 +115,14 @@ func (*ParamsParsingError) Error() string {
 	return "the parameters contains invalid characters and cannot be used"
 }
 
+// EventStreamsLimitError error is used when the maximum number of concurrent
+// streams of request events is reached
+type EventStreamsLimitError struct{}
+
+func (*EventStreamsLimitError) Error() string {
+	return "Too many streams of request events are open"
+}
+
 // handleServerError handles separate server errors and sends appropriate responses
 func handleServerError(writer http.ResponseWriter, err error) {
 	log.Error().Err(err).Msg("handleServerError()")
@@ -134,7 +142,7 @@ func handleServerError(writer http.ResponseWriter, err error) {
 		respErr = responses.SendForbidden(writer, err.Error())
 	case *ContentServiceUnavailableError, *AggregatorServiceUnavailableError,
 		*AMSAPIUnavailableError, *content.RuleContentDirectoryTimeoutError,
-		*UpgradesDataEngServiceUnavailableError:
+		*UpgradesDataEngServiceUnavailableError, *EventStreamsLimitError:
 		respErr = responses.SendServiceUnavailable(writer, err.Error())
 	default:
 		respErr = responses.SendInternalServerError(writer, "Internal Server Error")
@@ -16,6 +16,13 @@ limitations under the License.
 
 package server
 
+import (
+	"net/http"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
 // Export for testing
 //
 // This source file contains name aliases of all package-private functions
@@ -27,7 +34,55 @@ package server
 // to see why this trick is needed.
 
 var (
-	FillImpacted       = fillImpacted
-	GetAuthTokenHeader = (*HTTPServer).getAuthTokenHeader
-	HandleServerError  = handleServerError
+	FillImpacted               = fillImpacted
+	GetAuthTokenHeader         = (*HTTPServer).getAuthTokenHeader
+	HandleServerError          = handleServerError
+	ReadCSVFormat              = readCSVFormat
+	SanitizeCSVCell            = sanitizeCSVCell
+	SendClustersListCSV        = sendClustersListCSV
+	SendRecommendationsListCSV = sendRecommendationsListCSV
 )
+
+// FilterClustersList reads filters, sorting and pagination from the request
+// and applies them to given clusters list view
+func FilterClustersList(request *http.Request, clusters []types.ClusterListView) (
+	[]types.ClusterListView, int, error,
+) {
+	query, err := readClustersListQuery(request)
+	if err != nil {
+		return nil, 0, err
+	}
+	page, filtered := query.apply(clusters)
+	return page, filtered, nil
+}
+
+// FilterRecommendationsList reads filters, sorting and pagination from the
+// request and applies them to given recommendations list view
+func FilterRecommendationsList(request *http.Request, recommendations []types.RecommendationListView) (
+	[]types.RecommendationListView, int, error,
+) {
+	query, err := readRecommendationsListQuery(request)
+	if err != nil {
+		return nil, 0, err
+	}
+	page, filtered := query.apply(recommendations)
+	return page, filtered, nil
+}
+
+// FilterRequestsList reads filters, order and limit from the request and
+// applies them to given list of requests
+func FilterRequestsList(request *http.Request, requests []types.RequestStatus) (
+	[]types.RequestStatus, error,
+) {
+	query, err := readRequestsListQuery(request)
+	if err != nil {
+		return nil, err
+	}
+	return query.apply(requests), nil
+}
+
+// EventsIntervals returns maximum wait time, heartbeat interval and poll
+// interval of the events stream of the server
+func EventsIntervals(server *HTTPServer) (maxWait, heartbeatInterval, pollInterval time.Duration) {
+	return server.eventsIntervals.maxWait, server.eventsIntervals.heartbeatInterval, server.eventsIntervals.pollInterval
+}
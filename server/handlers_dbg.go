@@ -0,0 +1,91 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+// Handlers for endpoints available in debug mode only.
+
+import (
+	"net/http"
+	"time"
+
+	httputils "github.com/RedHatInsights/insights-operator-utils/http"
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// invalidateClusterListCache removes cached lists of clusters for given
+// organization, so the next request retrieves fresh data from AMS API
+func (server *HTTPServer) invalidateClusterListCache(writer http.ResponseWriter, request *http.Request) {
+	orgID, err := httputils.GetRouterPositiveIntParam(request, "organization")
+	if err != nil {
+		httputils.HandleOrgIDError(writer, err)
+		return
+	}
+
+	invalidator, ok := server.amsClient.(amsclient.ClusterListCacheInvalidator)
+	if !ok {
+		err = responses.SendBadRequest(writer, "cluster list cache is not enabled")
+		if err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return
+	}
+
+	err = invalidator.InvalidateClusterListCache(types.OrgID(orgID))
+	if err != nil {
+		log.Error().Err(err).Msg("unable to invalidate cluster list cache")
+		handleServerError(writer, err)
+		return
+	}
+
+	err = responses.SendOK(writer, responses.BuildOkResponse())
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// getInternalOrganizations returns the active list of organizations allowed
+// to access internal rules and the time it has been loaded. The time is
+// omitted when the list is taken from configuration.
+func (server *HTTPServer) getInternalOrganizations(writer http.ResponseWriter, _ *http.Request) {
+	enabled := server.Config.EnableInternalRulesOrganizations
+	organizations := []ctypes.OrgID{}
+	loadedAt := ""
+
+	switch {
+	case !enabled:
+	case server.InternalOrganizations != nil:
+		organizations = server.InternalOrganizations.List()
+		loadedAt = server.InternalOrganizations.LoadedAt().Format(time.RFC3339)
+	case server.Config.InternalRulesOrganizations != nil:
+		organizations = server.Config.InternalRulesOrganizations
+	}
+
+	response := responses.BuildOkResponse()
+	response["enabled"] = enabled
+	response["organizations"] = organizations
+	if loadedAt != "" {
+		response["loaded_at"] = loadedAt
+	}
+
+	err := responses.SendOK(writer, response)
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
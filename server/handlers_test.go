@@ -21,14 +21,15 @@ import (
 	"testing"
 	"time"
 
-	// "github.com/RedHatInsights/insights-content-service/groups"
+	"github.com/RedHatInsights/insights-content-service/groups"
 	"github.com/RedHatInsights/insights-operator-utils/responses"
 	iou_helpers "github.com/RedHatInsights/insights-operator-utils/tests/helpers"
 	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
 	ira_server "github.com/RedHatInsights/insights-results-aggregator/server"
 
-	// "github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
 	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
 	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
 	data "github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
 	"github.com/RedHatInsights/insights-results-smart-proxy/types"
@@ -336,7 +337,7 @@ func TestHTTPServer_ReportEndpoint(t *testing.T) {
 
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportEndpoint,
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -373,7 +374,7 @@ func TestHTTPServer_ReportEndpoint_UnavailableContentService(t *testing.T) {
 
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportEndpoint,
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -407,7 +408,7 @@ func TestHTTPServer_ReportEndpointNoContent(t *testing.T) {
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
 		// previously was InternalServerError, but it was changed as an edge-case which will appear as "No issues found"
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportEndpoint,
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -436,7 +437,7 @@ func TestHTTPServer_ReportEndpointV2NoContent(t *testing.T) {
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 
 		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
 			Method:       http.MethodGet,
@@ -481,7 +482,7 @@ func TestHTTPServer_ReportEndpointV2TestAMSData(t *testing.T) {
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 
 		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
 			Method:       http.MethodGet,
@@ -529,7 +530,7 @@ func TestHTTPServer_ReportEndpointV2TestManagedClustersRules(t *testing.T) {
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 
 		// 3 rules, only 1 of which is managed
 		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
@@ -583,7 +584,7 @@ func TestHTTPServer_ReportEndpointNoContentFor2Rules(t *testing.T) {
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
 		// 1 rule returned, but count = 3
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportEndpoint,
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -615,7 +616,7 @@ func TestHTTPServer_ReportEndpoint_WithOnlyOSDEndpoint(t *testing.T) {
 
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportEndpoint + "?" + server.OSDEligibleParam + "=true",
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -649,7 +650,7 @@ func TestHTTPServer_ReportEndpoint_InsightsOperatorUserAgentManagedCluster(t *te
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 
 		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
 			Method:       http.MethodGet,
@@ -702,7 +703,7 @@ func TestHTTPServer_ReportEndpoint_InsightsOperatorUserAgentNonManagedCluster(t
 			clusterInfoList,
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 
 		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
 			Method:       http.MethodGet,
@@ -755,7 +756,7 @@ func TestHTTPServer_ReportEndpoint_WithDisabledRulesForCluster(t *testing.T) {
 			expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 		}
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportEndpoint + "?" + server.GetDisabledParam + "=false",
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -768,7 +769,7 @@ func TestHTTPServer_ReportEndpoint_WithDisabledRulesForCluster(t *testing.T) {
 		})
 
 		// Not using the parameter gets the same result as using with =false
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportEndpoint,
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -781,7 +782,7 @@ func TestHTTPServer_ReportEndpoint_WithDisabledRulesForCluster(t *testing.T) {
 		})
 
 		// Enabling the parameter
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportEndpoint + "?" + server.GetDisabledParam + "=true",
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -813,7 +814,7 @@ func TestHTTPServer_ReportEndpoint_WithDisabledRulesForClusterAndMissingContent(
 
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportEndpoint,
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -853,7 +854,7 @@ func TestHTTPServer_ReportEndpoint_WithClusterAndSystemWideDisabledRules(t *test
 			})
 		}
 		// Get report with get_disabled = false
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportEndpoint + "?" + server.GetDisabledParam + "=false",
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -866,7 +867,7 @@ func TestHTTPServer_ReportEndpoint_WithClusterAndSystemWideDisabledRules(t *test
 		})
 
 		// Get report without specifying get_disabled => same result as above
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportEndpoint,
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -880,7 +881,7 @@ func TestHTTPServer_ReportEndpoint_WithClusterAndSystemWideDisabledRules(t *test
 
 		// Get report with get_disabled = true
 		// => Report contains disabled rules for cluster and org-wide disabled rules
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportEndpoint + "?" + server.GetDisabledParam + "=true",
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -923,7 +924,7 @@ func TestHTTPServer_ReportMetainfoEndpointNoReports(t *testing.T) {
 		})
 
 		// check the Smart Proxy report/info endpoint
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportMetainfoEndpoint,
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -966,7 +967,7 @@ func TestHTTPServer_ReportMetainfoEndpointTwoReports(t *testing.T) {
 		})
 
 		// check the Smart Proxy report/info endpoint
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportMetainfoEndpoint,
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -997,7 +998,7 @@ func TestHTTPServer_ReportMetainfoEndpointForbidden(t *testing.T) {
 			Body:       "",
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportMetainfoEndpoint,
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -1031,7 +1032,7 @@ func TestHTTPServer_ReportMetainfoEndpointImproperJSON(t *testing.T) {
 		})
 
 		// check the Smart Proxy report/info endpoint
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportMetainfoEndpoint,
 			EndpointArgs: []interface{}{testdata.ClusterName},
@@ -1076,7 +1077,7 @@ func TestHTTPServer_ReportMetainfoEndpointWrongClusterName(t *testing.T) {
 		})
 
 		// check the Smart Proxy report/info endpoint
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ReportMetainfoEndpoint,
 			EndpointArgs: []interface{}{clusterName},
@@ -1112,7 +1113,7 @@ func TestHTTPServer_RuleEndpoint(t *testing.T) {
 			Body:       testdata.Report3SingleRuleExpectedResponse,
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:   http.MethodGet,
 			Endpoint: server.SingleRuleEndpoint,
 			EndpointArgs: []interface{}{
@@ -1155,7 +1156,7 @@ func TestHTTPServer_RuleEndpoint_UnavailableContentService(t *testing.T) {
 			Body:       testdata.Report3SingleRuleExpectedResponse,
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:   http.MethodGet,
 			Endpoint: server.SingleRuleEndpoint,
 			EndpointArgs: []interface{}{
@@ -1192,7 +1193,7 @@ func TestHTTPServer_RuleEndpoint_WithOSD(t *testing.T) {
 			Body:       testdata.Report3SingleRuleExpectedResponse,
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:   http.MethodGet,
 			Endpoint: server.SingleRuleEndpoint + "?" + server.OSDEligibleParam + "=true",
 			EndpointArgs: []interface{}{
@@ -1229,7 +1230,7 @@ func TestHTTPServer_RuleEndpoint_WithNotOSDRule(t *testing.T) {
 			Body:       testdata.Report3SingleRule2ExpectedResponse,
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:   http.MethodGet,
 			Endpoint: server.SingleRuleEndpoint + "?" + server.OSDEligibleParam + "=true",
 			EndpointArgs: []interface{}{
@@ -1251,7 +1252,7 @@ func TestHTTPServer_GetContent(t *testing.T) {
 	assert.Nil(t, err)
 
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.Content,
 			XRHIdentity: goodXRHAuthToken,
@@ -1324,7 +1325,7 @@ func TestHTTPServer_OverviewEndpoint(t *testing.T) {
 
 		expectNoRulesDisabledPerCluster(&t, testdata.OrgID)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(
 			t,
 			testServer,
@@ -1405,7 +1406,7 @@ func TestHTTPServer_OverviewEndpointManagedClustersRules(t *testing.T) {
 		expectNoRulesDisabledPerCluster(&t, testdata.OrgID)
 
 		// managed cluster; 1 managed rule, 2 non-managed rules == only 1 rule must count
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(
 			t,
 			testServer,
@@ -1483,7 +1484,7 @@ func TestHTTPServer_OverviewEndpoint_UnavailableContentService(t *testing.T) {
 
 		expectNoRulesDisabledPerCluster(&t, testdata.OrgID)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, helpers.DefaultServerConfig.APIv1Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.OverviewEndpoint,
@@ -1555,7 +1556,7 @@ func TestHTTPServer_OverviewGetEndpointDisabledRule(t *testing.T) {
 
 		expectNoRulesDisabledPerCluster(&t, testdata.OrgID)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(
 			t,
 			testServer,
@@ -1671,7 +1672,7 @@ func TestHTTPServer_OverviewEndpointWithFallback(t *testing.T) {
 
 		config := helpers.DefaultServerConfigXRH
 		config.UseOrgClustersFallback = true
-		testServer := helpers.CreateHTTPServer(&config, nil, nil, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&config, nil, nil, nil, nil)
 		iou_helpers.AssertAPIRequest(
 			t,
 			testServer,
@@ -1743,7 +1744,7 @@ func TestInternalOrganizations(t *testing.T) {
 	} {
 		t.Run(testCase.TestName, func(t *testing.T) {
 			helpers.RunTestWithTimeout(t, func(t testing.TB) {
-				helpers.AssertAPIRequest(t, testCase.ServerConfig, nil, nil, nil, nil, &helpers.APIRequest{
+				helpers.AssertAPIRequest(t, testCase.ServerConfig, nil, nil, &helpers.APIRequest{
 					Method:       http.MethodGet,
 					Endpoint:     server.RuleContent,
 					EndpointArgs: []interface{}{internalTestRuleModule},
@@ -1779,7 +1780,7 @@ func TestRuleNames(t *testing.T) {
 	} {
 		t.Run(testCase.TestName, func(t *testing.T) {
 			helpers.RunTestWithTimeout(t, func(t testing.TB) {
-				helpers.AssertAPIRequest(t, testCase.ServerConfig, nil, nil, nil, nil, &helpers.APIRequest{
+				helpers.AssertAPIRequest(t, testCase.ServerConfig, nil, nil, &helpers.APIRequest{
 					Method:      http.MethodGet,
 					Endpoint:    server.RuleIDs,
 					XRHIdentity: testCase.MockAuthToken,
@@ -1807,7 +1808,7 @@ func TestRuleNamesResponse(t *testing.T) {
 		}
 	`
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
-		helpers.AssertAPIRequest(t, &serverConfigInternalOrganizations1, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, &serverConfigInternalOrganizations1, nil, nil, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.RuleIDs,
 			XRHIdentity: goodXRHAuthToken,
@@ -1824,7 +1825,7 @@ func TestRuleNamesResponse(t *testing.T) {
 			"status": "ok"
 		}`
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
-		helpers.AssertAPIRequest(t, &serverConfigInternalOrganizations2, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, &serverConfigInternalOrganizations2, nil, nil, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.RuleIDs,
 			XRHIdentity: goodXRHAuthToken,
@@ -1859,7 +1860,7 @@ func TestHTTPServer_OverviewWithClusterIDsEndpoint(t *testing.T) {
 
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:      http.MethodPost,
 			Endpoint:    server.OverviewEndpoint,
 			OrgID:       testdata.OrgID,
@@ -1902,7 +1903,7 @@ func TestHTTPServer_OverviewWithClusterIDsEndpoint_UnavailableContentService(t *
 
 		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:      http.MethodPost,
 			Endpoint:    server.OverviewEndpoint,
 			OrgID:       testdata.OrgID,
@@ -1947,7 +1948,7 @@ func TestHTTPServer_OverviewWithClusterIDsEndpointDisabledRules(t *testing.T) {
 			Body:       helpers.ToJSONString(ResponseRule1DisabledSystemWide),
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:      http.MethodPost,
 			Endpoint:    server.OverviewEndpoint,
 			OrgID:       testdata.OrgID,
@@ -1983,7 +1984,7 @@ func TestHTTPServer_OverviewWithClusterIDsEndpointDisabledRules(t *testing.T) {
 			Body:       helpers.ToJSONString(ResponseRule2DisabledSystemWide),
 		})
 
-		helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 			Method:      http.MethodPost,
 			Endpoint:    server.OverviewEndpoint,
 			OrgID:       testdata.OrgID,
@@ -2059,7 +2060,7 @@ func TestHTTPServer_RecommendationsListEndpoint2Rules_ImpactingMissing(t *testin
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.RecommendationsListEndpoint,
@@ -2168,7 +2169,7 @@ func TestHTTPServer_RecommendationsListEndpoint2Rules_ImpactingMissing1RuleDisab
 		)
 
 		// one rule acked; one rule user disabled (not counted as impacting)
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.RecommendationsListEndpoint,
@@ -2243,7 +2244,7 @@ func TestHTTPServer_RecommendationsListEndpoint2Rules1MissingContent(t *testing.
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.RecommendationsListEndpoint,
@@ -2255,6 +2256,28 @@ func TestHTTPServer_RecommendationsListEndpoint2Rules1MissingContent(t *testing.
 	}, testTimeout)
 }
 
+// TestHTTPServer_RecommendationsListEndpoint_InvalidQuery tests that invalid
+// filtering, sorting or pagination parameters are refused
+func TestHTTPServer_RecommendationsListEndpoint_InvalidQuery(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		amsClientMock := helpers.AMSClientWithOrgResults(
+			testdata.OrgID,
+			data.GetRandomClusterInfoList(2),
+		)
+
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
+		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
+			Method:      http.MethodGet,
+			Endpoint:    server.RecommendationsListEndpoint + "?" + server.TotalRiskParam + "=5",
+			XRHIdentity: goodXRHAuthToken,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusBadRequest,
+		})
+	}, testTimeout)
+}
+
 // TestHTTPServer_RecommendationsListEndpoint_NoRuleContent
 func TestHTTPServer_RecommendationsListEndpoint_NoRuleContent(t *testing.T) {
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
@@ -2312,7 +2335,7 @@ func TestHTTPServer_RecommendationsListEndpoint_NoRuleContent(t *testing.T) {
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.RecommendationsListEndpoint,
@@ -2382,7 +2405,7 @@ func TestHTTPServer_RecommendationsListEndpoint3Rules1Internal0Clusters_Impactin
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.RecommendationsListEndpoint + "?" + server.ImpactingParam + "=true",
@@ -2452,7 +2475,7 @@ func TestHTTPServer_RecommendationsListEndpoint3Rules1Internal0Clusters_Impactin
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.RecommendationsListEndpoint + "?" + server.ImpactingParam + "=false",
@@ -2526,7 +2549,7 @@ func TestHTTPServer_RecommendationsListEndpoint2Rules1Internal2Clusters_Impactin
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.RecommendationsListEndpoint,
@@ -2605,7 +2628,7 @@ func TestHTTPServer_RecommendationsListEndpoint4Rules1Internal2Clusters_Impactin
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.RecommendationsListEndpoint,
@@ -2623,7 +2646,7 @@ func TestHTTPServer_RecommendationsListEndpoint_BadToken(t *testing.T) {
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
 		defer helpers.CleanAfterGock(t)
 
-		helpers.AssertAPIv2Request(t, &helpers.DefaultServerConfigXRH, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIv2Request(t, &helpers.DefaultServerConfigXRH, nil, nil, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.RecommendationsListEndpoint,
 			XRHIdentity: invalidXRHAuthToken,
@@ -2638,7 +2661,7 @@ func TestHTTPServer_RecommendationsListEndpoint_BadImpactingParam(t *testing.T)
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
 		defer helpers.CleanAfterGock(t)
 
-		helpers.AssertAPIv2Request(t, &helpers.DefaultServerConfigXRH, nil, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIv2Request(t, &helpers.DefaultServerConfigXRH, nil, nil, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.RecommendationsListEndpoint + "?" + server.ImpactingParam + "=badbool",
 			XRHIdentity: goodXRHAuthToken,
@@ -2710,7 +2733,7 @@ func TestHTTPServer_RecommendationsListEndpointAMSManagedClusters(t *testing.T)
 			},
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.RecommendationsListEndpoint,
@@ -2789,7 +2812,7 @@ func TestHTTPServer_GetRecommendationContent(t *testing.T) {
 					}
 				}
 
-				helpers.AssertAPIv2Request(t, testCase.ServerConfig, nil, nil, nil, nil, &helpers.APIRequest{
+				helpers.AssertAPIv2Request(t, testCase.ServerConfig, nil, nil, &helpers.APIRequest{
 					Method:       http.MethodGet,
 					Endpoint:     server.RuleContentV2,
 					EndpointArgs: []interface{}{testCase.RuleID},
@@ -2919,7 +2942,7 @@ func TestHTTPServer_GetRecommendationContentWithUserData(t *testing.T) {
 					}
 				}
 
-				helpers.AssertAPIv2Request(t, testCase.ServerConfig, nil, nil, nil, nil, &helpers.APIRequest{
+				helpers.AssertAPIv2Request(t, testCase.ServerConfig, nil, nil, &helpers.APIRequest{
 					Method:       http.MethodGet,
 					Endpoint:     server.RuleContentWithUserData,
 					EndpointArgs: []interface{}{testCase.RuleID},
@@ -2967,7 +2990,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_NoClusters(t *testing.T) {
 
 		expectNoRulesDisabledPerCluster(&t, testdata.OrgID)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.ClustersRecommendationsEndpoint,
@@ -3027,7 +3050,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_ClustersFoundNoInsights(t *t
 			resp.Clusters[i].LastCheckedAt = "" // will be empty because we don't have the cluster in our DB
 		}
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.ClustersRecommendationsEndpoint,
@@ -3040,6 +3063,166 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_ClustersFoundNoInsights(t *t
 	}, testTimeout)
 }
 
+// TestHTTPServer_ClustersRecommendationsEndpoint_AMSClusterMetadata tests that cluster metadata
+// retrieved from AMS API (version, product, cloud provider, region, creation time) are returned
+func TestHTTPServer_ClustersRecommendationsEndpoint_AMSClusterMetadata(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+		err := loadMockRuleContentDir(&ctypes.RuleContentDirectory{})
+		assert.Nil(t, err)
+		clusterInfoList := data.GetRandomClusterInfoList(2)
+		for i := range clusterInfoList {
+			clusterInfoList[i].Version = "4.13.0"
+			clusterInfoList[i].Product = "ROSA"
+			clusterInfoList[i].CloudProvider = "aws"
+			clusterInfoList[i].Region = "us-east-1"
+			clusterInfoList[i].CreatedAt = "2023-01-02T03:04:05Z"
+		}
+
+		clusterList := types.GetClusterNames(clusterInfoList)
+		reqBody, _ := json.Marshal(clusterList)
+
+		respBody := `{
+			"clusters":{}
+		}`
+
+		// prepare response from amsclient for list of clusters
+		amsClientMock := helpers.AMSClientWithOrgResults(
+			testdata.OrgID,
+			clusterInfoList,
+		)
+
+		// prepare response from aggregator
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+			&helpers.APIRequest{
+				Method:       http.MethodPost,
+				Endpoint:     ira_server.ClustersRecommendationsListEndpoint,
+				EndpointArgs: []interface{}{testdata.OrgID, userIDOnGoodJWTAuthBearer},
+				Body:         reqBody,
+			},
+			&helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       respBody,
+			},
+		)
+
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+
+		expectNoRulesDisabledPerCluster(&t, testdata.OrgID)
+
+		resp := GetClustersResponse2ClusterNoHits
+		resp.Clusters = make([]types.ClusterListView, len(GetClustersResponse2ClusterNoHits.Clusters))
+		copy(resp.Clusters, GetClustersResponse2ClusterNoHits.Clusters)
+		for i := range clusterInfoList {
+			resp.Clusters[i].ClusterID = clusterInfoList[i].ID
+			resp.Clusters[i].ClusterName = clusterInfoList[i].DisplayName
+			resp.Clusters[i].Managed = clusterInfoList[i].Managed
+			resp.Clusters[i].LastCheckedAt = ""
+			resp.Clusters[i].Version = clusterInfoList[i].Version
+			resp.Clusters[i].Product = clusterInfoList[i].Product
+			resp.Clusters[i].CloudProvider = clusterInfoList[i].CloudProvider
+			resp.Clusters[i].Region = clusterInfoList[i].Region
+			resp.Clusters[i].CreatedAt = clusterInfoList[i].CreatedAt
+		}
+
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
+		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
+			Method:      http.MethodGet,
+			Endpoint:    server.ClustersRecommendationsEndpoint,
+			XRHIdentity: goodXRHAuthToken,
+		}, &helpers.APIResponse{
+			StatusCode:  http.StatusOK,
+			Body:        helpers.ToJSONString(resp),
+			BodyChecker: clusterInResponseChecker,
+		})
+	}, testTimeout)
+}
+
+// TestHTTPServer_ClustersRecommendationsEndpoint_CSV tests that clusters list
+// is exported as CSV when requested
+func TestHTTPServer_ClustersRecommendationsEndpoint_CSV(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+		err := loadMockRuleContentDir(&ctypes.RuleContentDirectory{})
+		assert.Nil(t, err)
+		clusterInfoList := data.GetRandomClusterInfoList(2)
+		clusterInfoList[0].Version = "4.13.0"
+		clusterInfoList[0].Region = "us-east-1"
+
+		clusterList := types.GetClusterNames(clusterInfoList)
+		reqBody, _ := json.Marshal(clusterList)
+
+		// prepare response from amsclient for list of clusters
+		amsClientMock := helpers.AMSClientWithOrgResults(
+			testdata.OrgID,
+			clusterInfoList,
+		)
+
+		// prepare response from aggregator
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+			&helpers.APIRequest{
+				Method:       http.MethodPost,
+				Endpoint:     ira_server.ClustersRecommendationsListEndpoint,
+				EndpointArgs: []interface{}{testdata.OrgID, userIDOnGoodJWTAuthBearer},
+				Body:         reqBody,
+			},
+			&helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       `{"clusters":{}}`,
+			},
+		)
+
+		expectNoRulesDisabledSystemWide(&t, testdata.OrgID)
+
+		expectNoRulesDisabledPerCluster(&t, testdata.OrgID)
+
+		expectedCSV := "cluster_id,cluster_name,managed,cluster_version,product,cloud_provider,region," +
+			"created_at,last_checked_at,total_hit_count," +
+			"hits_total_risk_1,hits_total_risk_2,hits_total_risk_3,hits_total_risk_4\r\n" +
+			fmt.Sprintf("%v,%v,true,4.13.0,,,us-east-1,,,0,0,0,0,0\r\n", clusterInfoList[0].ID, clusterInfoList[0].DisplayName) +
+			fmt.Sprintf("%v,%v,false,,,,,,,0,0,0,0,0\r\n", clusterInfoList[1].ID, clusterInfoList[1].DisplayName)
+
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
+		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
+			Method:       http.MethodGet,
+			Endpoint:     server.ClustersRecommendationsEndpoint,
+			XRHIdentity:  goodXRHAuthToken,
+			ExtraHeaders: http.Header{"Accept": []string{server.CSVContentType}},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       expectedCSV,
+			BodyChecker: func(t testing.TB, expected, got []byte) {
+				assert.Equal(t, string(expected), string(got))
+			},
+			Headers: map[string]string{
+				"Content-Type": "text/csv; charset=utf-8",
+			},
+		})
+	}, testTimeout)
+}
+
+// TestHTTPServer_ClustersRecommendationsEndpoint_InvalidQuery tests that invalid
+// filtering, sorting or pagination parameters are refused
+func TestHTTPServer_ClustersRecommendationsEndpoint_InvalidQuery(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		amsClientMock := helpers.AMSClientWithOrgResults(
+			testdata.OrgID,
+			data.GetRandomClusterInfoList(2),
+		)
+
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
+		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
+			Method:      http.MethodGet,
+			Endpoint:    server.ClustersRecommendationsEndpoint + "?" + server.SortParam + "=unknown",
+			XRHIdentity: goodXRHAuthToken,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusBadRequest,
+		})
+	}, testTimeout)
+}
+
 // TestHTTPServer_ClustersRecommendationsEndpoint_NoRuleHits tests clusters received from AMS API, but no rule hits
 func TestHTTPServer_ClustersRecommendationsEndpoint_NoRuleHits(t *testing.T) {
 	helpers.RunTestWithTimeout(t, func(t testing.TB) {
@@ -3100,7 +3283,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_NoRuleHits(t *testing.T) {
 			resp.Clusters[i].LastCheckedAt = testTimestamp
 		}
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.ClustersRecommendationsEndpoint,
@@ -3160,7 +3343,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_NoReportInDB(t *testing.T) {
 			resp.Clusters[i].Managed = clusterInfoList[i].Managed
 		}
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.ClustersRecommendationsEndpoint,
@@ -3244,7 +3427,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_2ClustersFilled(t *testing.T
 			resp.Clusters[i].Managed = clusterInfoList[i].Managed
 		}
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.ClustersRecommendationsEndpoint,
@@ -3328,7 +3511,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_2Clusters1Managed(t *testing
 		}
 
 		// cluster 1 is managed, so must only show 1 rule. cluster 2 will show both rules.
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.ClustersRecommendationsEndpoint,
@@ -3415,7 +3598,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_2Clusters1WithVersion(t *tes
 		}
 
 		// cluster 1 is managed, so must only show 1 rule. cluster 2 will show both rules.
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.ClustersRecommendationsEndpoint,
@@ -3519,7 +3702,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_AckedRule(t *testing.T) {
 			resp.Clusters[i].Managed = clusterInfoList[i].Managed
 		}
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.ClustersRecommendationsEndpoint,
@@ -3627,7 +3810,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_DisabledRuleSingleCluster(t
 			resp.Clusters[i].Managed = clusterInfoList[i].Managed
 		}
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.ClustersRecommendationsEndpoint,
@@ -3756,7 +3939,7 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_DisabledAndAcked(t *testing.
 			resp.Clusters[i].Managed = clusterInfoList[i].Managed
 		}
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 		iou_helpers.AssertAPIRequest(t, testServer, serverConfigJWT.APIv2Prefix, &helpers.APIRequest{
 			Method:      http.MethodGet,
 			Endpoint:    server.ClustersRecommendationsEndpoint,
@@ -3769,17 +3952,38 @@ func TestHTTPServer_ClustersRecommendationsEndpoint_DisabledAndAcked(t *testing.
 	}, testTimeout)
 }
 
-// TODO: fix race condition/deadlock, then this test can be enabled again
-/*
 func TestHTTPServer_GroupsEndpoint(t *testing.T) {
-	groupsChannel := make(chan []groups.Group)
-	errorFoundChannel := make(chan bool)
-	errorChannel := make(chan error)
+	groupsStore := services.NewGroupsStore()
+	groupsStore.Update(make([]groups.Group, 1), nil)
 
-	records := make([]groups.Group, 1)
-	go func() { groupsChannel <- records }()
-	go func() { errorFoundChannel <- false }()
+	expectedBody := `
+		{
+			"groups": [
+				{
+					"description": "",
+					"tags": null,
+					"title":""
+				}
+			],
+			"status": "ok"
+		}`
+	helpers.AssertAPIRequest(t, nil, nil, groupsStore, &helpers.APIRequest{
+		Method:      http.MethodGet,
+		Endpoint:    server.RuleGroupsEndpoint,
+		OrgID:       testdata.OrgID,
+		XRHIdentity: goodXRHAuthToken,
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Body:       expectedBody,
+	})
+}
+
+func TestHTTPServer_GroupsEndpoint_FailedPoll(t *testing.T) {
+	groupsStore := services.NewGroupsStore()
+	groupsStore.Update(make([]groups.Group, 1), nil)
+	groupsStore.Update(nil, &content.RuleContentDirectoryTimeoutError{})
 
+	// the last valid groups are returned
 	expectedBody := `
 		{
 			"groups": [
@@ -3791,55 +3995,145 @@ func TestHTTPServer_GroupsEndpoint(t *testing.T) {
 			],
 			"status": "ok"
 		}`
-	helpers.RunTestWithTimeout(t, func(t testing.TB) {
-		helpers.AssertAPIRequest(t, nil, nil, groupsChannel, errorFoundChannel, errorChannel, &helpers.APIRequest{
-			Method:      http.MethodGet,
-			Endpoint:    server.RuleGroupsEndpoint,
-			OrgID:       testdata.OrgID,
-			XRHIdentity: goodXRHAuthToken,
-		}, &helpers.APIResponse{
-			StatusCode: http.StatusOK,
-			Body:       expectedBody,
-		})
-	}, 30*time.Second)
+	helpers.AssertAPIRequest(t, nil, nil, groupsStore, &helpers.APIRequest{
+		Method:      http.MethodGet,
+		Endpoint:    server.RuleGroupsEndpoint,
+		OrgID:       testdata.OrgID,
+		XRHIdentity: goodXRHAuthToken,
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusOK,
+		Body:       expectedBody,
+	})
 }
-*/
 
-// TODO: fix race condition/deadlock, then this test can be enabled again
-/*
 func TestHTTPServer_GroupsEndpoint_UnavailableContentService(t *testing.T) {
-	groupsChannel := make(chan []groups.Group)
-	errorFoundChannel := make(chan bool)
-	errorChannel := make(chan error)
-
-	go func() { errorFoundChannel <- true }()
-	go func() { errorChannel <- &content.RuleContentDirectoryTimeoutError{} }()
+	groupsStore := services.NewGroupsStore()
+	groupsStore.Update(nil, &content.RuleContentDirectoryTimeoutError{})
 
 	expectedBody := `
 		{
 			"status" : "Content directory cache has been empty for too long time; timeout triggered"
 		}`
 
-	helpers.RunTestWithTimeout(t, func(t testing.TB) {
-		helpers.AssertAPIRequest(t, nil, nil, groupsChannel, errorFoundChannel, errorChannel, &helpers.APIRequest{
-			Method:      http.MethodGet,
-			Endpoint:    server.RuleGroupsEndpoint,
-			OrgID:       testdata.OrgID,
-			XRHIdentity: goodXRHAuthToken,
-		}, &helpers.APIResponse{
-			StatusCode: http.StatusServiceUnavailable,
-			Body:       expectedBody,
-		})
-	}, 30*time.Second)
+	helpers.AssertAPIRequest(t, nil, nil, groupsStore, &helpers.APIRequest{
+		Method:      http.MethodGet,
+		Endpoint:    server.RuleGroupsEndpoint,
+		OrgID:       testdata.OrgID,
+		XRHIdentity: goodXRHAuthToken,
+	}, &helpers.APIResponse{
+		StatusCode: http.StatusServiceUnavailable,
+		Body:       expectedBody,
+	})
 }
-*/
 
 // TestServeInfoMap checks the REST API server behaviour for info endpoint
 func TestServeInfoMap(t *testing.T) {
-	helpers.AssertAPIRequest(t, nil, nil, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
 		Method:   http.MethodGet,
 		Endpoint: "info",
 	}, &helpers.APIResponse{
 		StatusCode: http.StatusOK,
 	})
 }
+
+// TestHTTPServer_RuleEndpoint_Render checks that templates of the rule
+// content are filled by extra_data when requested
+func TestHTTPServer_RuleEndpoint_Render(t *testing.T) {
+	errorKey := testdata.RuleContent1.ErrorKeys[testdata.ErrorKey1]
+	errorKey.Reason = "Node **{{=pydata.node}}** is affected"
+	errorKey.Resolution = "Restart {{=pydata.missing}}"
+	ruleContent := testdata.RuleContent1
+	ruleContent.ErrorKeys = map[string]ctypes.RuleErrorKeyContent{testdata.ErrorKey1: errorKey}
+
+	err := loadMockRuleContentDir(createRuleContentDirectoryFromRuleContent([]ctypes.RuleContent{ruleContent}))
+	assert.Nil(t, err)
+
+	ruleOnReport := testdata.RuleOnReport1
+	ruleOnReport.TemplateData = map[string]interface{}{"node": "master-0"}
+	aggregatorResponse := helpers.ToJSONString(map[string]interface{}{
+		"report": ruleOnReport,
+		"status": "ok",
+	})
+
+	for render, expectedReason := range map[string]string{
+		"true": "Node **master-0** is affected",
+		"text": "Node master-0 is affected",
+	} {
+		helpers.RunTestWithTimeout(t, func(t testing.TB) {
+			defer helpers.CleanAfterGock(t)
+
+			helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+				Method:   http.MethodGet,
+				Endpoint: ira_server.RuleEndpoint,
+				EndpointArgs: []interface{}{
+					testdata.OrgID,
+					testdata.ClusterName,
+					testdata.UserID,
+					fmt.Sprintf("%v|%v", testdata.RuleErrorKey1.RuleModule, testdata.RuleErrorKey1.ErrorKey),
+				},
+			}, &helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       aggregatorResponse,
+			})
+
+			helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
+				Method:   http.MethodGet,
+				Endpoint: server.SingleRuleEndpoint + "?" + server.RenderParam + "=" + render,
+				EndpointArgs: []interface{}{
+					testdata.ClusterName, fmt.Sprintf("%v|%v", testdata.RuleErrorKey1.RuleModule, testdata.RuleErrorKey1.ErrorKey),
+				},
+				UserID:      testdata.UserID,
+				OrgID:       testdata.OrgID,
+				XRHIdentity: goodXRHAuthToken,
+			}, &helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				BodyChecker: func(t testing.TB, _, got []byte) {
+					var response struct {
+						Report types.RuleWithContentResponse `json:"report"`
+					}
+					helpers.FailOnError(t, json.Unmarshal(got, &response))
+					assert.Equal(t, expectedReason, response.Report.Reason)
+					// raw template is returned when it can't be rendered
+					assert.Equal(t, errorKey.Resolution, response.Report.Resolution)
+					assert.Contains(t, response.Report.RenderWarning, "resolution")
+				},
+			})
+		}, testTimeout)
+	}
+}
+
+func TestHTTPServer_RuleEndpoint_InvalidRender(t *testing.T) {
+	err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+	assert.Nil(t, err)
+
+	helpers.RunTestWithTimeout(t, func(t testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+			Method:   http.MethodGet,
+			Endpoint: ira_server.RuleEndpoint,
+			EndpointArgs: []interface{}{
+				testdata.OrgID,
+				testdata.ClusterName,
+				testdata.UserID,
+				fmt.Sprintf("%v|%v", testdata.RuleErrorKey1.RuleModule, testdata.RuleErrorKey1.ErrorKey),
+			},
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusOK,
+			Body:       testdata.Report3SingleRuleExpectedResponse,
+		})
+
+		helpers.AssertAPIRequest(t, nil, nil, nil, &helpers.APIRequest{
+			Method:   http.MethodGet,
+			Endpoint: server.SingleRuleEndpoint + "?" + server.RenderParam + "=html",
+			EndpointArgs: []interface{}{
+				testdata.ClusterName, fmt.Sprintf("%v|%v", testdata.RuleErrorKey1.RuleModule, testdata.RuleErrorKey1.ErrorKey),
+			},
+			UserID:      testdata.UserID,
+			OrgID:       testdata.OrgID,
+			XRHIdentity: goodXRHAuthToken,
+		}, &helpers.APIResponse{
+			StatusCode: http.StatusBadRequest,
+		})
+	}, testTimeout)
+}
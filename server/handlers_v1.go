@@ -43,7 +43,9 @@ type infoEndpointStruct struct {
 
 // getGroups sends the latest valid groups configuration to the client in
 // standard HTTP response
-func (server *HTTPServer) getGroups(writer http.ResponseWriter, _ *http.Request) {
+func (server *HTTPServer) getGroups(writer http.ResponseWriter, request *http.Request) {
+	version := content.GetContentVersion()
+
 	// retrieve the latest groups configuration
 	groupsConfig, err := server.getGroupsConfig()
 	if err != nil {
@@ -51,6 +53,11 @@ func (server *HTTPServer) getGroups(writer http.ResponseWriter, _ *http.Request)
 		return
 	}
 
+	modifiedAt := lastModified(version.ModifiedAt, server.groupsModifiedAt())
+	if checkNotModified(writer, request, contentETag(version, jsonHash(groupsConfig)), modifiedAt) {
+		return
+	}
+
 	responseContent := make(map[string]interface{})
 	responseContent["status"] = "ok"
 	responseContent["groups"] = groupsConfig
@@ -69,20 +76,28 @@ func (server HTTPServer) getContentForRuleV1(writer http.ResponseWriter, request
 		return
 	}
 
-	ruleContent, err := content.GetRuleContentV1(ruleID)
+	// version and content must come from the same snapshot
+	snapshot, err := content.GetContentSnapshot()
 	if err != nil {
 		handleServerError(writer, err)
 		return
 	}
 
-	// check for internal rule permissions
-	if internal := content.IsRuleInternal(ruleID); internal {
-		err := server.checkInternalRulePermissions(request)
-		if err != nil {
-			log.Error().Err(err).Send()
-			handleServerError(writer, err)
-			return
-		}
+	version := snapshot.Version()
+	ruleContent, err := snapshot.GetRuleContentV1(ruleID)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	err = checkRuleVisibility(server.ruleVisibility(request), ruleID, snapshot.GetErrorKeysTags(ruleID)...)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	if checkNotModified(writer, request, contentETag(version), version.ModifiedAt) {
+		return
 	}
 
 	err = responses.SendOK(writer, responses.BuildOkResponseWithData("content", ruleContent))
@@ -94,25 +109,30 @@ func (server HTTPServer) getContentForRuleV1(writer http.ResponseWriter, request
 
 // getContent retrieves all the static content
 func (server HTTPServer) getContentV1(writer http.ResponseWriter, request *http.Request) {
-	// Generate an array of RuleContent
-	allRules, err := content.GetAllContentV1()
-
+	snapshot, err := content.GetContentSnapshot()
 	if err != nil {
 		log.Error().Err(err).Send()
 		handleServerError(writer, err)
 		return
 	}
+	version := snapshot.Version()
+
+	// Generate an array of RuleContent
+	allRules := snapshot.GetAllContentV1()
 
 	var rules []sptypes.RuleContentV1
 
-	if err := server.checkInternalRulePermissions(request); err != nil {
-		for _, rule := range allRules {
-			if !content.IsRuleInternal(types.RuleID(rule.Plugin.PythonModule)) {
-				rules = append(rules, rule)
-			}
+	visibility := server.ruleVisibility(request)
+	for _, rule := range allRules {
+		ruleID := types.RuleID(rule.Plugin.PythonModule)
+		if visibility.Allows(ruleID, snapshot.GetErrorKeysTags(ruleID)...) {
+			rules = append(rules, rule)
 		}
-	} else {
-		rules = allRules
+	}
+
+	modifiedAt := lastModified(version.ModifiedAt, server.visibilityModifiedAt())
+	if checkNotModified(writer, request, contentETag(version, visibilityPart(visibility)), modifiedAt) {
+		return
 	}
 
 	err = responses.SendOK(writer, responses.BuildOkResponseWithData("content", rules))
@@ -136,24 +156,29 @@ func (server HTTPServer) getClustersForOrg(writer http.ResponseWriter, request *
 
 // getRuleIDs returns a list of the names of the rules
 func (server HTTPServer) getRuleIDs(writer http.ResponseWriter, request *http.Request) {
-	allRuleIDs, err := content.GetRuleIDs()
-
+	snapshot, err := content.GetContentSnapshot()
 	if err != nil {
 		log.Error().Err(err).Send()
 		handleServerError(writer, err)
 		return
 	}
 
+	version := snapshot.Version()
+	allRuleIDs := snapshot.GetRuleIDs()
+
 	var ruleIDs []string
 
-	if err := server.checkInternalRulePermissions(request); err != nil {
-		for _, rule := range allRuleIDs {
-			if !content.IsRuleInternal(types.RuleID(rule)) {
-				ruleIDs = append(ruleIDs, rule)
-			}
+	visibility := server.ruleVisibility(request)
+	for _, rule := range allRuleIDs {
+		ruleID := types.RuleID(rule)
+		if visibility.Allows(ruleID, snapshot.GetErrorKeysTags(ruleID)...) {
+			ruleIDs = append(ruleIDs, rule)
 		}
-	} else {
-		ruleIDs = allRuleIDs
+	}
+
+	modifiedAt := lastModified(version.ModifiedAt, server.visibilityModifiedAt())
+	if checkNotModified(writer, request, contentETag(version, visibilityPart(visibility)), modifiedAt) {
+		return
 	}
 
 	if err := responses.SendOK(writer, responses.BuildOkResponseWithData("rules", ruleIDs)); err != nil {
@@ -176,6 +201,11 @@ func (server HTTPServer) getOrganizationOverview(
 		ClustersHitByTag:       make(map[string]int),
 	}
 
+	snapshot, err := content.GetContentSnapshot()
+	if err != nil {
+		return overview, err
+	}
+
 	// iterates over clusters and their hitting recommendations, accesses map to the get rule severity
 	for i := range clusterInfoList {
 		clusterInfo := &clusterInfoList[i]
@@ -194,11 +224,8 @@ func (server HTTPServer) getOrganizationOverview(
 
 		var filteredRecommendations int
 		for _, ruleID := range enabledOnlyRecommendations {
-			ruleContent, err := content.GetContentForRecommendation(ruleID)
+			ruleContent, err := snapshot.GetContentForRecommendation(ruleID)
 			if err != nil {
-				if err, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
-					return overview, err
-				}
 				// missing rule content, simply omit the rule as we can't display anything
 				log.Error().Err(err).Msgf("unable to get content for rule with id %v", ruleID)
 				filteredRecommendations++
@@ -301,6 +328,11 @@ func generateOrgOverview(
 	hitsByTotalRisk := make(map[int]int)
 	hitsByTags := make(map[string]int)
 
+	snapshot, err := content.GetContentSnapshot()
+	if err != nil {
+		return sptypes.OrgOverviewResponse{}, err
+	}
+
 	for _, singleReport := range aggregatorReport.Reports {
 		var clusterReport types.ReportRules
 
@@ -323,11 +355,8 @@ func generateOrgOverview(
 
 			ruleID := rule.Module
 			errorKey := rule.ErrorKey
-			ruleWithContent, err := content.GetRuleWithErrorKeyContent(ruleID, errorKey)
+			ruleWithContent, err := snapshot.GetRuleWithErrorKeyContent(ruleID, errorKey)
 			if err != nil {
-				if _, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
-					return sptypes.OrgOverviewResponse{}, err
-				}
 				log.Error().Err(err).Msgf("Unable to retrieve content for rule %s", ruleID)
 				continue
 			}
@@ -394,7 +423,7 @@ func (server *HTTPServer) fillInContentServiceInfoParams() map[string]string {
 	url := httputils.MakeURLToEndpoint(
 		server.ServicesConfig.ContentBaseEndpoint,
 		infoEndpoint)
-	return infoFromService(url)
+	return infoFromService(server.ServicesConfig.ContentClient(), url)
 }
 
 // fillInAggregatorInfoParams method fills-in info parameters needed for /info
@@ -404,14 +433,14 @@ func (server *HTTPServer) fillInAggregatorInfoParams() map[string]string {
 	url := httputils.MakeURLToEndpoint(
 		server.ServicesConfig.AggregatorBaseEndpoint,
 		infoEndpoint)
-	return infoFromService(url)
+	return infoFromService(server.ServicesConfig.AggregatorClient(), url)
 }
 
 // infoFromService retrieves info parameters through /info endpoint and make a
 // map from it
-func infoFromService(url string) map[string]string {
+func infoFromService(client *http.Client, url string) map[string]string {
 	log.Info().Str("URL to service endpoint", url).Msg("Getting info from service")
-	m, err := readInfoAPIEndpoint(url)
+	m, err := readInfoAPIEndpoint(client, url)
 
 	// service access was not ok
 	if err != nil {
@@ -428,10 +457,10 @@ func infoFromService(url string) map[string]string {
 
 // readInfoAPIEndpoint function performs REST API request and parse the
 // returned response
-func readInfoAPIEndpoint(url string) (map[string]string, error) {
+func readInfoAPIEndpoint(client *http.Client, url string) (map[string]string, error) {
 	// perform GET request to given service
 	// nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
-	response, err := http.Get(url) // #nosec G107
+	response, err := client.Get(url) // #nosec G107
 
 	// error happening during GET request
 	if err != nil {
@@ -63,31 +63,26 @@ const (
 	RedisNotInitializedErrorMessage = "Redis is not initialized, request can not be finished correctly"
 )
 
-// getContentCheckInternal retrieves static content for the given ruleID and if the rule is internal,
-// checks if user has permissions to access it.
-func (server HTTPServer) getContentCheckInternal(ruleID ctypes.RuleID, request *http.Request) (
+// getContentCheckInternal retrieves static content for the given ruleID from
+// the snapshot and checks if the rule is visible to the requester.
+func (server HTTPServer) getContentCheckInternal(
+	snapshot *content.RulesWithContentStorage, ruleID ctypes.RuleID, request *http.Request,
+) (
 	ruleContent *types.RuleWithContent,
 	err error,
 ) {
-	ruleContent, err = content.GetContentForRecommendation(ruleID)
+	ruleContent, err = snapshot.GetContentForRecommendation(ruleID)
 	if err != nil {
 		return
 	}
 
-	// check for internal rule permissions
-	if internal := content.IsRuleInternal(ruleID); internal {
-		err = server.checkInternalRulePermissions(request)
-		if err != nil {
-			log.Error().Err(err).Send()
-			return
-		}
-	}
-
+	err = checkRuleVisibility(server.ruleVisibility(request), ruleContent.Module, ruleContent.Tags)
 	return
 }
 
 // getRuleWithGroups retrieves static content for the given ruleID along with rule groups
 func (server HTTPServer) getRuleWithGroups(
+	snapshot *content.RulesWithContentStorage,
 	request *http.Request,
 	ruleID ctypes.RuleID,
 ) (
@@ -95,7 +90,7 @@ func (server HTTPServer) getRuleWithGroups(
 	ruleGroups []groups.Group,
 	err error,
 ) {
-	ruleContent, err = server.getContentCheckInternal(ruleID, request)
+	ruleContent, err = server.getContentCheckInternal(snapshot, ruleID, request)
 	if err != nil {
 		log.Error().Msgf("error retrieving rule content for rule ID %v", ruleID)
 		return
@@ -121,13 +116,26 @@ func (server HTTPServer) getRecommendationContent(writer http.ResponseWriter, re
 		return
 	}
 
-	ruleContent, ruleGroups, err := server.getRuleWithGroups(request, ruleID)
+	// version and content must come from the same snapshot
+	snapshot, err := content.GetContentSnapshot()
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	version := snapshot.Version()
+	ruleContent, ruleGroups, err := server.getRuleWithGroups(snapshot, request, ruleID)
 	if err != nil {
 		log.Error().Err(err).Msgf("error retrieving rule content and groups for rule ID %v", ruleID)
 		handleServerError(writer, err)
 		return
 	}
 
+	modifiedAt := lastModified(version.ModifiedAt, server.groupsModifiedAt())
+	if checkNotModified(writer, request, contentETag(version, jsonHash(ruleGroups)), modifiedAt) {
+		return
+	}
+
 	contentResponse := types.RecommendationContent{
 		// RuleID in rule.module|ERROR_KEY format
 		RuleSelector: ctypes.RuleSelector(ruleID),
@@ -174,7 +182,13 @@ func (server HTTPServer) getRecommendationContentWithUserData(writer http.Respon
 		return
 	}
 
-	ruleContent, ruleGroups, err := server.getRuleWithGroups(request, ruleID)
+	snapshot, err := content.GetContentSnapshot()
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	ruleContent, ruleGroups, err := server.getRuleWithGroups(snapshot, request, ruleID)
 	if err != nil {
 		log.Error().Err(err).Msgf("error retrieving rule content and groups for rule ID %v", ruleID)
 		handleServerError(writer, err)
@@ -260,6 +274,19 @@ func (server HTTPServer) getRecommendations(writer http.ResponseWriter, request
 	}
 	log.Info().Int(orgIDTag, int(orgID)).Str(userIDTag, string(userID)).Msg("getRecommendations start")
 
+	query, err := readRecommendationsListQuery(request)
+	if err != nil {
+		log.Error().Err(err).Msg("getRecommendations invalid query parameters")
+		handleServerError(writer, err)
+		return
+	}
+
+	asCSV, err := readCSVFormat(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
 	activeClustersInfo, err := server.readClusterInfoForOrgID(orgID)
 	if err != nil {
 		log.Error().Err(err).Int(orgIDTag, int(orgID)).Msg("problem reading cluster list for org")
@@ -302,6 +329,7 @@ func (server HTTPServer) getRecommendations(writer http.ResponseWriter, request
 
 	recommendationList, err = getFilteredRecommendationsList(
 		activeClustersInfo, impactingRecommendations, impactingFlag, ackedRulesMap, disabledClustersForRules,
+		server.ruleVisibility(request),
 	)
 
 	if err != nil {
@@ -314,9 +342,28 @@ func (server HTTPServer) getRecommendations(writer http.ResponseWriter, request
 		Str(userIDTag, string(userID)).
 		Msgf("number of final recommendations: %d", len(recommendationList))
 
+	page, filteredCount := query.apply(recommendationList)
+
+	if asCSV {
+		if err := sendRecommendationsListCSV(writer, orgID, page); err != nil {
+			log.Error().Err(err).Msg(problemSendingResponseError)
+		}
+		log.Info().Uint32(orgIDTag, uint32(orgID)).Msgf(
+			"getRecommendations took %s", time.Since(tStart),
+		)
+		return
+	}
+
 	resp := make(map[string]interface{})
 	resp["status"] = OkMsg
-	resp["recommendations"] = recommendationList
+	resp["meta"] = map[string]int{
+		"count":    len(page),
+		"total":    len(recommendationList),
+		"filtered": filteredCount,
+		"offset":   query.pagination.offset,
+		"limit":    query.pagination.limit,
+	}
+	resp["recommendations"] = page
 
 	log.Info().Uint32(orgIDTag, uint32(orgID)).Msgf(
 		"getRecommendations took %s", time.Since(tStart),
@@ -390,6 +437,19 @@ func (server HTTPServer) getClustersView(writer http.ResponseWriter, request *ht
 	}
 	log.Info().Int(orgIDTag, int(orgID)).Str(userIDTag, string(userID)).Msg("getClustersView start")
 
+	query, err := readClustersListQuery(request)
+	if err != nil {
+		log.Error().Err(err).Msg("getClustersView invalid query parameters")
+		handleServerError(writer, err)
+		return
+	}
+
+	asCSV, err := readCSVFormat(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
 	clusterList, clusterRuleHits, ackedRulesMap, disabledRules := server.getClusterListAndUserData(
 		writer,
 		orgID,
@@ -407,13 +467,27 @@ func (server HTTPServer) getClustersView(writer http.ResponseWriter, request *ht
 	log.Info().Uint32(orgIDTag, uint32(orgID)).Msgf("time since getClustersView start, after matchClusterInfoAndUserData took %s", time.Since(tStart))
 	log.Info().Uint32(orgIDTag, uint32(orgID)).Msgf("getClustersView final number %v", len(clusterViewResponse))
 
+	page, filteredCount := query.apply(clusterViewResponse)
+
+	if asCSV {
+		if err := sendClustersListCSV(writer, orgID, page); err != nil {
+			log.Error().Err(err).Msg(problemSendingResponseError)
+		}
+		log.Info().Uint32(orgIDTag, uint32(orgID)).Msgf("getClustersView took %s", time.Since(tStart))
+		return
+	}
+
 	resp := make(map[string]interface{})
 	metaCount := map[string]int{
-		"count": len(clusterViewResponse),
+		"count":    len(page),
+		"total":    len(clusterViewResponse),
+		"filtered": filteredCount,
+		"offset":   query.pagination.offset,
+		"limit":    query.pagination.limit,
 	}
 	resp["status"] = OkMsg
 	resp["meta"] = metaCount
-	resp["data"] = clusterViewResponse
+	resp["data"] = page
 
 	log.Info().Uint32(orgIDTag, uint32(orgID)).Msgf("getClustersView took %s", time.Since(tStart))
 
@@ -479,15 +553,14 @@ func matchClusterInfoAndUserData(
 ) {
 	clusterListView := make([]types.ClusterListView, 0)
 
-	recommendationSeverities, uniqueSeverities, err := content.GetExternalRuleSeverities()
+	// severities and managed info must come from the same snapshot
+	snapshot, err := content.GetContentSnapshot()
 	if err != nil {
 		return clusterListView, err
 	}
 
-	rulesManagedInfo, err := content.GetExternalRulesManagedInfo()
-	if err != nil {
-		return clusterListView, err
-	}
+	recommendationSeverities, uniqueSeverities := snapshot.GetExternalRuleSeverities()
+	rulesManagedInfo := snapshot.GetExternalRulesManagedInfo()
 
 	// iterates over clusters and their hitting recommendations, accesses map to the get rule severity
 	for i := range clusterInfoList {
@@ -496,6 +569,11 @@ func matchClusterInfoAndUserData(
 			ClusterName:     clusterInfoList[i].DisplayName,
 			Managed:         clusterInfoList[i].Managed,
 			HitsByTotalRisk: make(map[int]int),
+			Version:         clusterInfoList[i].Version,
+			Product:         clusterInfoList[i].Product,
+			CloudProvider:   clusterInfoList[i].CloudProvider,
+			Region:          clusterInfoList[i].Region,
+			CreatedAt:       clusterInfoList[i].CreatedAt,
 		}
 
 		// zero in unique severities to have constitent response
@@ -508,7 +586,10 @@ func matchClusterInfoAndUserData(
 			clusterViewItem.LastCheckedAt = types.Timestamp(
 				hittingRecommendations.CreatedAt.UTC().Format(time.RFC3339),
 			)
-			clusterViewItem.Version = hittingRecommendations.Meta.Version
+			// version from the latest report is more recent than the one known to AMS API
+			if hittingRecommendations.Meta.Version != "" {
+				clusterViewItem.Version = hittingRecommendations.Meta.Version
+			}
 
 			// filter out acked and disabled rules
 			enabledOnlyRecommendations := filterOutDisabledRules(
@@ -638,6 +719,7 @@ func getFilteredRecommendationsList(
 	impactingFlag types.ImpactingFlag,
 	ruleAcksMap map[types.RuleID]bool,
 	disabledClustersForRules map[types.RuleID][]types.ClusterName,
+	visibility services.RuleVisibility,
 ) (
 	recommendationList []types.RecommendationListView,
 	err error,
@@ -645,17 +727,19 @@ func getFilteredRecommendationsList(
 	clusterInfoMap := types.ClusterInfoArrayToMap(activeClustersInfo)
 	recommendationList = make([]types.RecommendationListView, 0)
 
+	snapshot, err := content.GetContentSnapshot()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to retrieve rule content")
+		return
+	}
+
 	var ruleIDList []ctypes.RuleID
 	if impactingFlag == OnlyImpacting {
 		// retrieve content only for impacting rules
 		ruleIDList = generateImpactingRuleIDList(impactingRecommendations)
 	} else {
 		// retrieve content for all external rules and decide whether exclude impacting in loop
-		ruleIDList, err = content.GetExternalRuleIDs()
-		if err != nil {
-			log.Error().Err(err).Msg("unable to retrieve external rule ids from content directory")
-			return
-		}
+		ruleIDList = snapshot.GetExternalRuleIDs()
 	}
 
 	// iterate over rules and count impacted clusters, exluding user disabled ones
@@ -678,16 +762,17 @@ func getFilteredRecommendationsList(
 			impactingClustersList = excludeDisabledClusters(impactingClustersList, disabledClusters)
 		}
 
-		ruleContent, err := content.GetContentForRecommendation(ruleID)
+		ruleContent, err := snapshot.GetContentForRecommendation(ruleID)
 		if err != nil {
-			if err, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
-				return recommendationList, err
-			}
 			// missing rule content, simply omit the rule as we can't display anything
 			log.Error().Err(err).Msgf("unable to get content for rule with id %v", ruleID)
 			continue
 		}
 
+		if !visibility.Allows(ruleContent.Module, ruleContent.Tags) {
+			continue
+		}
+
 		if !ruleContent.OSDCustomer {
 			// rule doesn't have osd_customer tag, so it doesn't apply to managed clusters
 			for _, clusterID := range impactingClustersList {
@@ -749,7 +834,7 @@ func (server HTTPServer) getImpactingRecommendations(
 
 	// #nosec G107
 	// nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
-	aggregatorResp, err := http.Post(aggregatorURL, JSONContentType, bytes.NewBuffer(jsonMarshalled))
+	aggregatorResp, err := server.ServicesConfig.AggregatorClient().Post(aggregatorURL, JSONContentType, bytes.NewBuffer(jsonMarshalled))
 	if err != nil {
 		log.Error().Err(err).Msgf("getImpactingRecommendations problem getting response from aggregator")
 		handleServerError(writer, err)
@@ -812,7 +897,7 @@ func (server HTTPServer) getClustersAndRecommendations(
 
 	// #nosec G107
 	// nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
-	aggregatorResp, err := http.Post(aggregatorURL, JSONContentType, bytes.NewBuffer(jsonMarshalled))
+	aggregatorResp, err := server.ServicesConfig.AggregatorClient().Post(aggregatorURL, JSONContentType, bytes.NewBuffer(jsonMarshalled))
 	if err != nil {
 		log.Error().Err(err).Msgf("getClustersAndRecommendations problem getting response from aggregator")
 		if _, ok := err.(*url.Error); ok {
@@ -852,23 +937,24 @@ func (server HTTPServer) getClustersAndRecommendations(
 
 // getContent retrieves all the static content tied with groups info
 func (server HTTPServer) getContentWithGroups(writer http.ResponseWriter, request *http.Request) {
-	// Generate an array of RuleContent
-	allRules, err := content.GetAllContentV2()
+	snapshot, err := content.GetContentSnapshot()
 	if err != nil {
 		handleServerError(writer, err)
 		return
 	}
+	version := snapshot.Version()
+
+	// Generate an array of RuleContent
+	allRules := snapshot.GetAllContentV2()
 
 	var rules []types.RuleContentV2
 
-	if err := server.checkInternalRulePermissions(request); err != nil {
-		for _, rule := range allRules {
-			if !content.IsRuleInternal(ctypes.RuleID(rule.Plugin.PythonModule)) {
-				rules = append(rules, rule)
-			}
+	visibility := server.ruleVisibility(request)
+	for _, rule := range allRules {
+		ruleID := ctypes.RuleID(rule.Plugin.PythonModule)
+		if visibility.Allows(ruleID, snapshot.GetErrorKeysTags(ruleID)...) {
+			rules = append(rules, rule)
 		}
-	} else {
-		rules = allRules
 	}
 
 	// retrieve the latest groups configuration
@@ -877,6 +963,13 @@ func (server HTTPServer) getContentWithGroups(writer http.ResponseWriter, reques
 		handleServerError(writer, err)
 		return
 	}
+
+	etag := contentETag(version, visibilityPart(visibility), jsonHash(ruleGroups))
+	modifiedAt := lastModified(version.ModifiedAt, server.groupsModifiedAt(), server.visibilityModifiedAt())
+	if checkNotModified(writer, request, etag, modifiedAt) {
+		return
+	}
+
 	// prepare data structure for building response
 	responseContent := make(map[string]interface{})
 	responseContent["status"] = OkMsg
@@ -891,15 +984,105 @@ func (server HTTPServer) getContentWithGroups(writer http.ResponseWriter, reques
 	}
 }
 
+// getContentVersions returns versions of the static content kept in memory,
+// the newest first
+func (server HTTPServer) getContentVersions(writer http.ResponseWriter, request *http.Request) {
+	// make sure the content has been loaded
+	if _, err := content.GetContentSnapshot(); err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	versions := content.GetContentVersions()
+
+	err := responses.SendOK(writer, responses.BuildOkResponseWithData("versions", versions))
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+}
+
+// getContentDiff returns differences between two versions of the static
+// content. When versions are not specified, the current version is compared
+// with the previous one.
+func (server HTTPServer) getContentDiff(writer http.ResponseWriter, request *http.Request) {
+	// make sure the content has been loaded
+	if _, err := content.GetContentSnapshot(); err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	diff, err := content.GetContentDiff(
+		request.URL.Query().Get(FromVersionParam),
+		request.URL.Query().Get(ToVersionParam),
+		server.ruleVisibility(request),
+	)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	err = responses.SendOK(writer, responses.BuildOkResponseWithData("diff", diff))
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+}
+
+// searchContent returns recommendations whose static content contains all
+// words of the query, the most relevant first
+func (server HTTPServer) searchContent(writer http.ResponseWriter, request *http.Request) {
+	query := strings.TrimSpace(request.URL.Query().Get(SearchQueryParam))
+	if query == "" {
+		handleServerError(writer, &RouterMissingParamError{ParamName: SearchQueryParam})
+		return
+	}
+
+	pagination, err := readPaginationParams(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	snapshot, err := content.GetContentSnapshot()
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	// only rules visible to the requester are searched, the same way as in
+	// getContentCheckInternal
+	results := snapshot.Search(query, server.ruleVisibility(request))
+	start, end := pagination.bounds(len(results))
+	page := results[start:end]
+
+	resp := make(map[string]interface{})
+	resp["status"] = OkMsg
+	resp["meta"] = map[string]int{
+		"count":  len(page),
+		"total":  len(results),
+		"offset": pagination.offset,
+		"limit":  pagination.limit,
+	}
+	resp["results"] = page
+
+	err = responses.SendOK(writer, resp)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+}
+
 // getImpactedClustersFromAggregator sends GET to aggregator with or without content
 // depending on the list of active clusters provided by the AMS client.
 func getImpactedClustersFromAggregator(
+	client *http.Client,
 	url string,
 	activeClusters []ctypes.ClusterName,
 ) (resp *http.Response, err error) {
 	if len(activeClusters) < 1 {
 		// #nosec G107
-		resp, err = http.Get(url)
+		resp, err = client.Get(url)
 		return
 	}
 
@@ -920,7 +1103,6 @@ func getImpactedClustersFromAggregator(
 	}
 
 	req.Header.Set(contentTypeHeader, JSONContentType)
-	client := &http.Client{}
 	resp, err = client.Do(req)
 	return
 }
@@ -952,8 +1134,10 @@ func (server HTTPServer) getImpactedClusters(
 	)
 
 	// nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
-	aggregatorResp, err := getImpactedClustersFromAggregator(aggregatorURL, activeClusters)
-	// if http.Get fails for whatever reason
+	aggregatorResp, err := getImpactedClustersFromAggregator(
+		server.ServicesConfig.AggregatorClient(), aggregatorURL, activeClusters,
+	)
+	// if the request fails for whatever reason
 	if err != nil {
 		handleServerError(writer, err)
 		return []ctypes.HittingClustersData{}, err
@@ -995,6 +1179,12 @@ func (server HTTPServer) getClustersDetailForRule(writer http.ResponseWriter, re
 		return
 	}
 
+	asCSV, err := readCSVFormat(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
 	recommendation, err := content.GetContentForRecommendation(ctypes.RuleID(selector))
 	if err != nil {
 		// The given rule selector does not exit
@@ -1002,6 +1192,12 @@ func (server HTTPServer) getClustersDetailForRule(writer http.ResponseWriter, re
 		return
 	}
 
+	err = checkRuleVisibility(server.ruleVisibility(request), recommendation.Module, recommendation.Tags)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
 	// Get list of clusters for given organization
 	activeClustersInfo, err := server.readClusterInfoForOrgID(orgID)
 	if err != nil {
@@ -1040,7 +1236,16 @@ func (server HTTPServer) getClustersDetailForRule(writer http.ResponseWriter, re
 		return
 	}
 
-	err = server.processClustersDetailResponse(impactedClusters, disabledClusters, activeClustersInfo, writer)
+	data := processClustersDetailData(impactedClusters, disabledClusters, activeClustersInfo)
+
+	if asCSV {
+		err = sendClustersDetailCSV(writer, orgID, data)
+	} else {
+		err = responses.Send(http.StatusOK, writer, types.ClustersDetailResponse{
+			Status: OkMsg,
+			Data:   data,
+		})
+	}
 	if err != nil {
 		log.Error().Err(err).Int(orgIDTag, int(orgID)).Str(userIDTag, string(userID)).Str(selectorStr, string(selector)).
 			Msg("Couldn't process response for clusters detail")
@@ -1072,7 +1277,7 @@ func (server *HTTPServer) getListOfDisabledClusters(
 
 	// #nosec G107
 	// nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
-	resp, err := http.Get(aggregatorURL)
+	resp, err := server.ServicesConfig.AggregatorClient().Get(aggregatorURL)
 	if err != nil {
 		return nil, err
 	}
@@ -1092,13 +1297,12 @@ func (server *HTTPServer) getListOfDisabledClusters(
 	return response.DisabledClusters, nil
 }
 
-// processClustersDetailResponse processes responses from aggregator and AMS API and sends a response
-func (server *HTTPServer) processClustersDetailResponse(
+// processClustersDetailData processes responses from aggregator and AMS API into clusters detail data
+func processClustersDetailData(
 	impactedClusters []ctypes.HittingClustersData,
 	disabledClusters []ctypes.DisabledClusterInfo,
 	clusterInfo []types.ClusterInfo,
-	writer http.ResponseWriter,
-) error {
+) types.ClustersDetailData {
 	data := types.ClustersDetailData{
 		EnabledClusters:  make([]ctypes.HittingClustersData, 0),
 		DisabledClusters: make([]ctypes.DisabledClusterInfo, 0),
@@ -1126,12 +1330,7 @@ func (server *HTTPServer) processClustersDetailResponse(
 		data.EnabledClusters = append(data.EnabledClusters, impactedC)
 	}
 
-	response := types.ClustersDetailResponse{
-		Status: OkMsg,
-		Data:   data,
-	}
-
-	return responses.Send(http.StatusOK, writer, response)
+	return data
 }
 
 // getRequestStatusForCluster method implements endpoint that should return a status
@@ -1162,7 +1361,7 @@ func (server *HTTPServer) getRequestStatusForCluster(writer http.ResponseWriter,
 		return
 	}
 
-	// get request ID list from Redis using SCAN command
+	// get request ID list from Redis
 	requestIDsForCluster, err := server.redis.GetRequestIDsForClusterID(orgID, clusterID)
 	if err != nil {
 		handleServerError(writer, err)
@@ -1208,7 +1407,8 @@ func (server *HTTPServer) getRequestStatusForCluster(writer http.ResponseWriter,
 }
 
 // getRequestsForCluster method implements endpoint that should return a list of
-// all request IDs and their details for given cluster
+// all request IDs and their details for given cluster. The list can be
+// filtered by received timestamp, ordered and limited by query parameters.
 func (server *HTTPServer) getRequestsForCluster(writer http.ResponseWriter, request *http.Request) {
 	orgID, err := server.GetCurrentOrgID(request)
 	if err != nil {
@@ -1223,13 +1423,19 @@ func (server *HTTPServer) getRequestsForCluster(writer http.ResponseWriter, requ
 		return
 	}
 
+	query, err := readRequestsListQuery(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
 	// make sure we don't access server.redis when it's nil
 	if !server.checkRedisClientReadiness(writer) {
 		// error has been handled already
 		return
 	}
 
-	// get request ID list from Redis using SCAN command
+	// get request ID list from Redis
 	requestIDsForCluster, err := server.redis.GetRequestIDsForClusterID(orgID, clusterID)
 	if err != nil {
 		handleServerError(writer, err)
@@ -1253,7 +1459,7 @@ func (server *HTTPServer) getRequestsForCluster(writer http.ResponseWriter, requ
 	// prepare data structure
 	responseData := map[string]interface{}{}
 	responseData["cluster"] = string(clusterID)
-	responseData["requests"] = requestIDsData
+	responseData["requests"] = query.apply(requestIDsData)
 	responseData["status"] = OkMsg
 
 	// send response to client
@@ -1286,6 +1492,12 @@ func (server *HTTPServer) getRequestsForClusterPostVariant(writer http.ResponseW
 
 	log.Debug().Str("selected cluster", string(clusterID)).Msg(logMsg)
 
+	query, err := readRequestsListQuery(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
 	// get request ID list from request body
 	requestIDsForCluster, err := readRequestIDList(writer, request)
 	if err != nil {
@@ -1319,7 +1531,7 @@ func (server *HTTPServer) getRequestsForClusterPostVariant(writer http.ResponseW
 	// prepare data structure
 	responseData := map[string]interface{}{}
 	responseData["cluster"] = string(clusterID)
-	responseData["requests"] = requestIDsData
+	responseData["requests"] = query.apply(requestIDsData)
 	responseData["status"] = OkMsg
 
 	// send response to client
@@ -1331,7 +1543,8 @@ func (server *HTTPServer) getRequestsForClusterPostVariant(writer http.ResponseW
 }
 
 // getReportForRequest method implements endpoint that should return
-// simplified result for given request ID
+// simplified result for given request ID. Full rule content is returned when
+// requested by the details parameter and when it's available.
 func (server *HTTPServer) getReportForRequest(writer http.ResponseWriter, request *http.Request) {
 	orgID, err := server.GetCurrentOrgID(request)
 	if err != nil {
@@ -1352,14 +1565,33 @@ func (server *HTTPServer) getReportForRequest(writer http.ResponseWriter, reques
 		return
 	}
 
+	details, err := readDetailsParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	render, err := readRenderParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
 	// make sure we don't access server.redis when it's nil
 	if !server.checkRedisClientReadiness(writer) {
 		// error has been handled already
 		return
 	}
 
-	// get rule hits from Redis
-	ruleHits, err := server.redis.GetRuleHitsForRequest(orgID, clusterID, requestID)
+	// get rule hits from Redis, together with their template data when
+	// details are requested
+	var ruleHits []types.RuleID
+	var templateData map[types.RuleID]interface{}
+	if details {
+		ruleHits, templateData, err = server.redis.GetRuleHitsWithTemplateDataForRequest(orgID, clusterID, requestID)
+	} else {
+		ruleHits, err = server.redis.GetRuleHitsForRequest(orgID, clusterID, requestID)
+	}
 	if err != nil {
 		handleServerError(writer, err)
 		return
@@ -1380,14 +1612,29 @@ func (server *HTTPServer) getReportForRequest(writer http.ResponseWriter, reques
 		return
 	}
 
-	filteredRuleHits := filterRulesGetContent(ruleHits, ackedRulesMap, disabledRulesForCluster)
-
 	// prepare response
 	responseData := map[string]interface{}{}
 	responseData["cluster"] = string(clusterID)
 	responseData["requestID"] = requestID
 	responseData["status"] = StatusProcessed
-	responseData["report"] = filteredRuleHits
+
+	// full rule content can be returned only when the template data have
+	// been stored together with the rule hits, simplified rule hits are
+	// returned otherwise
+	if details {
+		responseData["details"] = templateData != nil
+	}
+	if templateData != nil {
+		rulesWithContent := filterRulesGetDetails(
+			ruleHits, templateData, ackedRulesMap, disabledRulesForCluster, server.ruleVisibility(request),
+		)
+		render.apply(rulesWithContent)
+		responseData["report"] = rulesWithContent
+	} else {
+		responseData["report"] = filterRulesGetContent(
+			ruleHits, ackedRulesMap, disabledRulesForCluster, server.ruleVisibility(request),
+		)
+	}
 
 	// send response to client
 	err = responses.SendOK(writer, responseData)
@@ -1401,10 +1648,17 @@ func filterRulesGetContent(
 	ruleHits []types.RuleID,
 	ackedRules map[ctypes.RuleID]bool,
 	disabledRulesForCluster map[ctypes.RuleID]bool,
+	visibility services.RuleVisibility,
 ) []types.SimplifiedRuleHit {
 	// initialize the return value so that it's not nil (and in API response null)
 	filteredRuleHits := []types.SimplifiedRuleHit{}
 
+	snapshot, err := content.GetContentSnapshot()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to retrieve rule content")
+		return filteredRuleHits
+	}
+
 	for _, ruleID := range ruleHits {
 		// skip acked rule
 		if _, found := ackedRules[ruleID]; found {
@@ -1416,13 +1670,18 @@ func filterRulesGetContent(
 			continue
 		}
 
-		ruleContent, err := content.GetContentForRecommendation(ruleID)
+		ruleContent, err := snapshot.GetContentForRecommendation(ruleID)
 		if err != nil {
 			// rule content not found, log and skip as in other endpoints
 			log.Error().Err(err).Msgf("error retrieving rule content for rule %v", ruleID)
 			continue
 		}
 
+		// skip rules not visible to the requester
+		if !visibility.Allows(ruleContent.Module, ruleContent.Tags) {
+			continue
+		}
+
 		splitRuleID := strings.Split(string(ruleID), "|")
 		// fill in data from rule content
 		simplifiedRuleHit := types.SimplifiedRuleHit{
@@ -1438,6 +1697,61 @@ func filterRulesGetContent(
 	return filteredRuleHits
 }
 
+// filterRulesGetDetails filters rule hits the same way as
+// filterRulesGetContent, but it returns full rule content filled in by the
+// template data of the rule hits, the same as in cluster report
+func filterRulesGetDetails(
+	ruleHits []types.RuleID,
+	templateData map[types.RuleID]interface{},
+	ackedRules map[ctypes.RuleID]bool,
+	disabledRulesForCluster map[ctypes.RuleID]bool,
+	visibility services.RuleVisibility,
+) []types.RuleWithContentResponse {
+	// initialize the return value so that it's not nil (and in API response null)
+	rulesWithContent := []types.RuleWithContentResponse{}
+
+	snapshot, err := content.GetContentSnapshot()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to retrieve rule content")
+		return rulesWithContent
+	}
+
+	for _, ruleID := range ruleHits {
+		// skip acked rule
+		if _, found := ackedRules[ruleID]; found {
+			continue
+		}
+
+		// skip single disabled rules for given cluster
+		if _, found := disabledRulesForCluster[ruleID]; found {
+			continue
+		}
+
+		splitRuleID := strings.Split(string(ruleID), "|")
+		ruleOnReport := ctypes.RuleOnReport{
+			Module:       ctypes.RuleID(splitRuleID[0]),
+			ErrorKey:     ctypes.ErrorKey(splitRuleID[1]),
+			TemplateData: templateData[ruleID],
+		}
+
+		ruleWithContent, _, err := snapshot.FetchRuleContent(&ruleOnReport, false)
+		if err != nil {
+			// rule content not found, log and skip as in other endpoints
+			log.Error().Err(err).Msgf("error retrieving rule content for rule %v", ruleID)
+			continue
+		}
+
+		// skip rules not visible to the requester
+		if !visibility.Allows(ruleWithContent.RuleID, ruleWithContent.Tags) {
+			continue
+		}
+
+		rulesWithContent = append(rulesWithContent, *ruleWithContent)
+	}
+
+	return rulesWithContent
+}
+
 func (server HTTPServer) getDisabledRulesForClusterMap(
 	writer http.ResponseWriter,
 	orgID types.OrgID,
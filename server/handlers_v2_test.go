@@ -19,6 +19,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,6 +30,7 @@ import (
 	ctypes "github.com/RedHatInsights/insights-results-types"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
 	"github.com/RedHatInsights/insights-results-smart-proxy/server"
 	"github.com/RedHatInsights/insights-results-smart-proxy/services"
 	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
@@ -40,8 +43,8 @@ const (
 )
 
 var (
-	receivedTimestampTest  = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
-	processedTimestampTest = time.Now().UTC().Format(time.RFC3339)
+	receivedTimestampTest  = "2023-01-02T15:04:05Z"
+	processedTimestampTest = "2023-01-02T15:05:05Z"
 )
 
 func TestHTTPServer_SetRating(t *testing.T) {
@@ -70,8 +73,6 @@ func TestHTTPServer_SetRating(t *testing.T) {
 		&helpers.DefaultServerConfigXRH,
 		nil,
 		nil,
-		nil,
-		nil,
 		&helpers.APIRequest{
 			Method:      http.MethodPost,
 			Endpoint:    server.Rating,
@@ -197,7 +198,7 @@ func TestHTTPServer_ClustersDetailEndpointAggregatorResponseOk(t *testing.T) {
 		clusters[1], data.ClusterDisplayName2, disabledAt, justificationNote,
 	)
 
-	testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+	testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 
 	iou_helpers.AssertAPIRequest(
 		t,
@@ -307,7 +308,7 @@ func TestHTTPServer_ClustersDetailEndpointAggregatorResponseOk_ImpactedClusterDi
 
 	expectedResponse = fmt.Sprintf(expectedResponse, clusters[0], data.ClusterDisplayName1, disabledAt, justificationNote)
 
-	testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+	testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 
 	iou_helpers.AssertAPIRequest(
 		t,
@@ -421,7 +422,7 @@ func TestHTTPServer_ClustersDetailEndpointAggregatorResponseOk_DisabledClusterNo
 	// 2nd cluster is there
 	expectedResponse = fmt.Sprintf(expectedResponse, clusters[1], data.ClusterDisplayName2)
 
-	testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+	testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 
 	iou_helpers.AssertAPIRequest(
 		t,
@@ -490,7 +491,7 @@ func TestHTTPServer_ClustersDetailEndpointAMSManagedClusters(t *testing.T) {
 				"status":"ok"
 			}
 			`
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 
 		// cluster is managed, but rule is not == must not show as hitting
 		iou_helpers.AssertAPIRequest(
@@ -560,8 +561,6 @@ func TestHTTPServer_ClustersDetailEndpointAggregatorResponse400(t *testing.T) {
 		&helpers.DefaultServerConfigXRH,
 		nil,
 		nil,
-		nil,
-		nil,
 		&helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ClustersDetail,
@@ -628,8 +627,6 @@ func TestHTTPServer_ClustersDetailEndpointAggregatorResponse404(t *testing.T) {
 		&helpers.DefaultServerConfigXRH,
 		nil,
 		nil,
-		nil,
-		nil,
 		&helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ClustersDetail,
@@ -685,8 +682,6 @@ func TestHTTPServer_ClustersDetailEndpointAggregatorResponse500(t *testing.T) {
 		&helpers.DefaultServerConfigXRH,
 		nil,
 		nil,
-		nil,
-		nil,
 		&helpers.APIRequest{
 			Method:       http.MethodGet,
 			Endpoint:     server.ClustersDetail,
@@ -725,7 +720,7 @@ func TestHTTPServer_GetSingleClusterInfo(t *testing.T) {
 		expectedResponse = fmt.Sprintf(expectedResponse, clusterInfoList[0].ID, clusterInfoList[0].DisplayName,
 			clusterInfoList[0].Managed, clusterInfoList[0].Status,
 		)
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 
 		iou_helpers.AssertAPIRequest(
 			t,
@@ -754,7 +749,7 @@ func TestHTTPServer_GetSingleClusterInfoClusterNotFound(t *testing.T) {
 			[]types.ClusterInfo{},
 		)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, amsClientMock, nil, nil)
 
 		iou_helpers.AssertAPIRequest(
 			t,
@@ -776,7 +771,7 @@ func TestHTTPServer_GetRequestStatusForCluster_RedisNil(t *testing.T) {
 	helpers.RunTestWithTimeout(t, func(tt testing.TB) {
 		defer helpers.CleanAfterGock(t)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, nil, nil)
 
 		iou_helpers.AssertAPIRequest(
 			t,
@@ -800,9 +795,10 @@ func TestHTTPServer_GetRequestStatusForCluster_RedisError500(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		expectedKey := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName)
+		helpers.ExpectRequestIDsIndexMissing(redisServer, testdata.OrgID, testdata.ClusterName)
 		redisServer.ExpectScan(0, expectedKey, services.ScanBatchCount).SetErr(errors.New("Redis server failure"))
 
 		iou_helpers.AssertAPIRequest(
@@ -829,9 +825,10 @@ func TestHTTPServer_GetRequestStatusForCluster_NoRequestsForCluster(t *testing.T
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		expectedKey := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName)
+		helpers.ExpectRequestIDsIndexMissing(redisServer, testdata.OrgID, testdata.ClusterName)
 		redisServer.ExpectScan(0, expectedKey, services.ScanBatchCount).SetVal([]string{}, 0)
 
 		// no request IDs found
@@ -860,9 +857,10 @@ func TestHTTPServer_GetRequestStatusForCluster_RequestNotFound(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		expectedKey := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName)
+		helpers.ExpectRequestIDsIndexMissing(redisServer, testdata.OrgID, testdata.ClusterName)
 		redisServer.ExpectScan(0, expectedKey, services.ScanBatchCount).SetVal([]string{"requestIDNotTheOne", "requestIDAlsoNotTheOne"}, 0)
 
 		// request IDs found but don't match the requested one
@@ -892,7 +890,7 @@ func TestHTTPServer_GetRequestStatusForCluster_BadRequestClusterID(t *testing.T)
 		// mock server not needed because the request will not get to part requiring Redis
 		redisClient, _ := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// invalid clusterID
 		iou_helpers.AssertAPIRequest(
@@ -919,7 +917,7 @@ func TestHTTPServer_GetRequestStatusForCluster_BadRequestID(t *testing.T) {
 		// mock server not needed because the request will not get to part requiring Redis
 		redisClient, _ := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// invalid requestID in endpoint arg
 		iou_helpers.AssertAPIRequest(
@@ -946,7 +944,7 @@ func TestHTTPServer_GetRequestStatusForCluster_BadAuthToken(t *testing.T) {
 		// mock server not needed because the request will not get to part requiring Redis
 		redisClient, _ := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// bad token
 		iou_helpers.AssertAPIRequest(
@@ -971,9 +969,10 @@ func TestHTTPServer_GetRequestStatusForCluster_SingleRequestID(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		expectedKey := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName)
+		helpers.ExpectRequestIDsIndexMissing(redisServer, testdata.OrgID, testdata.ClusterName)
 		redisServer.ExpectScan(0, expectedKey, services.ScanBatchCount).SetVal([]string{"requestID1"}, 0)
 
 		expectedResponse := fmt.Sprintf(`{"cluster":"%v","requestID":"%v","status":"processed"}`, testdata.ClusterName, "requestID1")
@@ -1004,9 +1003,10 @@ func TestHTTPServer_GetRequestStatusForCluster_RequestIDOnSecondPage(t *testing.
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		expectedKey := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName)
+		helpers.ExpectRequestIDsIndexMissing(redisServer, testdata.OrgID, testdata.ClusterName)
 		redisServer.ExpectScan(0, expectedKey, services.ScanBatchCount).SetVal([]string{"requestID1"}, 42)
 		// requested request ID is found on the 2nd page returned from Redis (more Redis scenarios covered in services package)
 		redisServer.ExpectScan(42, expectedKey, services.ScanBatchCount).SetVal([]string{"requestID123"}, 0)
@@ -1039,9 +1039,10 @@ func TestHTTPServer_GetRequestsForCluster_OK1Request(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		expectedKey1stCommand := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName)
+		helpers.ExpectRequestIDsIndexMissing(redisServer, testdata.OrgID, testdata.ClusterName)
 		redisServer.ExpectScan(0, expectedKey1stCommand, services.ScanBatchCount).SetVal([]string{"requestID1"}, 0)
 
 		expectedKey2ndCommand := fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName, "requestID1")
@@ -1053,7 +1054,7 @@ func TestHTTPServer_GetRequestsForCluster_OK1Request(t *testing.T) {
 			"cluster":"%v",
 			"status":"ok",
 			"requests":[
-				{"processed":"%v", "received":"%v", "requestID":"requestID1", "valid":true}
+				{"processed":"%v", "received":"%v", "requestID":"requestID1", "valid":true, "latency":60}
 			]
 		}`, testdata.ClusterName, processedTimestampTest, receivedTimestampTest)
 
@@ -1076,13 +1077,143 @@ func TestHTTPServer_GetRequestsForCluster_OK1Request(t *testing.T) {
 	}, testTimeout)
 }
 
+// TestHTTPServer_GetRequestsForCluster_MemoryRedis checks that requests
+// stored in the in-memory Redis implementation are returned
+func TestHTTPServer_GetRequestsForCluster_MemoryRedis(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(tt testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		received := time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)
+		memoryRedis := services.NewMemoryRedis()
+		memoryRedis.AddRequest(services.MemoryRequest{
+			OrgID:     testdata.OrgID,
+			ClusterID: testdata.ClusterName,
+			RequestID: "requestID1",
+			Received:  received,
+			Processed: received.Add(time.Minute),
+		}, time.Hour)
+
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, memoryRedis, nil)
+
+		expectedResponse := fmt.Sprintf(`{
+			"cluster":"%v",
+			"status":"ok",
+			"requests":[
+				{"processed":"2023-01-02T15:05:05Z", "received":"2023-01-02T15:04:05Z", "requestID":"requestID1", "valid":true, "latency":60}
+			]
+		}`, testdata.ClusterName)
+
+		iou_helpers.AssertAPIRequest(
+			t,
+			testServer,
+			serverConfigJWT.APIv2Prefix,
+			&helpers.APIRequest{
+				Method:       http.MethodGet,
+				Endpoint:     server.ListAllRequestIDs,
+				EndpointArgs: []interface{}{testdata.ClusterName},
+				XRHIdentity:  goodXRHAuthToken,
+			}, &helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       expectedResponse,
+			},
+		)
+	}, testTimeout)
+}
+
+// TestHTTPServer_GetRequestsForCluster_Query checks that requests are
+// filtered, ordered and limited according to the query parameters
+func TestHTTPServer_GetRequestsForCluster_Query(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(tt testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		received := time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)
+		memoryRedis := services.NewMemoryRedis()
+		for i := 0; i < 3; i++ {
+			memoryRedis.AddRequest(services.MemoryRequest{
+				OrgID:     testdata.OrgID,
+				ClusterID: testdata.ClusterName,
+				RequestID: types.RequestID(fmt.Sprintf("requestID%d", i)),
+				Received:  received.Add(time.Duration(i) * time.Hour),
+				Processed: received.Add(time.Duration(i)*time.Hour + time.Duration(i+1)*time.Second),
+			}, 0)
+		}
+
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, memoryRedis, nil)
+
+		expectedResponse := fmt.Sprintf(`{
+			"cluster":"%v",
+			"status":"ok",
+			"requests":[
+				{"processed":"2023-01-02T16:04:07Z", "received":"2023-01-02T16:04:05Z", "requestID":"requestID1", "valid":true, "latency":2},
+				{"processed":"2023-01-02T17:04:08Z", "received":"2023-01-02T17:04:05Z", "requestID":"requestID2", "valid":true, "latency":3}
+			]
+		}`, testdata.ClusterName)
+
+		iou_helpers.AssertAPIRequest(
+			t,
+			testServer,
+			serverConfigJWT.APIv2Prefix,
+			&helpers.APIRequest{
+				Method:       http.MethodGet,
+				Endpoint:     server.ListAllRequestIDs + "?since=2023-01-02T16:00:00Z&order=asc&limit=2",
+				EndpointArgs: []interface{}{testdata.ClusterName},
+				XRHIdentity:  goodXRHAuthToken,
+			}, &helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       expectedResponse,
+			},
+		)
+
+		iou_helpers.AssertAPIRequest(
+			t,
+			testServer,
+			serverConfigJWT.APIv2Prefix,
+			&helpers.APIRequest{
+				Method:       http.MethodGet,
+				Endpoint:     server.ListAllRequestIDs + "?order=newest",
+				EndpointArgs: []interface{}{testdata.ClusterName},
+				XRHIdentity:  goodXRHAuthToken,
+			}, &helpers.APIResponse{
+				StatusCode: http.StatusBadRequest,
+			},
+		)
+
+		reqBody, err := json.Marshal([]types.RequestID{"requestID0", "requestID2", "requestID3"})
+		helpers.FailOnError(t, err)
+
+		expectedResponse = fmt.Sprintf(`{
+			"cluster":"%v",
+			"status":"ok",
+			"requests":[
+				{"processed":"2023-01-02T17:04:08Z", "received":"2023-01-02T17:04:05Z", "requestID":"requestID2", "valid":true, "latency":3}
+			]
+		}`, testdata.ClusterName)
+
+		iou_helpers.AssertAPIRequest(
+			t,
+			testServer,
+			serverConfigJWT.APIv2Prefix,
+			&helpers.APIRequest{
+				Method:       http.MethodPost,
+				Endpoint:     server.ListAllRequestIDs + "?limit=1",
+				EndpointArgs: []interface{}{testdata.ClusterName},
+				XRHIdentity:  goodXRHAuthToken,
+				Body:         reqBody,
+			}, &helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       expectedResponse,
+			},
+		)
+	}, testTimeout)
+}
+
 func TestHTTPServer_GetRequestsForCluster_OK3Requests(t *testing.T) {
 	helpers.RunTestWithTimeout(t, func(tt testing.TB) {
 		defer helpers.CleanAfterGock(t)
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		requestIDs := make([]string, 3)
 		for i := range requestIDs {
@@ -1090,6 +1221,7 @@ func TestHTTPServer_GetRequestsForCluster_OK3Requests(t *testing.T) {
 		}
 
 		expectedKey1stCommand := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName)
+		helpers.ExpectRequestIDsIndexMissing(redisServer, testdata.OrgID, testdata.ClusterName)
 		redisServer.ExpectScan(0, expectedKey1stCommand, services.ScanBatchCount).SetVal([]string{requestIDs[0], requestIDs[1], requestIDs[2]}, 0)
 
 		for i := range requestIDs {
@@ -1104,9 +1236,9 @@ func TestHTTPServer_GetRequestsForCluster_OK3Requests(t *testing.T) {
 			"cluster":"%v",
 			"status":"ok",
 			"requests":[
-				{"processed":"%v", "received":"%v", "requestID":"%v", "valid":true},
-				{"processed":"%v", "received":"%v", "requestID":"%v", "valid":true},
-				{"processed":"%v", "received":"%v", "requestID":"%v", "valid":true}
+				{"processed":"%v", "received":"%v", "requestID":"%v", "valid":true, "latency":60},
+				{"processed":"%v", "received":"%v", "requestID":"%v", "valid":true, "latency":60},
+				{"processed":"%v", "received":"%v", "requestID":"%v", "valid":true, "latency":60}
 			]
 		}`, testdata.ClusterName,
 			processedTimestampTest, receivedTimestampTest, requestIDs[0],
@@ -1139,9 +1271,10 @@ func TestHTTPServer_GetRequestsForCluster_RequestsNotFound(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		expectedKey := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName)
+		helpers.ExpectRequestIDsIndexMissing(redisServer, testdata.OrgID, testdata.ClusterName)
 		redisServer.ExpectScan(0, expectedKey, services.ScanBatchCount).SetVal([]string{}, 0)
 
 		// 2nd Redis call is not expected
@@ -1173,7 +1306,7 @@ func TestHTTPServer_GetRequestsForCluster_BadRequestClusterID(t *testing.T) {
 		// mock server not needed because the request will not get to part requiring Redis
 		redisClient, _ := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// invalid clusterID
 		iou_helpers.AssertAPIRequest(
@@ -1200,7 +1333,7 @@ func TestHTTPServer_GetRequestsForCluster_BadAuthToken(t *testing.T) {
 		// mock server not needed because the request will not get to part requiring Redis
 		redisClient, _ := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// invalid clusterID
 		iou_helpers.AssertAPIRequest(
@@ -1223,7 +1356,7 @@ func TestHTTPServer_GetRequestsForCluster_NoRedis(t *testing.T) {
 	helpers.RunTestWithTimeout(t, func(tt testing.TB) {
 		defer helpers.CleanAfterGock(t)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, nil, nil)
 
 		iou_helpers.AssertAPIRequest(
 			t,
@@ -1247,9 +1380,10 @@ func TestHTTPServer_GetRequestsForCluster_RedisError500(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		expectedKey1stCommand := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName)
+		helpers.ExpectRequestIDsIndexMissing(redisServer, testdata.OrgID, testdata.ClusterName)
 		redisServer.ExpectScan(0, expectedKey1stCommand, services.ScanBatchCount).SetErr(errors.New("Redis server failure"))
 
 		iou_helpers.AssertAPIRequest(
@@ -1276,9 +1410,10 @@ func TestHTTPServer_GetRequestsForCluster_RedisError500_2ndCmd(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		expectedKey1stCommand := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName)
+		helpers.ExpectRequestIDsIndexMissing(redisServer, testdata.OrgID, testdata.ClusterName)
 		redisServer.ExpectScan(0, expectedKey1stCommand, services.ScanBatchCount).SetVal([]string{"requestID1"}, 0)
 
 		expectedKey2ndCommand := fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName, "requestID1")
@@ -1310,7 +1445,7 @@ func TestHTTPServer_GetReportForRequest_OK1Request(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		expectedKey := fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName, "requestID1")
 		redisServer.ExpectHMGet(
@@ -1321,7 +1456,7 @@ func TestHTTPServer_GetReportForRequest_OK1Request(t *testing.T) {
 			"cluster":"%v",
 			"status":"ok",
 			"requests":[
-				{"processed":"%v", "received":"%v", "requestID":"requestID1", "valid":true}
+				{"processed":"%v", "received":"%v", "requestID":"requestID1", "valid":true, "latency":60}
 			]
 		}`, testdata.ClusterName, processedTimestampTest, receivedTimestampTest)
 
@@ -1354,7 +1489,7 @@ func TestHTTPServer_GetRequestsForClusterPostVariant_OK3Request1Found(t *testing
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		requestIDs := make([]string, 3)
 		for i := range requestIDs {
@@ -1378,9 +1513,9 @@ func TestHTTPServer_GetRequestsForClusterPostVariant_OK3Request1Found(t *testing
 			"cluster":"%v",
 			"status":"ok",
 			"requests":[
+				{"processed":"%v", "received":"%v", "requestID":"requestID2", "valid":true, "latency":60},
 				{"processed":"", "received":"", "requestID":"requestID0", "valid":false},
-				{"processed":"", "received":"", "requestID":"requestID1", "valid":false},
-				{"processed":"%v", "received":"%v", "requestID":"requestID2", "valid":true}
+				{"processed":"", "received":"", "requestID":"requestID1", "valid":false}
 			]
 		}`, testdata.ClusterName, processedTimestampTest, receivedTimestampTest)
 
@@ -1413,7 +1548,7 @@ func TestHTTPServer_GetRequestsForClusterPostVariant_OK1RequestNotFound(t *testi
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		expectedKey := fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName, "requestID1")
 		redisServer.ExpectHMGet(
@@ -1455,7 +1590,7 @@ func TestHTTPServer_GetRequestsForClusterPostVariant_NoRedis(t *testing.T) {
 	helpers.RunTestWithTimeout(t, func(tt testing.TB) {
 		defer helpers.CleanAfterGock(t)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, nil, nil)
 
 		requestIDList := []types.RequestID{"requestID1"}
 		reqBody, _ := json.Marshal(requestIDList)
@@ -1483,7 +1618,7 @@ func TestHTTPServer_GetRequestsForClusterPostVariant_RedisError500(t *testing.T)
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		expectedKey := fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName, "requestID1")
 		redisServer.ExpectHMGet(
@@ -1519,7 +1654,7 @@ func TestHTTPServer_GetRequestsForClusterPostVariant_BadRequestClusterID(t *test
 		// mock server not needed because the request will not get to part requiring Redis
 		redisClient, _ := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		requestIDList := []types.RequestID{"requestID1"}
 		reqBody, _ := json.Marshal(requestIDList)
@@ -1550,7 +1685,7 @@ func TestHTTPServer_GetRequestsForClusterPostVariant_BadAuthToken(t *testing.T)
 		// mock server not needed because the request will not get to part requiring Redis
 		redisClient, _ := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		requestIDList := []types.RequestID{"requestID1"}
 		reqBody, _ := json.Marshal(requestIDList)
@@ -1580,7 +1715,7 @@ func TestHTTPServer_GetRequestsForClusterPostVariant_NoBody(t *testing.T) {
 		// mock server not needed because the request will not get to part requiring Redis
 		redisClient, _ := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// invalid clusterID
 		iou_helpers.AssertAPIRequest(
@@ -1607,7 +1742,7 @@ func TestHTTPServer_GetRequestsForClusterPostVariant_BadBodyContent(t *testing.T
 		// mock server not needed because the request will not get to part requiring Redis
 		redisClient, _ := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// invalid clusterID
 		iou_helpers.AssertAPIRequest(
@@ -1635,7 +1770,7 @@ func TestHTTPServer_GetRequestsForClusterPostVariant_BadRequestID(t *testing.T)
 		// mock server not needed because the request will not get to part requiring Redis
 		redisClient, _ := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		requestIDList := []types.RequestID{"_"}
 		reqBody, _ := json.Marshal(requestIDList)
@@ -1668,7 +1803,7 @@ func TestHTTPServer_GetReportForRequest_OK_RequestNotFound(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// redis expects
 		expectedKey := fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName, "requestID1")
@@ -1704,7 +1839,7 @@ func TestHTTPServer_GetReportForRequest_OK_NoRuleHits(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// redis expects
 		expectedKey := fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName, "requestID1")
@@ -1773,7 +1908,7 @@ func TestHTTPServer_GetReportForRequest_OK_1RuleHit(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// redis expects
 		expectedRuleHits := fmt.Sprintf("%v|%v", testdata.Rule1ID, testdata.ErrorKey1)
@@ -1836,6 +1971,104 @@ func TestHTTPServer_GetReportForRequest_OK_1RuleHit(t *testing.T) {
 	}, testTimeout)
 }
 
+// TestHTTPServer_GetReportForRequest_Details checks that full rule content
+// is returned when template data are stored together with the rule hits and
+// that simplified rule hits are returned otherwise
+func TestHTTPServer_GetReportForRequest_Details(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(tt testing.TB) {
+		defer helpers.CleanAfterGock(t)
+
+		err := loadMockRuleContentDir(&testdata.RuleContentDirectory3Rules)
+		assert.Nil(t, err)
+
+		ruleID := types.RuleID(fmt.Sprintf("%v|%v", testdata.Rule1ID, testdata.ErrorKey1))
+		extraData := map[string]interface{}{"nodes": []interface{}{"node1"}}
+
+		memoryRedis := services.NewMemoryRedis()
+		memoryRedis.AddRequest(services.MemoryRequest{
+			OrgID:        testdata.OrgID,
+			ClusterID:    testdata.ClusterName,
+			RequestID:    "requestID1",
+			RuleHits:     []types.RuleID{ruleID},
+			TemplateData: map[types.RuleID]interface{}{ruleID: extraData},
+		}, 0)
+		memoryRedis.AddRequest(services.MemoryRequest{
+			OrgID:     testdata.OrgID,
+			ClusterID: testdata.ClusterName,
+			RequestID: "requestID2",
+			RuleHits:  []types.RuleID{ruleID},
+		}, 0)
+
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, memoryRedis, nil)
+
+		endpoint := helpers.DefaultServerConfigXRH.APIv2Prefix +
+			strings.NewReplacer("{cluster}", string(testdata.ClusterName), "{request_id}", "%s").
+				Replace(server.RuleHitsForRequestID) + "?" + server.DetailsParam + "=true"
+
+		expectAggregatorRuleDisables := func() {
+			helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint, &helpers.APIRequest{
+				Method:       http.MethodGet,
+				Endpoint:     ira_server.ListOfDisabledRulesSystemWide,
+				EndpointArgs: []interface{}{testdata.OrgID},
+			}, &helpers.APIResponse{
+				StatusCode: http.StatusOK,
+				Body:       ResponseNoRulesDisabledSystemWide,
+			})
+
+			reqBody, _ := json.Marshal([]types.ClusterName{testdata.ClusterName})
+			helpers.GockExpectAPIRequest(t, helpers.DefaultServicesConfig.AggregatorBaseEndpoint,
+				&helpers.APIRequest{
+					Method:       http.MethodPost,
+					Endpoint:     ira_server.ListOfDisabledRulesForClusters,
+					EndpointArgs: []interface{}{testdata.OrgID},
+					Body:         reqBody,
+				},
+				&helpers.APIResponse{
+					StatusCode: http.StatusOK,
+					Body:       `{"rules":[],"status":"ok"}`,
+				},
+			)
+		}
+
+		var detailedReport struct {
+			Details bool                            `json:"details"`
+			Report  []types.RuleWithContentResponse `json:"report"`
+		}
+		expectAggregatorRuleDisables()
+		assert.Equal(t, http.StatusOK, executeVisibilityRequest(t, testServer,
+			strings.Replace(endpoint, "%s", "requestID1", 1), &detailedReport,
+		))
+		assert.True(t, detailedReport.Details)
+		assert.Len(t, detailedReport.Report, 1)
+		assert.Equal(t, testdata.Rule1ID, detailedReport.Report[0].RuleID)
+		assert.Equal(t, testdata.RuleWithContent1.Reason, detailedReport.Report[0].Reason)
+		assert.Equal(t, testdata.RuleWithContent1.Resolution, detailedReport.Report[0].Resolution)
+		assert.Equal(t, testdata.RuleWithContent1.MoreInfo, detailedReport.Report[0].MoreInfo)
+		assert.NotEmpty(t, detailedReport.Report[0].Tags)
+		assert.Equal(t, extraData, detailedReport.Report[0].TemplateData)
+
+		var simplifiedReport struct {
+			Details bool                      `json:"details"`
+			Report  []types.SimplifiedRuleHit `json:"report"`
+		}
+		expectAggregatorRuleDisables()
+		assert.Equal(t, http.StatusOK, executeVisibilityRequest(t, testServer,
+			strings.Replace(endpoint, "%s", "requestID2", 1), &simplifiedReport,
+		))
+		assert.False(t, simplifiedReport.Details)
+		assert.Equal(t, []types.SimplifiedRuleHit{{
+			RuleFQDN:    string(testdata.Rule1ID),
+			ErrorKey:    string(testdata.ErrorKey1),
+			Description: testdata.RuleWithContent1.Generic,
+			TotalRisk:   testdata.RuleWithContent1.TotalRisk,
+		}}, simplifiedReport.Report)
+
+		assert.Equal(t, http.StatusBadRequest, executeVisibilityRequest(t, testServer,
+			strings.Replace(endpoint, "%s", "requestID1", 1)+"x", nil,
+		))
+	}, testTimeout)
+}
+
 func TestHTTPServer_GetReportForRequest_BadAuthToken(t *testing.T) {
 	helpers.RunTestWithTimeout(t, func(tt testing.TB) {
 		defer helpers.CleanAfterGock(t)
@@ -1843,7 +2076,7 @@ func TestHTTPServer_GetReportForRequest_BadAuthToken(t *testing.T) {
 		// mock server not needed because the request will not get to part requiring Redis
 		redisClient, _ := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// invalid clusterID
 		iou_helpers.AssertAPIRequest(
@@ -1869,7 +2102,7 @@ func TestHTTPServer_GetReportForRequest_BadRequestClusterID(t *testing.T) {
 		// mock server not needed because the request will not get to part requiring Redis
 		redisClient, _ := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// invalid clusterID
 		iou_helpers.AssertAPIRequest(
@@ -1896,7 +2129,7 @@ func TestHTTPServer_GetReportForRequest_BadRequestID(t *testing.T) {
 		// mock server not needed because the request will not get to part requiring Redis
 		redisClient, _ := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// invalid requestID in endpoint arg
 		iou_helpers.AssertAPIRequest(
@@ -1929,7 +2162,7 @@ func TestHTTPServer_GetReportForRequest_NoRuleContent(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// redis expects
 		expectedRuleHits := fmt.Sprintf("%v|%v", testdata.Rule1ID, testdata.ErrorKey1)
@@ -1999,7 +2232,7 @@ func TestHTTPServer_GetReportForRequest_OK_2RuleHits1Acked(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// redis expects
 		expectedRuleHits := fmt.Sprintf("%v|%v,%v|%v", testdata.Rule1ID, testdata.ErrorKey1, testdata.Rule2ID, testdata.ErrorKey2)
@@ -2073,7 +2306,7 @@ func TestHTTPServer_GetReportForRequest_OK_3RuleHits1Acked1Disabled(t *testing.T
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// redis expects
 
@@ -2165,7 +2398,7 @@ func TestHTTPServer_GetReportForRequest_AggregatorError(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// redis expects
 		expectedRuleHits := fmt.Sprintf("%v|%v", testdata.Rule1ID, testdata.ErrorKey1)
@@ -2210,7 +2443,7 @@ func TestHTTPServer_GetReportForRequest_AggregatorError_2ndCall(t *testing.T) {
 
 		redisClient, redisServer := helpers.GetMockRedis()
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, &redisClient, nil)
 
 		// redis expects
 		expectedRuleHits := fmt.Sprintf("%v|%v", testdata.Rule1ID, testdata.ErrorKey1)
@@ -2266,7 +2499,7 @@ func TestHTTPServer_GetReportForRequest_NoRedis(t *testing.T) {
 	helpers.RunTestWithTimeout(t, func(tt testing.TB) {
 		defer helpers.CleanAfterGock(t)
 
-		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, nil, nil, nil, nil)
+		testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, nil, nil)
 
 		requestIDList := []types.RequestID{"requestID1"}
 		reqBody, _ := json.Marshal(requestIDList)
@@ -2290,3 +2523,121 @@ func TestHTTPServer_GetReportForRequest_NoRedis(t *testing.T) {
 		)
 	}, testTimeout)
 }
+
+func getContentDiff(
+	t *testing.T, serverConfig *server.Configuration, from, to string,
+) (int, types.ContentDiff) {
+	testServer := helpers.CreateHTTPServer(serverConfig, nil, nil, nil, nil)
+
+	request := httptest.NewRequest(
+		http.MethodGet,
+		serverConfig.APIv2Prefix+server.ContentDiffV2+"?"+
+			server.FromVersionParam+"="+from+"&"+server.ToVersionParam+"="+to,
+		http.NoBody,
+	)
+	request.Header.Set("x-rh-identity", goodXRHAuthToken)
+
+	response := iou_helpers.ExecuteRequest(testServer, request).Result()
+	defer func() {
+		assert.NoError(t, response.Body.Close())
+	}()
+
+	var body struct {
+		Diff types.ContentDiff `json:"diff"`
+	}
+	if response.StatusCode == http.StatusOK {
+		helpers.FailOnError(t, json.NewDecoder(response.Body).Decode(&body))
+	}
+	return response.StatusCode, body.Diff
+}
+
+// TestHTTPServer_GetContentDiff checks that differences between two content
+// versions are returned and internal rules are visible to internal
+// organizations only
+func TestHTTPServer_GetContentDiff(t *testing.T) {
+	err := loadMockRuleContentDir(
+		createRuleContentDirectoryFromRuleContent([]ctypes.RuleContent{testdata.RuleContent1}),
+	)
+	assert.Nil(t, err)
+	from := content.GetContentVersion().Hash
+
+	err = loadMockRuleContentDir(
+		createRuleContentDirectoryFromRuleContent(
+			[]ctypes.RuleContent{RuleContentInternal1, testdata.RuleContent1, testdata.RuleContent2},
+		),
+	)
+	assert.Nil(t, err)
+	to := content.GetContentVersion().Hash
+
+	status, diff := getContentDiff(t, &serverConfigInternalOrganizations2, from, to)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, from, diff.From.Hash)
+	assert.Equal(t, to, diff.To.Hash)
+	assert.Equal(t, []ctypes.RuleID{testdata.Rule2ID}, diff.AddedRules)
+	assert.Equal(t, []ctypes.RuleID{testdata.Rule2CompositeID}, diff.AddedErrorKeys)
+	assert.Empty(t, diff.RemovedRules)
+	assert.Empty(t, diff.ChangedErrorKeys)
+
+	status, diff = getContentDiff(t, &serverConfigInternalOrganizations1, from, to)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []ctypes.RuleID{internalTestRuleModule, testdata.Rule2ID}, diff.AddedRules)
+
+	status, _ = getContentDiff(t, &helpers.DefaultServerConfigXRH, "unknown", to)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func searchContent(
+	t *testing.T, serverConfig *server.Configuration, query string,
+) (int, []types.ContentSearchResult) {
+	testServer := helpers.CreateHTTPServer(serverConfig, nil, nil, nil, nil)
+
+	request := httptest.NewRequest(
+		http.MethodGet,
+		serverConfig.APIv2Prefix+server.ContentSearchV2+"?"+query,
+		http.NoBody,
+	)
+	request.Header.Set("x-rh-identity", goodXRHAuthToken)
+
+	response := iou_helpers.ExecuteRequest(testServer, request).Result()
+	defer func() {
+		assert.NoError(t, response.Body.Close())
+	}()
+
+	var body struct {
+		Results []types.ContentSearchResult `json:"results"`
+	}
+	if response.StatusCode == http.StatusOK {
+		helpers.FailOnError(t, json.NewDecoder(response.Body).Decode(&body))
+	}
+	return response.StatusCode, body.Results
+}
+
+// TestHTTPServer_SearchContent checks that internal rules are found only for
+// internal organizations
+func TestHTTPServer_SearchContent(t *testing.T) {
+	err := loadMockRuleContentDir(
+		createRuleContentDirectoryFromRuleContent(
+			[]ctypes.RuleContent{RuleContentInternal1, testdata.RuleContent1},
+		),
+	)
+	assert.Nil(t, err)
+
+	status, results := searchContent(t, &serverConfigInternalOrganizations2, "q=description1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, results, 1)
+	assert.Equal(t, testdata.Rule1CompositeID, results[0].RuleID)
+	assert.Equal(t, []types.ContentSearchSnippet{
+		{Field: "description", Text: "<em>description1</em>"},
+	}, results[0].Snippets)
+
+	status, results = searchContent(t, &serverConfigInternalOrganizations1, "q=description1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, results, 2)
+
+	status, results = searchContent(t, &serverConfigInternalOrganizations1, "q=description1&limit=1&offset=1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, results, 1)
+
+	status, _ = searchContent(t, &serverConfigInternalOrganizations1, "q=")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
@@ -0,0 +1,201 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+// Liveness and readiness endpoints to be used by Kubernetes probes. Liveness
+// endpoint just reports that the process is running, readiness endpoint
+// checks the state of all dependencies and fails when any dependency listed
+// in ReadinessDependencies configuration option is not available.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/responses"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// HealthzEndpoint returns status ok while the process is running
+	HealthzEndpoint = "/healthz"
+	// ReadyzEndpoint returns state of dependencies and status ok when all
+	// required dependencies are available
+	ReadyzEndpoint = "/readyz"
+
+	// ContentDependency represents rule content loaded from content service
+	ContentDependency = "content"
+	// GroupsDependency represents groups polled from content service
+	GroupsDependency = "groups"
+	// RedisDependency represents Redis server
+	RedisDependency = "redis"
+	// AMSDependency represents AMS API client
+	AMSDependency = "ams"
+
+	dependencyStatusOK           = "ok"
+	dependencyStatusDown         = "down"
+	dependencyStatusShuttingDown = "shutting down"
+
+	// dependencyCheckTimeout is the maximum time spent by checking one
+	// dependency, so the readiness probe does not time out
+	dependencyCheckTimeout = 500 * time.Millisecond
+)
+
+// healthzEndpoint method handles requests to the liveness endpoint.
+func (server *HTTPServer) healthzEndpoint(writer http.ResponseWriter, _ *http.Request) {
+	err := responses.SendOK(writer, responses.BuildOkResponse())
+	if err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// readyzEndpoint method handles requests to the readiness endpoint. 503
+// Service Unavailable is returned when any required dependency is down or
+// when the server is shutting down.
+func (server *HTTPServer) readyzEndpoint(writer http.ResponseWriter, _ *http.Request) {
+	if server.isShuttingDown() {
+		response := types.ReadinessResponse{
+			Status:       dependencyStatusShuttingDown,
+			Dependencies: map[string]types.DependencyStatus{},
+		}
+		if err := responses.Send(http.StatusServiceUnavailable, writer, response); err != nil {
+			log.Error().Err(err).Msg(responseDataError)
+		}
+		return
+	}
+
+	checks := map[string]func() error{
+		ContentDependency: server.checkContentDependency,
+		GroupsDependency:  server.checkGroupsDependency,
+		RedisDependency:   server.checkRedisDependency,
+		AMSDependency:     server.checkAMSDependency,
+	}
+
+	required := make(map[string]bool)
+	for _, dependency := range server.Config.ReadinessDependencies {
+		required[dependency] = true
+		if _, found := checks[dependency]; !found {
+			checks[dependency] = func() error {
+				return errors.New("unknown dependency")
+			}
+		}
+	}
+
+	// all checks run concurrently and share the same deadline
+	ctx, cancel := context.WithTimeout(context.Background(), dependencyCheckTimeout)
+	defer cancel()
+
+	results := make(map[string]<-chan error, len(checks))
+	for dependency, check := range checks {
+		results[dependency] = startDependencyCheck(check)
+	}
+
+	response := types.ReadinessResponse{
+		Status:       dependencyStatusOK,
+		Dependencies: make(map[string]types.DependencyStatus, len(checks)),
+	}
+	for dependency, result := range results {
+		status := types.DependencyStatus{
+			Status:   dependencyStatusOK,
+			Required: required[dependency],
+		}
+		if err := waitForDependencyCheck(ctx, result); err != nil {
+			log.Warn().Err(err).Str("dependency", dependency).Msg("dependency is not ready")
+			status.Status = dependencyStatusDown
+			status.Error = err.Error()
+			if status.Required {
+				response.Status = dependencyStatusDown
+			}
+		}
+		response.Dependencies[dependency] = status
+	}
+
+	statusCode := http.StatusOK
+	if response.Status != dependencyStatusOK {
+		statusCode = http.StatusServiceUnavailable
+	}
+	if err := responses.Send(statusCode, writer, response); err != nil {
+		log.Error().Err(err).Msg(responseDataError)
+	}
+}
+
+// startDependencyCheck runs the check in a goroutine. Result of the check is
+// sent to returned channel.
+func startDependencyCheck(check func() error) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- check()
+	}()
+	return result
+}
+
+// waitForDependencyCheck waits for the result of the check until the context
+// is done
+func waitForDependencyCheck(ctx context.Context, result <-chan error) error {
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timed out after %v", dependencyCheckTimeout)
+	}
+}
+
+// checkContentDependency checks if the rule content has been loaded
+func (server *HTTPServer) checkContentDependency() error {
+	if !content.IsContentDirectoryReady() {
+		return errors.New("rule content has not been loaded yet")
+	}
+	return nil
+}
+
+// checkGroupsDependency checks if the last poll of groups succeeded
+func (server *HTTPServer) checkGroupsDependency() error {
+	if server.GroupsStore == nil {
+		return errors.New("groups are not polled")
+	}
+
+	status := server.GroupsStore.Status()
+	if status.Err != nil {
+		return fmt.Errorf(
+			"last poll of groups from content service failed at %s: %v",
+			status.LastError.UTC().Format(time.RFC3339), status.Err,
+		)
+	}
+	if status.LastSuccess.IsZero() {
+		return errors.New("groups have not been retrieved from content service yet")
+	}
+	return nil
+}
+
+// checkRedisDependency checks if Redis server responds
+func (server *HTTPServer) checkRedisDependency() error {
+	if server.redis == nil {
+		return errors.New("redis client is not initialized")
+	}
+	return server.redis.HealthCheck()
+}
+
+// checkAMSDependency checks if the AMS API client has been initialized
+func (server *HTTPServer) checkAMSDependency() error {
+	if server.amsClient == nil {
+		return errors.New("AMS client is not initialized")
+	}
+	return nil
+}
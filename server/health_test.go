@@ -0,0 +1,219 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-content-service/groups"
+	iou_helpers "github.com/RedHatInsights/insights-operator-utils/tests/helpers"
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// getReadiness sends request to the readiness endpoint (without any auth
+// header) and returns status code and decoded response
+func getReadiness(
+	t *testing.T, dependencies []string, redis services.RedisInterface, groupsStore *services.GroupsStore,
+) (int, types.ReadinessResponse) {
+	config := helpers.DefaultServerConfigXRH
+	config.ReadinessDependencies = dependencies
+	testServer := helpers.CreateHTTPServer(&config, nil, nil, redis, groupsStore)
+
+	request := httptest.NewRequest(http.MethodGet, server.ReadyzEndpoint, http.NoBody)
+	response := iou_helpers.ExecuteRequest(testServer, request).Result()
+	defer func() {
+		assert.NoError(t, response.Body.Close())
+	}()
+
+	var readiness types.ReadinessResponse
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&readiness))
+	return response.StatusCode, readiness
+}
+
+func loadContentForReadiness(t *testing.T) {
+	err := loadMockRuleContentDir(
+		createRuleContentDirectoryFromRuleContent([]ctypes.RuleContent{testdata.RuleContent1}),
+	)
+	assert.Nil(t, err)
+}
+
+// TestHTTPServer_Healthz checks that the liveness endpoint does not require
+// authentication
+func TestHTTPServer_Healthz(t *testing.T) {
+	testServer := helpers.CreateHTTPServer(&helpers.DefaultServerConfigXRH, nil, nil, nil, nil)
+
+	request := httptest.NewRequest(http.MethodGet, server.HealthzEndpoint, http.NoBody)
+	response := iou_helpers.ExecuteRequest(testServer, request)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"status": "ok"}`, response.Body.String())
+}
+
+func TestHTTPServer_Readyz_RequiredDependenciesReady(t *testing.T) {
+	loadContentForReadiness(t)
+
+	groupsStore := services.NewGroupsStore()
+	groupsStore.Update(make([]groups.Group, 1), nil)
+
+	statusCode, readiness := getReadiness(
+		t, []string{server.ContentDependency, server.GroupsDependency}, nil, groupsStore,
+	)
+
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "ok", readiness.Status)
+	assert.Equal(t, types.DependencyStatus{Status: "ok", Required: true}, readiness.Dependencies[server.ContentDependency])
+	assert.Equal(t, types.DependencyStatus{Status: "ok", Required: true}, readiness.Dependencies[server.GroupsDependency])
+
+	// optional dependencies are reported, but they don't affect the status
+	assert.Equal(t, "down", readiness.Dependencies[server.RedisDependency].Status)
+	assert.False(t, readiness.Dependencies[server.RedisDependency].Required)
+	assert.Equal(t, "down", readiness.Dependencies[server.AMSDependency].Status)
+	assert.False(t, readiness.Dependencies[server.AMSDependency].Required)
+}
+
+func TestHTTPServer_Readyz_GroupsPollFailed(t *testing.T) {
+	loadContentForReadiness(t)
+
+	groupsStore := services.NewGroupsStore()
+	groupsStore.Update(make([]groups.Group, 1), nil)
+	groupsStore.Update(nil, &server.ContentServiceUnavailableError{})
+
+	statusCode, readiness := getReadiness(
+		t, []string{server.ContentDependency, server.GroupsDependency}, nil, groupsStore,
+	)
+
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+	assert.Equal(t, "down", readiness.Status)
+	assert.Equal(t, "ok", readiness.Dependencies[server.ContentDependency].Status)
+	assert.Equal(t, "down", readiness.Dependencies[server.GroupsDependency].Status)
+	assert.NotEmpty(t, readiness.Dependencies[server.GroupsDependency].Error)
+}
+
+func TestHTTPServer_Readyz_GroupsNotRetrievedYet(t *testing.T) {
+	statusCode, readiness := getReadiness(
+		t, []string{server.GroupsDependency}, nil, services.NewGroupsStore(),
+	)
+
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+	assert.Equal(t, "down", readiness.Dependencies[server.GroupsDependency].Status)
+}
+
+func TestHTTPServer_Readyz_Redis(t *testing.T) {
+	t.Run("Redis responds", func(t *testing.T) {
+		client, mock := helpers.GetMockRedis()
+		mock.ExpectPing().SetVal("PONG")
+
+		statusCode, readiness := getReadiness(t, []string{server.RedisDependency}, &client, nil)
+
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, types.DependencyStatus{Status: "ok", Required: true}, readiness.Dependencies[server.RedisDependency])
+		helpers.RedisExpectationsMet(t, mock)
+	})
+
+	t.Run("Redis does not respond", func(t *testing.T) {
+		client, mock := helpers.GetMockRedis()
+		mock.ExpectPing().SetErr(http.ErrServerClosed)
+
+		statusCode, readiness := getReadiness(t, []string{server.RedisDependency}, &client, nil)
+
+		assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+		assert.Equal(t, "down", readiness.Dependencies[server.RedisDependency].Status)
+		helpers.RedisExpectationsMet(t, mock)
+	})
+
+	t.Run("Redis is not configured", func(t *testing.T) {
+		statusCode, readiness := getReadiness(t, []string{server.RedisDependency}, nil, nil)
+
+		assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+		assert.Equal(t, "down", readiness.Status)
+	})
+}
+
+func TestHTTPServer_Readyz_UnknownDependency(t *testing.T) {
+	statusCode, readiness := getReadiness(t, []string{"database"}, nil, nil)
+
+	assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+	assert.Equal(t, types.DependencyStatus{
+		Status:   "down",
+		Required: true,
+		Error:    "unknown dependency",
+	}, readiness.Dependencies["database"])
+}
+
+func TestHTTPServer_Readyz_ShuttingDown(t *testing.T) {
+	loadContentForReadiness(t)
+
+	config := helpers.DefaultServerConfigXRH
+	config.ReadinessDependencies = []string{server.ContentDependency}
+	testServer := helpers.CreateHTTPServer(&config, nil, nil, nil, nil)
+
+	request := httptest.NewRequest(http.MethodGet, server.ReadyzEndpoint, http.NoBody)
+	response := iou_helpers.ExecuteRequest(testServer, request)
+	assert.Equal(t, http.StatusOK, response.Code)
+
+	assert.NoError(t, testServer.Shutdown(context.Background()))
+
+	request = httptest.NewRequest(http.MethodGet, server.ReadyzEndpoint, http.NoBody)
+	response = iou_helpers.ExecuteRequest(testServer, request)
+	assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+	assert.JSONEq(t, `{"status": "shutting down", "dependencies": {}}`, response.Body.String())
+}
+
+func TestHTTPServer_Readyz_ShutdownDrainDelay(t *testing.T) {
+	loadContentForReadiness(t)
+
+	config := helpers.DefaultServerConfigXRH
+	config.ReadinessDependencies = []string{server.ContentDependency}
+	config.ShutdownDrainDelay = 500 * time.Millisecond
+	testServer := helpers.CreateHTTPServer(&config, nil, nil, nil, nil)
+
+	started := time.Now()
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- testServer.Shutdown(context.Background())
+	}()
+
+	// readiness probe fails while the server still serves requests
+	assert.Eventually(t, func() bool {
+		request := httptest.NewRequest(http.MethodGet, server.ReadyzEndpoint, http.NoBody)
+		return iou_helpers.ExecuteRequest(testServer, request).Code == http.StatusServiceUnavailable
+	}, time.Second, 5*time.Millisecond)
+	assert.Len(t, stopped, 0)
+
+	assert.NoError(t, <-stopped)
+	assert.GreaterOrEqual(t, time.Since(started), config.ShutdownDrainDelay)
+}
+
+func TestHTTPServer_ShutdownDrainDelayCanceled(t *testing.T) {
+	config := helpers.DefaultServerConfigXRH
+	config.ShutdownDrainDelay = time.Hour
+	testServer := helpers.CreateHTTPServer(&config, nil, nil, nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, testServer.Shutdown(ctx))
+}
@@ -77,7 +77,7 @@ func (server HTTPServer) postRatingToAggregator(
 	}
 	// #nosec G107
 	// nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
-	aggregatorResp, err := http.Post(aggregatorURL, JSONContentType, bytes.NewBuffer(body))
+	aggregatorResp, err := server.ServicesConfig.AggregatorClient().Post(aggregatorURL, JSONContentType, bytes.NewBuffer(body))
 	if err != nil {
 		handleServerError(writer, err)
 		return nil, false
@@ -124,7 +124,7 @@ func (server HTTPServer) getRatingForRecommendation(
 
 	// #nosec G107
 	// nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
-	aggregatorResp, err := http.Get(aggregatorURL)
+	aggregatorResp, err := server.ServicesConfig.AggregatorClient().Get(aggregatorURL)
 	if err != nil {
 		log.Error().Err(err).Msgf("problem getting URL %v from aggregator", aggregatorURL)
 		return
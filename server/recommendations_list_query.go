@@ -0,0 +1,291 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+// filtering, sorting and pagination of the recommendations list view
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RedHatInsights/insights-operator-utils/collections"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// TotalRiskParam parameter used to filter recommendations by comma
+	// separated list of total risk values
+	TotalRiskParam = "total_risk"
+	// ImpactParam parameter used to filter recommendations by comma separated
+	// list of impact values
+	ImpactParam = "impact"
+	// LikelihoodParam parameter used to filter recommendations by comma
+	// separated list of likelihood values
+	LikelihoodParam = "likelihood"
+	// TagsParam parameter used to filter recommendations having at least one
+	// of comma separated tags
+	TagsParam = "tags"
+	// DisabledParam parameter used to filter disabled or enabled recommendations
+	DisabledParam = "disabled"
+	// PublishedAfterParam parameter used to filter recommendations published
+	// at given time or later
+	PublishedAfterParam = "published_after"
+	// PublishedBeforeParam parameter used to filter recommendations published
+	// before given time
+	PublishedBeforeParam = "published_before"
+	// TextParam parameter used to search recommendations by (part of) their
+	// description or generic text
+	TextParam = "text"
+
+	dateLayout = "2006-01-02"
+)
+
+// recommendationsListQuery represents filters, sorting and pagination
+// requested for the recommendations list view
+type recommendationsListQuery struct {
+	totalRisks      map[uint8]bool
+	impacts         map[uint8]bool
+	likelihoods     map[uint8]bool
+	tags            []string
+	disabled        *bool
+	publishedAfter  time.Time
+	publishedBefore time.Time
+	text            string
+	sort            sortParam
+	pagination      paginationParams
+}
+
+// recommendationsListComparators contains functions comparing two
+// recommendations by given field
+var recommendationsListComparators = map[string]func(a, b *types.RecommendationListView) int{
+	"rule_id": func(a, b *types.RecommendationListView) int {
+		return strings.Compare(string(a.RuleID), string(b.RuleID))
+	},
+	"description": func(a, b *types.RecommendationListView) int {
+		return strings.Compare(strings.ToLower(a.Description), strings.ToLower(b.Description))
+	},
+	"generic": func(a, b *types.RecommendationListView) int {
+		return strings.Compare(strings.ToLower(a.Generic), strings.ToLower(b.Generic))
+	},
+	"publish_date": func(a, b *types.RecommendationListView) int {
+		return compareTimes(a.PublishDate, b.PublishDate)
+	},
+	"total_risk": func(a, b *types.RecommendationListView) int {
+		return compareInts(int(a.TotalRisk), int(b.TotalRisk))
+	},
+	"resolution_risk": func(a, b *types.RecommendationListView) int {
+		return compareInts(int(a.ResolutionRisk), int(b.ResolutionRisk))
+	},
+	"impact": func(a, b *types.RecommendationListView) int {
+		return compareInts(int(a.Impact), int(b.Impact))
+	},
+	"likelihood": func(a, b *types.RecommendationListView) int {
+		return compareInts(int(a.Likelihood), int(b.Likelihood))
+	},
+	"tags": func(a, b *types.RecommendationListView) int {
+		return strings.Compare(strings.Join(a.Tags, ","), strings.Join(b.Tags, ","))
+	},
+	"disabled": func(a, b *types.RecommendationListView) int {
+		return compareBools(a.Disabled, b.Disabled)
+	},
+	"impacted_clusters_count": func(a, b *types.RecommendationListView) int {
+		return compareInts(int(a.ImpactedClustersCnt), int(b.ImpactedClustersCnt))
+	},
+}
+
+// readRecommendationsListQuery reads filters, sorting and pagination
+// parameters of the recommendations list view from the request query
+func readRecommendationsListQuery(request *http.Request) (query recommendationsListQuery, err error) {
+	query.pagination, err = readPaginationParams(request)
+	if err != nil {
+		return
+	}
+
+	query.sort, err = readSortParam(request, func(field string) bool {
+		_, found := recommendationsListComparators[field]
+		return found
+	})
+	if err != nil {
+		return
+	}
+
+	if query.totalRisks, err = readRiskListParam(request, TotalRiskParam); err != nil {
+		return
+	}
+	if query.impacts, err = readRiskListParam(request, ImpactParam); err != nil {
+		return
+	}
+	if query.likelihoods, err = readRiskListParam(request, LikelihoodParam); err != nil {
+		return
+	}
+
+	if query.disabled, err = readOptionalBoolParam(DisabledParam, request); err != nil {
+		return
+	}
+
+	if query.publishedAfter, err = readTimeParam(request, PublishedAfterParam); err != nil {
+		return
+	}
+	if query.publishedBefore, err = readTimeParam(request, PublishedBeforeParam); err != nil {
+		return
+	}
+
+	if tags := request.URL.Query().Get(TagsParam); tags != "" {
+		query.tags = strings.Split(tags, ",")
+	}
+	query.text = strings.ToLower(request.URL.Query().Get(TextParam))
+	return
+}
+
+// readRiskListParam reads optional comma separated list of risk values
+// (total risk, impact or likelihood). Nil map is returned when the parameter
+// is not provided
+func readRiskListParam(request *http.Request, name string) (map[uint8]bool, error) {
+	value := request.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	risks := make(map[uint8]bool)
+	for _, item := range strings.Split(value, ",") {
+		risk, err := strconv.Atoi(item)
+		if err != nil || risk < minTotalRisk || risk > maxTotalRisk {
+			return nil, &RouterParsingError{
+				ParamName:  name,
+				ParamValue: value,
+				ErrString: fmt.Sprintf(
+					"comma separated list of integers between %d and %d is expected", minTotalRisk, maxTotalRisk,
+				),
+			}
+		}
+		risks[uint8(risk)] = true
+	}
+	return risks, nil
+}
+
+// readTimeParam reads optional time parameter in RFC3339 format or date only
+// (YYYY-MM-DD). Zero time is returned when the parameter is not provided
+func readTimeParam(request *http.Request, name string) (time.Time, error) {
+	value := request.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(dateLayout, value); err == nil {
+		return t, nil
+	}
+
+	return time.Time{}, &RouterParsingError{
+		ParamName:  name,
+		ParamValue: value,
+		ErrString:  "RFC3339 time or YYYY-MM-DD date is expected",
+	}
+}
+
+// matches checks if given recommendation satisfies all filters of the query
+func (query *recommendationsListQuery) matches(recommendation *types.RecommendationListView) bool {
+	if query.totalRisks != nil && !query.totalRisks[recommendation.TotalRisk] {
+		return false
+	}
+	if query.impacts != nil && !query.impacts[recommendation.Impact] {
+		return false
+	}
+	if query.likelihoods != nil && !query.likelihoods[recommendation.Likelihood] {
+		return false
+	}
+
+	if query.disabled != nil && recommendation.Disabled != *query.disabled {
+		return false
+	}
+
+	if !query.publishedAfter.IsZero() && recommendation.PublishDate.Before(query.publishedAfter) {
+		return false
+	}
+	if !query.publishedBefore.IsZero() && !recommendation.PublishDate.Before(query.publishedBefore) {
+		return false
+	}
+
+	if query.text != "" &&
+		!strings.Contains(strings.ToLower(recommendation.Description), query.text) &&
+		!strings.Contains(strings.ToLower(recommendation.Generic), query.text) {
+		return false
+	}
+
+	if len(query.tags) > 0 {
+		for _, tag := range query.tags {
+			if collections.StringInSlice(tag, recommendation.Tags) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// apply filters, sorts and paginates the recommendations list. Filtered list
+// is returned together with number of recommendations satisfying the filters.
+func (query *recommendationsListQuery) apply(recommendations []types.RecommendationListView) (
+	page []types.RecommendationListView, filteredCount int,
+) {
+	filtered := make([]types.RecommendationListView, 0, len(recommendations))
+	for i := range recommendations {
+		if query.matches(&recommendations[i]) {
+			filtered = append(filtered, recommendations[i])
+		}
+	}
+
+	if query.sort.field != "" {
+		compare := recommendationsListComparators[query.sort.field]
+		sort.SliceStable(filtered, func(i, j int) bool {
+			a, b := &filtered[i], &filtered[j]
+			if cmp := compare(a, b); cmp != 0 {
+				return (cmp < 0) != query.sort.descending
+			}
+			return a.RuleID < b.RuleID
+		})
+	}
+
+	start, end := query.pagination.bounds(len(filtered))
+	return filtered[start:end], len(filtered)
+}
+
+func compareTimes(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,162 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+var recommendationsListViewForQuery = []types.RecommendationListView{
+	{
+		RuleID:              "rule.a|KEY",
+		Description:         "Cluster is running out of disk space",
+		Generic:             "Disk usage is too high",
+		PublishDate:         time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC),
+		TotalRisk:           1,
+		Impact:              1,
+		Likelihood:          2,
+		Tags:                []string{"performance"},
+		ImpactedClustersCnt: 5,
+	},
+	{
+		RuleID:              "rule.b|KEY",
+		Description:         "Upgrade is blocked",
+		Generic:             "Some operators are degraded",
+		PublishDate:         time.Date(2022, 3, 15, 12, 0, 0, 0, time.UTC),
+		TotalRisk:           3,
+		Impact:              3,
+		Likelihood:          3,
+		Tags:                []string{"service_availability", "upgrade"},
+		Disabled:            true,
+		ImpactedClustersCnt: 1,
+	},
+	{
+		RuleID:              "rule.c|KEY",
+		Description:         "Insecure TLS configuration",
+		Generic:             "TLS 1.0 is enabled",
+		PublishDate:         time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC),
+		TotalRisk:           4,
+		Impact:              4,
+		Likelihood:          3,
+		Tags:                []string{"security"},
+		ImpactedClustersCnt: 3,
+	},
+}
+
+func filterRecommendationsList(t *testing.T, query string) ([]types.RecommendationListView, int) {
+	request := httptest.NewRequest(http.MethodGet, "/rule?"+query, http.NoBody)
+	page, filtered, err := server.FilterRecommendationsList(request, recommendationsListViewForQuery)
+	helpers.FailOnError(t, err)
+	return page, filtered
+}
+
+func ruleIDs(recommendations []types.RecommendationListView) []ctypes.RuleID {
+	ids := make([]ctypes.RuleID, len(recommendations))
+	for i := range recommendations {
+		ids[i] = recommendations[i].RuleID
+	}
+	return ids
+}
+
+func TestRecommendationsListNoQuery(t *testing.T) {
+	page, filtered := filterRecommendationsList(t, "")
+	assert.Equal(t, recommendationsListViewForQuery, page)
+	assert.Equal(t, 3, filtered)
+}
+
+func TestRecommendationsListFilters(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected []ctypes.RuleID
+	}{
+		{"total_risk=3,4", []ctypes.RuleID{"rule.b|KEY", "rule.c|KEY"}},
+		{"impact=1", []ctypes.RuleID{"rule.a|KEY"}},
+		{"likelihood=3", []ctypes.RuleID{"rule.b|KEY", "rule.c|KEY"}},
+		{"tags=security,performance", []ctypes.RuleID{"rule.a|KEY", "rule.c|KEY"}},
+		{"disabled=true", []ctypes.RuleID{"rule.b|KEY"}},
+		{"disabled=false", []ctypes.RuleID{"rule.a|KEY", "rule.c|KEY"}},
+		{"published_after=2022-03-15T12:00:00Z", []ctypes.RuleID{"rule.b|KEY", "rule.c|KEY"}},
+		{"published_before=2022-03-15T12:00:00Z", []ctypes.RuleID{"rule.a|KEY"}},
+		{"published_after=2022-01-01&published_before=2023-01-01", []ctypes.RuleID{"rule.b|KEY"}},
+		{"text=tls", []ctypes.RuleID{"rule.c|KEY"}},
+		{"text=DEGRADED", []ctypes.RuleID{"rule.b|KEY"}},
+		{"total_risk=4&disabled=true", []ctypes.RuleID{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			page, filtered := filterRecommendationsList(t, tc.query)
+			assert.Equal(t, tc.expected, ruleIDs(page))
+			assert.Equal(t, len(tc.expected), filtered)
+		})
+	}
+}
+
+func TestRecommendationsListSorting(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected []ctypes.RuleID
+	}{
+		{"sort=-total_risk", []ctypes.RuleID{"rule.c|KEY", "rule.b|KEY", "rule.a|KEY"}},
+		{"sort=impacted_clusters_count", []ctypes.RuleID{"rule.b|KEY", "rule.c|KEY", "rule.a|KEY"}},
+		{"sort=-publish_date", []ctypes.RuleID{"rule.c|KEY", "rule.b|KEY", "rule.a|KEY"}},
+		{"sort=description", []ctypes.RuleID{"rule.a|KEY", "rule.c|KEY", "rule.b|KEY"}},
+		{"sort=-likelihood", []ctypes.RuleID{"rule.b|KEY", "rule.c|KEY", "rule.a|KEY"}},
+		{"sort=-disabled", []ctypes.RuleID{"rule.b|KEY", "rule.a|KEY", "rule.c|KEY"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			page, _ := filterRecommendationsList(t, tc.query)
+			assert.Equal(t, tc.expected, ruleIDs(page))
+		})
+	}
+}
+
+func TestRecommendationsListPagination(t *testing.T) {
+	page, filtered := filterRecommendationsList(t, "sort=-total_risk&limit=2&offset=1")
+	assert.Equal(t, []ctypes.RuleID{"rule.b|KEY", "rule.a|KEY"}, ruleIDs(page))
+	assert.Equal(t, 3, filtered)
+
+	page, _ = filterRecommendationsList(t, fmt.Sprintf("sort=-total_risk&offset=1&limit=%d", math.MaxInt))
+	assert.Equal(t, []ctypes.RuleID{"rule.b|KEY", "rule.a|KEY"}, ruleIDs(page))
+}
+
+func TestRecommendationsListInvalidQuery(t *testing.T) {
+	for _, query := range []string{
+		"total_risk=5", "impact=x", "likelihood=1,,2", "disabled=maybe",
+		"published_after=yesterday", "sort=reason", "limit=-5",
+	} {
+		t.Run(query, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/rule?"+query, http.NoBody)
+			_, _, err := server.FilterRecommendationsList(request, recommendationsListViewForQuery)
+
+			var parsingError *server.RouterParsingError
+			assert.ErrorAs(t, err, &parsingError)
+		})
+	}
+}
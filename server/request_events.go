@@ -0,0 +1,325 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+// Server-sent events stream with progress of processing of one on-demand data
+// gathering request. The stream is updated whenever Redis notifies about a
+// change of the request and periodically, so it works even when the
+// notifications are not available.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	httputils "github.com/RedHatInsights/insights-operator-utils/http"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// RequestEventReceived is the event sent when the request is received
+	RequestEventReceived = "received"
+	// RequestEventProcessed is the event sent when the request is processed
+	RequestEventProcessed = "processed"
+	// RequestEventReportReady is the event sent when the report of the
+	// request is ready, the stream ends then
+	RequestEventReportReady = "report-ready"
+	// RequestEventTimeout is the event sent when the report is not ready
+	// in the maximum wait time, the stream ends then
+	RequestEventTimeout = "timeout"
+
+	// defaultEventsMaxWait is used when maximum wait time is not configured
+	defaultEventsMaxWait = 25 * time.Second
+	// defaultEventsHeartbeatInterval is used when heartbeat interval is not
+	// configured
+	defaultEventsHeartbeatInterval = 10 * time.Second
+	// defaultEventsPollInterval is used when poll interval is not configured
+	defaultEventsPollInterval = 2 * time.Second
+	// defaultEventsMaxStreams is used when maximum number of concurrent
+	// streams is not configured
+	defaultEventsMaxStreams = 100
+
+	heartbeatComment = ": heartbeat\n\n"
+)
+
+// contextKeyFlusher is the key of the flusher of the original response writer
+// in request context
+const contextKeyFlusher = ctypes.ContextKey("flusher")
+
+// requestEvents are events of request progress in the order they are sent
+var requestEvents = []string{RequestEventReceived, RequestEventProcessed, RequestEventReportReady}
+
+// requestEventData is the data of events sent in the stream
+type requestEventData struct {
+	Cluster   types.ClusterName `json:"cluster"`
+	RequestID types.RequestID   `json:"requestID"`
+	Received  string            `json:"received,omitempty"`
+	Processed string            `json:"processed,omitempty"`
+}
+
+// requestEventStream writes events of one request to the client
+type requestEventStream struct {
+	writer    http.ResponseWriter
+	flusher   http.Flusher
+	clusterID types.ClusterName
+	requestID types.RequestID
+	// sent is the number of events from requestEvents sent already
+	sent int
+}
+
+// update sends events of all progress stages the request reached since the
+// last update. True is returned when the report is ready, so there are no
+// more events to send.
+func (s *requestEventStream) update(progress *types.RequestProgress) (bool, error) {
+	reached := 0
+	switch {
+	case progress.ReportReady:
+		reached = 3
+	case progress.Processed:
+		reached = 2
+	case progress.Received:
+		reached = 1
+	}
+
+	for ; s.sent < reached; s.sent++ {
+		if err := s.send(requestEvents[s.sent], progress); err != nil {
+			return false, err
+		}
+	}
+	return s.sent == len(requestEvents), nil
+}
+
+// send writes one event to the client
+func (s *requestEventStream) send(event string, progress *types.RequestProgress) error {
+	data, err := json.Marshal(requestEventData{
+		Cluster:   s.clusterID,
+		RequestID: s.requestID,
+		Received:  progress.ReceivedAt,
+		Processed: progress.ProcessedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(s.writer, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// heartbeat writes comment keeping the connection open
+func (s *requestEventStream) heartbeat() error {
+	if _, err := fmt.Fprint(s.writer, heartbeatComment); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// keepFlusher middleware stores the flusher of the response writer in request
+// context, because the request logging middleware wraps the writer into one
+// that can't be flushed
+func keepFlusher(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if flusher, ok := writer.(http.Flusher); ok {
+			request = request.WithContext(context.WithValue(request.Context(), contextKeyFlusher, flusher))
+		}
+		next.ServeHTTP(writer, request)
+	})
+}
+
+// eventsMaxStreams returns configured maximum number of concurrent streams of
+// events, or its default
+func eventsMaxStreams(config Configuration) int {
+	if config.EventsMaxStreams <= 0 {
+		return defaultEventsMaxStreams
+	}
+	return config.EventsMaxStreams
+}
+
+// eventsIntervals are maximum wait time, heartbeat interval and poll
+// interval of the events stream
+type eventsIntervals struct {
+	maxWait           time.Duration
+	heartbeatInterval time.Duration
+	pollInterval      time.Duration
+}
+
+// newEventsIntervals returns configured intervals of the events stream, or
+// their defaults. Maximum wait time is shortened so the stream ends before
+// given write timeout of the server.
+func newEventsIntervals(config Configuration, writeTimeout time.Duration) eventsIntervals {
+	intervals := eventsIntervals{
+		maxWait:           config.EventsMaxWait,
+		heartbeatInterval: config.EventsHeartbeatInterval,
+		pollInterval:      config.EventsPollInterval,
+	}
+
+	if intervals.maxWait <= 0 {
+		intervals.maxWait = defaultEventsMaxWait
+	}
+	if writeTimeout > 0 && intervals.maxWait >= writeTimeout {
+		intervals.maxWait = writeTimeout - time.Second
+		log.Warn().
+			Dur("events_max_wait", config.EventsMaxWait).
+			Dur("write_timeout", writeTimeout).
+			Msgf("maximum wait time of events shortened to %v", intervals.maxWait)
+	}
+
+	if intervals.heartbeatInterval <= 0 {
+		intervals.heartbeatInterval = defaultEventsHeartbeatInterval
+	}
+	if intervals.pollInterval <= 0 {
+		intervals.pollInterval = defaultEventsPollInterval
+	}
+	return intervals
+}
+
+// getEventsForRequest method implements endpoint that streams server-sent
+// events about progress of processing of given request ID. Events are sent
+// when the request is received, processed and when its report is ready. The
+// stream ends when the report is ready or after the maximum wait time.
+func (server *HTTPServer) getEventsForRequest(writer http.ResponseWriter, request *http.Request) {
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		log.Error().Msg(authTokenFormatError)
+		handleServerError(writer, err)
+		return
+	}
+
+	clusterID, successful := httputils.ReadClusterName(writer, request)
+	if !successful {
+		// error handled by function
+		return
+	}
+
+	requestID, err := readRequestID(writer, request)
+	if err != nil {
+		// error handled by function
+		return
+	}
+
+	// make sure we don't access server.redis when it's nil
+	if !server.checkRedisClientReadiness(writer) {
+		// error has been handled already
+		return
+	}
+
+	flusher, ok := request.Context().Value(contextKeyFlusher).(http.Flusher)
+	if !ok {
+		handleServerError(writer, errors.New("streaming of events is not supported"))
+		return
+	}
+
+	// streams stay open for long, so their number is limited
+	select {
+	case server.eventStreams <- struct{}{}:
+		defer func() { <-server.eventStreams }()
+	default:
+		handleServerError(writer, &EventStreamsLimitError{})
+		return
+	}
+
+	// subscribe before the progress is read, so no change is missed
+	var notifications <-chan struct{}
+	subscription, err := server.redis.SubscribeRequestEvents(orgID, clusterID, requestID)
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to subscribe to request events, polling is used")
+	} else {
+		defer func() {
+			if err := subscription.Close(); err != nil {
+				log.Error().Err(err).Msg("unable to close subscription of request events")
+			}
+		}()
+		notifications = subscription.C
+	}
+
+	// Redis errors are reported by status code until the stream starts
+	progress, err := server.redis.GetRequestProgress(orgID, clusterID, requestID)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	header := writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	writer.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	timeout := time.NewTimer(server.eventsIntervals.maxWait)
+	defer timeout.Stop()
+	heartbeat := time.NewTicker(server.eventsIntervals.heartbeatInterval)
+	defer heartbeat.Stop()
+	poll := time.NewTicker(server.eventsIntervals.pollInterval)
+	defer poll.Stop()
+
+	stream := requestEventStream{
+		writer:    writer,
+		flusher:   flusher,
+		clusterID: clusterID,
+		requestID: requestID,
+	}
+
+	for {
+		done, err := stream.update(&progress)
+		if err != nil {
+			log.Error().Err(err).Msg("unable to send request event")
+			return
+		}
+		if done || server.isShuttingDown() {
+			return
+		}
+
+		select {
+		case <-request.Context().Done():
+			return
+		case <-timeout.C:
+			if err := stream.send(RequestEventTimeout, &progress); err != nil {
+				log.Error().Err(err).Msg("unable to send request event")
+			}
+			return
+		case <-heartbeat.C:
+			if err := stream.heartbeat(); err != nil {
+				log.Error().Err(err).Msg("unable to send heartbeat")
+				return
+			}
+			continue
+		case _, ok := <-notifications:
+			if !ok {
+				log.Warn().Msg("subscription of request events ended, polling is used")
+				notifications = nil
+			}
+		case <-poll.C:
+		}
+
+		current, err := server.redis.GetRequestProgress(orgID, clusterID, requestID)
+		if err != nil {
+			// keep the previous progress, it's read again by next poll
+			log.Error().Err(err).Msg("unable to read progress of request")
+			continue
+		}
+		progress = current
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// startEventsServer starts test server streaming request events
+func startEventsServer(t *testing.T, config *server.Configuration, redis services.RedisInterface) *httptest.Server {
+	testServer := httptest.NewServer(helpers.CreateHTTPServer(config, nil, nil, redis, nil).Initialize())
+	t.Cleanup(testServer.Close)
+	return testServer
+}
+
+// requestEvents opens events stream of requestID1 of the test cluster on
+// given test server
+func requestEvents(t *testing.T, testServer *httptest.Server, config *server.Configuration) (
+	*http.Response, *bufio.Reader,
+) {
+	endpoint := testServer.URL + config.APIv2Prefix + strings.NewReplacer(
+		"{cluster}", string(testdata.ClusterName), "{request_id}", "requestID1",
+	).Replace(server.EventsForRequestID)
+
+	request, err := http.NewRequest(http.MethodGet, endpoint, http.NoBody)
+	helpers.FailOnError(t, err)
+	request.Header.Set("x-rh-identity", goodXRHAuthToken)
+
+	response, err := http.DefaultClient.Do(request)
+	helpers.FailOnError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, response.Body.Close())
+	})
+
+	return response, bufio.NewReader(response.Body)
+}
+
+// openRequestEvents opens events stream of requestID1 of the test cluster
+func openRequestEvents(t *testing.T, config *server.Configuration, redis services.RedisInterface) (
+	*http.Response, *bufio.Reader,
+) {
+	return requestEvents(t, startEventsServer(t, config, redis), config)
+}
+
+// readEvent reads next event or comment from the stream
+func readEvent(t *testing.T, stream *bufio.Reader) string {
+	var lines []string
+	for {
+		line, err := stream.ReadString('\n')
+		helpers.FailOnError(t, err)
+		if line == "\n" {
+			return strings.Join(lines, "")
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestGetEventsForRequest(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(tt testing.TB) {
+		memoryRedis := services.NewMemoryRedis()
+		memoryRedis.Set(fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName, "requestID1"), nil, 0)
+
+		config := helpers.DefaultServerConfigXRH
+		// notifications are used instead of polling
+		config.EventsPollInterval = time.Hour
+		response, stream := openRequestEvents(t, &config, memoryRedis)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+		assert.Equal(t, fmt.Sprintf(
+			"event: received\ndata: {\"cluster\":\"%v\",\"requestID\":\"requestID1\"}\n", testdata.ClusterName,
+		), readEvent(t, stream))
+
+		received := time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)
+		memoryRedis.AddRequest(services.MemoryRequest{
+			OrgID:     testdata.OrgID,
+			ClusterID: testdata.ClusterName,
+			RequestID: "requestID1",
+			Received:  received,
+			Processed: received.Add(time.Minute),
+		}, 0)
+
+		for _, event := range []string{server.RequestEventProcessed, server.RequestEventReportReady} {
+			assert.Equal(t, fmt.Sprintf(
+				"event: %v\ndata: {\"cluster\":\"%v\",\"requestID\":\"requestID1\","+
+					"\"received\":\"2023-01-02T15:04:05Z\",\"processed\":\"2023-01-02T15:05:05Z\"}\n",
+				event, testdata.ClusterName,
+			), readEvent(t, stream))
+		}
+
+		// the stream ends when the report is ready
+		_, err := stream.ReadByte()
+		assert.Equal(t, io.EOF, err)
+	}, testTimeout)
+}
+
+func TestGetEventsForRequestTimeout(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(tt testing.TB) {
+		config := helpers.DefaultServerConfigXRH
+		config.EventsMaxWait = 500 * time.Millisecond
+		config.EventsHeartbeatInterval = 100 * time.Millisecond
+		_, stream := openRequestEvents(t, &config, services.NewMemoryRedis())
+
+		assert.Equal(t, ": heartbeat\n", readEvent(t, stream))
+
+		for {
+			event := readEvent(t, stream)
+			if event != ": heartbeat\n" {
+				assert.Equal(t, fmt.Sprintf(
+					"event: timeout\ndata: {\"cluster\":\"%v\",\"requestID\":\"requestID1\"}\n", testdata.ClusterName,
+				), event)
+				break
+			}
+		}
+
+		_, err := stream.ReadByte()
+		assert.Equal(t, io.EOF, err)
+	}, testTimeout)
+}
+
+func TestGetEventsForRequestNoRedis(t *testing.T) {
+	response, _ := openRequestEvents(t, &helpers.DefaultServerConfigXRH, nil)
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+}
+
+func TestGetEventsForRequestStreamsLimit(t *testing.T) {
+	helpers.RunTestWithTimeout(t, func(tt testing.TB) {
+		config := helpers.DefaultServerConfigXRH
+		config.EventsMaxStreams = 1
+		config.EventsHeartbeatInterval = 100 * time.Millisecond
+		testServer := startEventsServer(t, &config, services.NewMemoryRedis())
+
+		response, stream := requestEvents(t, testServer, &config)
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+		assert.Equal(t, ": heartbeat\n", readEvent(t, stream))
+
+		response, _ = requestEvents(t, testServer, &config)
+		assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	}, testTimeout)
+}
+
+func TestEventsIntervals(t *testing.T) {
+	// defaults are used when the intervals are not configured
+	config := helpers.DefaultServerConfigXRH
+	maxWait, heartbeatInterval, pollInterval := server.EventsIntervals(
+		server.New(config, helpers.DefaultServicesConfig, nil, nil, nil),
+	)
+	assert.Equal(t, 25*time.Second, maxWait)
+	assert.Equal(t, 10*time.Second, heartbeatInterval)
+	assert.Equal(t, 2*time.Second, pollInterval)
+
+	// maximum wait time is shortened below the write timeout of the server
+	config.EventsMaxWait = time.Minute
+	config.EventsHeartbeatInterval = 5 * time.Second
+	config.EventsPollInterval = time.Second
+	maxWait, heartbeatInterval, pollInterval = server.EventsIntervals(
+		server.New(config, helpers.DefaultServicesConfig, nil, nil, nil),
+	)
+	assert.Equal(t, 29*time.Second, maxWait)
+	assert.Equal(t, 5*time.Second, heartbeatInterval)
+	assert.Equal(t, time.Second, pollInterval)
+}
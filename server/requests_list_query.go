@@ -0,0 +1,163 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+// filtering, sorting and limiting of the list of requests for a cluster
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// SinceParam parameter used to filter requests received at given time or
+	// later
+	SinceParam = "since"
+	// UntilParam parameter used to filter requests received before given time
+	UntilParam = "until"
+	// OrderParam parameter used to select order of requests by received
+	// timestamp, "asc" or "desc" (default)
+	OrderParam = "order"
+
+	orderAscending  = "asc"
+	orderDescending = "desc"
+)
+
+// requestsListQuery represents filters, order and limit requested for the
+// list of requests for a cluster
+type requestsListQuery struct {
+	since     time.Time
+	until     time.Time
+	limit     int
+	ascending bool
+}
+
+// requestsListItem is request status with parsed received timestamp
+type requestsListItem struct {
+	status   types.RequestStatus
+	received time.Time
+	valid    bool
+}
+
+// readRequestsListQuery reads filters, order and limit of the list of
+// requests from the request query
+func readRequestsListQuery(request *http.Request) (query requestsListQuery, err error) {
+	if query.since, err = readTimeParam(request, SinceParam); err != nil {
+		return
+	}
+	if query.until, err = readTimeParam(request, UntilParam); err != nil {
+		return
+	}
+	if query.limit, err = readNonNegativeIntParam(LimitParam, request); err != nil {
+		return
+	}
+
+	switch order := request.URL.Query().Get(OrderParam); order {
+	case "", orderDescending:
+	case orderAscending:
+		query.ascending = true
+	default:
+		err = &RouterParsingError{
+			ParamName:  OrderParam,
+			ParamValue: order,
+			ErrString:  "asc or desc is expected",
+		}
+	}
+	return
+}
+
+// matches checks if request received at given time satisfies the time
+// filters. Requests without valid received timestamp satisfy no time filter.
+func (query *requestsListQuery) matches(item *requestsListItem) bool {
+	if query.since.IsZero() && query.until.IsZero() {
+		return true
+	}
+	if !item.valid {
+		return false
+	}
+	if !query.since.IsZero() && item.received.Before(query.since) {
+		return false
+	}
+	if !query.until.IsZero() && !item.received.Before(query.until) {
+		return false
+	}
+	return true
+}
+
+// apply filters, sorts and limits the list of requests and fills in their
+// processing latency. Requests are sorted by the received timestamp, requests
+// without valid timestamp are placed at the end of the list.
+func (query *requestsListQuery) apply(requests []types.RequestStatus) []types.RequestStatus {
+	items := make([]requestsListItem, 0, len(requests))
+	for _, request := range requests {
+		item := requestsListItem{status: request}
+		item.received, item.valid = parseRequestTimestamp(request.Received)
+		if query.matches(&item) {
+			items = append(items, item)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := &items[i], &items[j]
+		if a.valid != b.valid {
+			return a.valid
+		}
+		if cmp := compareTimes(a.received, b.received); cmp != 0 {
+			return (cmp < 0) == query.ascending
+		}
+		return a.status.RequestID < b.status.RequestID
+	})
+
+	if query.limit > 0 && query.limit < len(items) {
+		items = items[:query.limit]
+	}
+
+	filtered := make([]types.RequestStatus, len(items))
+	for i := range items {
+		filtered[i] = items[i].status
+		filtered[i].Latency = requestLatency(&items[i])
+	}
+	return filtered
+}
+
+// parseRequestTimestamp parses received or processed timestamp stored in
+// Redis. False is returned when the timestamp is missing or not valid.
+func parseRequestTimestamp(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// requestLatency returns the time between receiving and processing the
+// request in seconds. Nil is returned when any timestamp is not valid.
+func requestLatency(item *requestsListItem) *float64 {
+	if !item.valid {
+		return nil
+	}
+	processed, valid := parseRequestTimestamp(item.status.Processed)
+	if !valid {
+		return nil
+	}
+	latency := processed.Sub(item.received).Seconds()
+	return &latency
+}
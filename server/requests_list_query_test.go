@@ -0,0 +1,119 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+var requestsListForQuery = []types.RequestStatus{
+	{
+		RequestID: "request-b",
+		Valid:     true,
+		Received:  "2023-01-02T10:00:00Z",
+		Processed: "2023-01-02T10:00:30Z",
+	},
+	{
+		RequestID: "request-x",
+		Valid:     false,
+	},
+	{
+		RequestID: "request-a",
+		Valid:     true,
+		Received:  "2023-01-01T10:00:00Z",
+		Processed: "2023-01-01T10:02:00Z",
+	},
+	{
+		RequestID: "request-c",
+		Valid:     true,
+		Received:  "2023-01-03T10:00:00Z",
+		Processed: "not processed yet",
+	},
+}
+
+func filterRequestsList(t *testing.T, query string) []types.RequestStatus {
+	request := httptest.NewRequest(http.MethodGet, "/requests?"+query, http.NoBody)
+	requests, err := server.FilterRequestsList(request, requestsListForQuery)
+	helpers.FailOnError(t, err)
+	return requests
+}
+
+func requestStatusIDs(requests []types.RequestStatus) []string {
+	ids := make([]string, len(requests))
+	for i := range requests {
+		ids[i] = requests[i].RequestID
+	}
+	return ids
+}
+
+func TestRequestsListQuery(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		{"", []string{"request-c", "request-b", "request-a", "request-x"}},
+		{"order=desc", []string{"request-c", "request-b", "request-a", "request-x"}},
+		{"order=asc", []string{"request-a", "request-b", "request-c", "request-x"}},
+		{"since=2023-01-02", []string{"request-c", "request-b"}},
+		{"since=2023-01-02T10:00:01Z", []string{"request-c"}},
+		{"until=2023-01-02T10:00:00Z", []string{"request-a"}},
+		{"since=2023-01-01&until=2023-01-03&order=asc", []string{"request-a", "request-b"}},
+		{"limit=1", []string{"request-c"}},
+		{"limit=2&order=asc", []string{"request-a", "request-b"}},
+		{"limit=0", []string{"request-c", "request-b", "request-a", "request-x"}},
+		{"since=2023-02-01", []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			assert.Equal(t, tc.expected, requestStatusIDs(filterRequestsList(t, tc.query)))
+		})
+	}
+}
+
+func TestRequestsListLatency(t *testing.T) {
+	requests := filterRequestsList(t, "order=asc")
+	latencies := make(map[string]*float64)
+	for i := range requests {
+		latencies[requests[i].RequestID] = requests[i].Latency
+	}
+
+	assert.Equal(t, 120.0, *latencies["request-a"])
+	assert.Equal(t, 30.0, *latencies["request-b"])
+	assert.Nil(t, latencies["request-c"])
+	assert.Nil(t, latencies["request-x"])
+}
+
+func TestRequestsListInvalidQuery(t *testing.T) {
+	for _, query := range []string{
+		"limit=x", "limit=-1", "order=newest", "since=yesterday", "until=2023-13-01",
+	} {
+		t.Run(query, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/requests?"+query, http.NoBody)
+			_, err := server.FilterRequestsList(request, requestsListForQuery)
+
+			var parsingError *server.RouterParsingError
+			assert.ErrorAs(t, err, &parsingError)
+		})
+	}
+}
@@ -27,6 +27,7 @@ import (
 	ctypes "github.com/RedHatInsights/insights-results-types"
 	"github.com/rs/zerolog/log"
 
+	"github.com/RedHatInsights/insights-results-smart-proxy/content"
 	"github.com/RedHatInsights/insights-results-smart-proxy/types"
 )
 
@@ -41,8 +42,57 @@ const (
 	RuleIDParamName = "rule_id"
 	// RequestIDParam parameter name in the URL for request IDs
 	RequestIDParam = "request_id"
+	// LimitParam parameter used to limit number of items returned in the response
+	LimitParam = "limit"
+	// OffsetParam parameter used to skip given number of items in the response
+	OffsetParam = "offset"
+	// SortParam parameter used to select the field the response is sorted by.
+	// Descending order is selected by "-" prefix, for example sort=-total_hit_count
+	SortParam = "sort"
+	// FromVersionParam parameter used to select the older version of the content
+	FromVersionParam = "from"
+	// ToVersionParam parameter used to select the newer version of the content
+	ToVersionParam = "to"
+	// SearchQueryParam parameter used to pass full-text search query
+	SearchQueryParam = "q"
+	// RenderParam parameter used to request rule content templates filled by
+	// extra_data, "true" or "markdown" for markdown, "text" for plain text
+	RenderParam = "render"
+	// DetailsParam parameter used to request full rule content in the report
+	// of on-demand data gathering request
+	DetailsParam = "details"
 )
 
+// paginationParams represents requested page of items. Zero limit means
+// that the number of items is not limited.
+type paginationParams struct {
+	limit  int
+	offset int
+}
+
+// bounds returns start and end index of the requested page in list of given
+// length
+func (p paginationParams) bounds(length int) (start, end int) {
+	start = p.offset
+	if start > length {
+		start = length
+	}
+
+	// compared without adding to start, so huge limit can't overflow
+	end = length
+	if p.limit > 0 && p.limit < length-start {
+		end = start + p.limit
+	}
+	return start, end
+}
+
+// sortParam represents requested sorting of items. Empty field means that
+// the items are not sorted.
+type sortParam struct {
+	field      string
+	descending bool
+}
+
 func readRuleIDWithErrorKey(writer http.ResponseWriter, request *http.Request) (ctypes.RuleID, ctypes.ErrorKey, error) {
 	ruleIDWithErrorKey, err := httputils.GetRouterParam(request, RuleIDParamName)
 	if err != nil {
@@ -144,12 +194,132 @@ func readQueryBoolParam(name string, defaultValue bool, request *http.Request) (
 	return strconv.ParseBool(value)
 }
 
+// readOptionalBoolParam returns the value of given boolean parameter in the
+// query. Nil is returned if the parameter is not provided.
+func readOptionalBoolParam(name string, request *http.Request) (*bool, error) {
+	value := request.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, &RouterParsingError{
+			ParamName:  name,
+			ParamValue: value,
+			ErrString:  "unparsable boolean value",
+		}
+	}
+	return &parsed, nil
+}
+
+// readNonNegativeIntParam returns the value of given integer parameter in the
+// query. Zero is returned if the parameter is not provided.
+func readNonNegativeIntParam(name string, request *http.Request) (int, error) {
+	value := request.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return 0, &RouterParsingError{
+			ParamName:  name,
+			ParamValue: value,
+			ErrString:  "non-negative integer is expected",
+		}
+	}
+	return number, nil
+}
+
+// readPaginationParams returns the values of "limit" and "offset" parameters
+// in query if available
+func readPaginationParams(request *http.Request) (params paginationParams, err error) {
+	params.limit, err = readNonNegativeIntParam(LimitParam, request)
+	if err != nil {
+		return
+	}
+
+	params.offset, err = readNonNegativeIntParam(OffsetParam, request)
+	return
+}
+
+// readSortParam returns the value of the "sort" parameter in query if
+// available. The field is checked by given validation function.
+func readSortParam(request *http.Request, isValidField func(string) bool) (sortParam, error) {
+	value := request.URL.Query().Get(SortParam)
+	if value == "" {
+		return sortParam{}, nil
+	}
+
+	param := sortParam{field: value}
+	if strings.HasPrefix(value, "-") {
+		param.field = strings.TrimPrefix(value, "-")
+		param.descending = true
+	}
+
+	if !isValidField(param.field) {
+		return sortParam{}, &RouterParsingError{
+			ParamName:  SortParam,
+			ParamValue: value,
+			ErrString:  "unsupported sort field",
+		}
+	}
+	return param, nil
+}
+
 // readGetDisabledParam returns the value of the "get_disabled" parameter in query
 // if available
 func readGetDisabledParam(request *http.Request) (bool, error) {
 	return readQueryBoolParam(GetDisabledParam, false, request)
 }
 
+// renderParams represents requested rendering of rule content templates
+type renderParams struct {
+	render    bool
+	plainText bool
+}
+
+// readRenderParam returns the value of the "render" parameter in query if
+// available
+func readRenderParam(request *http.Request) (renderParams, error) {
+	value := request.URL.Query().Get(RenderParam)
+	switch strings.ToLower(value) {
+	case "", "false":
+		return renderParams{}, nil
+	case "true", content.RenderFormatMarkdown:
+		return renderParams{render: true}, nil
+	case content.RenderFormatText:
+		return renderParams{render: true, plainText: true}, nil
+	default:
+		return renderParams{}, &RouterParsingError{
+			ParamName:  RenderParam,
+			ParamValue: value,
+			ErrString:  "expected true, false, markdown or text",
+		}
+	}
+}
+
+// apply renders templates of given rules when requested
+func (p renderParams) apply(rules []types.RuleWithContentResponse) {
+	if !p.render {
+		return
+	}
+	for i := range rules {
+		content.RenderRuleTemplates(&rules[i], p.plainText)
+	}
+}
+
+// readDetailsParam returns the value of the "details" parameter in query if
+// available
+func readDetailsParam(request *http.Request) (bool, error) {
+	details, err := readOptionalBoolParam(DetailsParam, request)
+	if err != nil || details == nil {
+		return false, err
+	}
+	return *details, nil
+}
+
 // readOSDEligibleParam returns the value of the "osd_eligible" parameter in query
 // if available
 func readOSDEligible(request *http.Request) (bool, error) {
@@ -55,8 +55,6 @@ func TestEnableEndpoint(t *testing.T) {
 			&helpers.DefaultServerConfigXRH,
 			&helpers.DefaultServicesConfig,
 			nil,
-			nil,
-			nil,
 			&helpers.APIRequest{
 				Method:       http.MethodPut,
 				Endpoint:     server.EnableRuleForClusterEndpoint,
@@ -97,8 +95,6 @@ func TestDisableEndpoint(t *testing.T) {
 			&helpers.DefaultServerConfigXRH,
 			&helpers.DefaultServicesConfig,
 			nil,
-			nil,
-			nil,
 			&helpers.APIRequest{
 				Method:       http.MethodPut,
 				Endpoint:     server.DisableRuleForClusterEndpoint,
@@ -127,8 +123,6 @@ func TestEnableEndpointBadErrorKey(t *testing.T) {
 			&helpers.DefaultServerConfigXRH,
 			&helpers.DefaultServicesConfig,
 			nil,
-			nil,
-			nil,
 			&helpers.APIRequest{
 				Method:       http.MethodPut,
 				Endpoint:     server.EnableRuleForClusterEndpoint,
@@ -157,8 +151,6 @@ func TestDisableEndpointBadErrorKey(t *testing.T) {
 			&helpers.DefaultServerConfigXRH,
 			&helpers.DefaultServicesConfig,
 			nil,
-			nil,
-			nil,
 			&helpers.APIRequest{
 				Method:       http.MethodPut,
 				Endpoint:     server.DisableRuleForClusterEndpoint,
@@ -26,12 +26,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	// we just have to import this package in order to expose pprof
@@ -95,15 +95,31 @@ const (
 
 // HTTPServer is an implementation of Server interface
 type HTTPServer struct {
-	Config            Configuration
-	InfoParams        map[string]string
-	ServicesConfig    services.Configuration
-	amsClient         amsclient.AMSClient
-	GroupsChannel     chan []groups.Group
-	ErrorFoundChannel chan bool
-	ErrorChannel      chan error
-	Serv              *http.Server
-	redis             services.RedisInterface
+	Config         Configuration
+	InfoParams     map[string]string
+	ServicesConfig services.Configuration
+	amsClient      amsclient.AMSClient
+	GroupsStore    *services.GroupsStore
+	// VisibilityPolicy is the store of rule visibility policy read from
+	// the policy file. When nil, the policy is derived from the list of
+	// internal rules organizations.
+	VisibilityPolicy *services.VisibilityPolicyStore
+	// InternalOrganizations is the store of internal rules organizations
+	// read from CSV file. When nil, the list from configuration is used.
+	InternalOrganizations *services.OrganizationsStore
+	// organizationsPolicy is the policy derived from the list of internal
+	// rules organizations in configuration, built once by New
+	organizationsPolicy *services.VisibilityPolicy
+	Serv                *http.Server
+	redis               services.RedisInterface
+	// shuttingDown is set to 1 (atomically) when graceful shutdown begins
+	shuttingDown int32
+	// eventStreams limits the number of concurrent streams of request
+	// events, each open stream holds one slot
+	eventStreams chan struct{}
+	// eventsIntervals are intervals of the events stream, computed once
+	// by New
+	eventsIntervals eventsIntervals
 }
 
 // RequestModifier is a type of function which modifies request when proxying
@@ -124,19 +140,33 @@ func New(config Configuration,
 	servicesConfig services.Configuration,
 	amsClient amsclient.AMSClient,
 	redis services.RedisInterface,
-	groupsChannel chan []groups.Group,
-	errorFoundChannel chan bool,
-	errorChannel chan error,
+	groupsStore *services.GroupsStore,
 ) *HTTPServer {
-	return &HTTPServer{
-		Config:            config,
-		InfoParams:        make(map[string]string),
-		ServicesConfig:    servicesConfig,
-		amsClient:         amsClient,
-		redis:             redis,
-		GroupsChannel:     groupsChannel,
-		ErrorFoundChannel: errorFoundChannel,
-		ErrorChannel:      errorChannel,
+	server := &HTTPServer{
+		Config:         config,
+		InfoParams:     make(map[string]string),
+		ServicesConfig: servicesConfig,
+		amsClient:      amsClient,
+		redis:          redis,
+		GroupsStore:    groupsStore,
+		Serv:           newHTTPServer(config.Address),
+		eventStreams:   make(chan struct{}, eventsMaxStreams(config)),
+	}
+	server.eventsIntervals = newEventsIntervals(config, server.Serv.WriteTimeout)
+	if config.EnableInternalRulesOrganizations {
+		server.organizationsPolicy = services.NewOrganizationsVisibilityPolicy(config.InternalRulesOrganizations)
+	}
+	return server
+}
+
+// newHTTPServer constructs HTTP server listening on given address. The
+// handler is set when the server is started.
+func newHTTPServer(address string) *http.Server {
+	return &http.Server{
+		Addr:              address,
+		ReadTimeout:       1 * time.Minute,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      30 * time.Second,
 	}
 }
 
@@ -154,6 +184,7 @@ func (server *HTTPServer) Initialize() http.Handler {
 	log.Info().Msgf("Initializing HTTP server at '%s'", server.Config.Address)
 
 	router := mux.NewRouter().StrictSlash(true)
+	router.Use(keepFlusher)
 	router.Use(httputils.LogRequest)
 
 	apiPrefix := server.Config.APIv1Prefix
@@ -176,6 +207,8 @@ func (server *HTTPServer) Initialize() http.Handler {
 			openAPIv2URL,
 			infoV1URL,
 			infoV2URL,
+			HealthzEndpoint,
+			ReadyzEndpoint,
 			metricsURL + "?",   // to be able to test using Frisby
 			openAPIv1URL + "?", // to be able to test using Frisby
 			openAPIv2URL + "?", // to be able to test using Frisby
@@ -216,6 +249,10 @@ func (server *HTTPServer) addEndpointsToRouter(router *mux.Router) {
 	}
 	server.addV1EndpointsToRouter(router)
 	server.addV2EndpointsToRouter(router)
+
+	// liveness and readiness probes
+	router.HandleFunc(HealthzEndpoint, server.healthzEndpoint).Methods(http.MethodGet)
+	router.HandleFunc(ReadyzEndpoint, server.readyzEndpoint).Methods(http.MethodGet)
 }
 
 // Start method starts HTTP or HTTPS server.
@@ -223,13 +260,10 @@ func (server *HTTPServer) Start() error {
 	address := server.Config.Address
 	log.Info().Msgf("Starting HTTP server at '%s'", address)
 	router := server.Initialize()
-	server.Serv = &http.Server{
-		Addr:              address,
-		Handler:           router,
-		ReadTimeout:       1 * time.Minute,
-		ReadHeaderTimeout: 5 * time.Second,
-		WriteTimeout:      30 * time.Second,
+	if server.Serv == nil {
+		server.Serv = newHTTPServer(address)
 	}
+	server.Serv.Handler = router
 	var err error
 
 	if server.Config.UseHTTPS {
@@ -247,9 +281,39 @@ func (server *HTTPServer) Start() error {
 
 // Stop method stops server's execution.
 func (server *HTTPServer) Stop(ctx context.Context) error {
+	if server.Serv == nil {
+		return nil
+	}
 	return server.Serv.Shutdown(ctx)
 }
 
+// Shutdown method gracefully stops server's execution. The server is marked
+// as not ready first, so readiness probe starts failing. New connections are
+// still accepted during the configured drain delay, so the load balancer
+// has time to notice the failing probe. Then the server stops accepting new
+// connections and waits for in-flight requests until the context is done.
+func (server *HTTPServer) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&server.shuttingDown, 1)
+
+	if delay := server.Config.ShutdownDrainDelay; delay > 0 {
+		log.Info().Dur("drain_delay", delay).Msg("HTTP server marked as not ready, draining")
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	log.Info().Msg("Shutting down HTTP server, waiting for in-flight requests")
+	return server.Stop(ctx)
+}
+
+// isShuttingDown checks if graceful shutdown of the server has begun
+func (server *HTTPServer) isShuttingDown() bool {
+	return atomic.LoadInt32(&server.shuttingDown) == 1
+}
+
 // modifyRequest function modifies HTTP request during proxying it to another
 // service.
 // TODO: move to utils?
@@ -302,7 +366,7 @@ func (server *HTTPServer) proxyTo(baseURL string, options *ProxyOptions) func(ht
 			return
 		}
 
-		client := http.Client{}
+		client := server.upstreamClient(baseURL)
 		req, err := http.NewRequest(request.Method, endpointURL.String(), request.Body)
 		if err != nil {
 			panic(err)
@@ -343,8 +407,21 @@ func (server *HTTPServer) evaluateProxyError(writer http.ResponseWriter, err err
 	}
 }
 
+// upstreamClient returns the shared HTTP client configured for the service
+// available on given base URL
+func (server *HTTPServer) upstreamClient(baseURL string) *http.Client {
+	switch baseURL {
+	case server.ServicesConfig.AggregatorBaseEndpoint:
+		return server.ServicesConfig.AggregatorClient()
+	case server.ServicesConfig.ContentBaseEndpoint:
+		return server.ServicesConfig.ContentClient()
+	default:
+		return &http.Client{}
+	}
+}
+
 func sendRequest(
-	client http.Client, req *http.Request, options *ProxyOptions,
+	client *http.Client, req *http.Request, options *ProxyOptions,
 ) (*http.Response, []byte, error) {
 	log.Debug().Msgf("Connecting to %s", req.URL.RequestURI())
 	response, err := client.Do(req)
@@ -450,7 +527,7 @@ func (server HTTPServer) getClusterDetailsFromAggregator(orgID ctypes.OrgID) ([]
 	)
 
 	// #nosec G107
-	response, err := http.Get(aggregatorURL)
+	response, err := server.ServicesConfig.AggregatorClient().Get(aggregatorURL)
 	if err != nil {
 		log.Error().Err(err).Msgf("problem getting cluster list from aggregator")
 		if _, ok := err.(*url.Error); ok {
@@ -485,7 +562,7 @@ func (server HTTPServer) readAggregatorReportForClusterID(
 	)
 
 	// #nosec G107
-	aggregatorResp, err := http.Get(aggregatorURL)
+	aggregatorResp, err := server.ServicesConfig.AggregatorClient().Get(aggregatorURL)
 	if err != nil {
 		if _, ok := err.(*url.Error); ok {
 			handleServerError(writer, &AggregatorServiceUnavailableError{})
@@ -543,7 +620,7 @@ func (server HTTPServer) readAggregatorReportMetainfoForClusterID(
 	)
 
 	// #nosec G107
-	aggregatorResp, err := http.Get(aggregatorURL)
+	aggregatorResp, err := server.ServicesConfig.AggregatorClient().Get(aggregatorURL)
 	if err != nil {
 		if _, ok := err.(*url.Error); ok {
 			handleServerError(writer, &AggregatorServiceUnavailableError{})
@@ -594,7 +671,7 @@ func (server HTTPServer) readAggregatorReportForClusterList(
 		clist)
 
 	// #nosec G107
-	aggregatorResp, err := http.Get(aggregatorURL)
+	aggregatorResp, err := server.ServicesConfig.AggregatorClient().Get(aggregatorURL)
 	if err != nil {
 		if _, ok := err.(*url.Error); ok {
 			handleServerError(writer, &AggregatorServiceUnavailableError{})
@@ -647,7 +724,7 @@ func (server HTTPServer) readAggregatorReportForClusterListFromBody(
 		return nil, false
 	}
 	// #nosec G107
-	aggregatorResp, err := http.Post(aggregatorURL, JSONContentType, bytes.NewBuffer(body))
+	aggregatorResp, err := server.ServicesConfig.AggregatorClient().Post(aggregatorURL, JSONContentType, bytes.NewBuffer(body))
 	if err != nil {
 		if _, ok := err.(*url.Error); ok {
 			handleServerError(writer, &AggregatorServiceUnavailableError{})
@@ -711,7 +788,7 @@ func (server HTTPServer) readAggregatorRuleForClusterID(
 	)
 
 	// #nosec G107
-	aggregatorResp, err := http.Get(aggregatorURL)
+	aggregatorResp, err := server.ServicesConfig.AggregatorClient().Get(aggregatorURL)
 	if err != nil {
 		if _, ok := err.(*url.Error); ok {
 			handleServerError(writer, &AggregatorServiceUnavailableError{})
@@ -875,6 +952,12 @@ func (server HTTPServer) buildReportEndpointResponse(
 	}
 	log.Info().Msgf("Cluster ID: %v; %s flag = %t", clusterID, GetDisabledParam, includeDisabled)
 
+	render, err := readRenderParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
 	orgID, err := server.GetCurrentOrgID(request)
 	if err != nil {
 		log.Error().Msg(authTokenFormatError)
@@ -893,6 +976,7 @@ func (server HTTPServer) buildReportEndpointResponse(
 
 	visibleRules, noContentRulesCnt, disabledRulesCnt, err := filterRulesInResponse(
 		aggregatorResponse.Report, osdFlag, includeDisabled, systemWideRuleDisables,
+		server.ruleVisibility(request),
 	)
 	log.Info().Msgf("Cluster ID: %v; visible rules %d, no content rules %d, disabled rules %d", clusterID, len(visibleRules), noContentRulesCnt, disabledRulesCnt)
 
@@ -901,6 +985,8 @@ func (server HTTPServer) buildReportEndpointResponse(
 		return nil, 0, err
 	}
 
+	render.apply(visibleRules)
+
 	rulesCount = server.getRuleCount(visibleRules, noContentRulesCnt, disabledRulesCnt, clusterID)
 	return
 }
@@ -1151,6 +1237,13 @@ func (server HTTPServer) singleRuleEndpoint(writer http.ResponseWriter, request
 	if err != nil {
 		log.Err(err).Msgf("Got error while parsing `%s` value", OSDEligibleParam)
 	}
+
+	render, err := readRenderParam(request)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
 	rule, filtered, err = content.FetchRuleContent(aggregatorResponse, osdFlag)
 
 	if err != nil || filtered {
@@ -1158,13 +1251,14 @@ func (server HTTPServer) singleRuleEndpoint(writer http.ResponseWriter, request
 		return
 	}
 
-	if rule.Internal {
-		err = server.checkInternalRulePermissions(request)
-		if err != nil {
-			log.Error().Err(err).Send()
-			handleServerError(writer, err)
-			return
-		}
+	err = checkRuleVisibility(server.ruleVisibility(request), rule.RuleID, rule.Tags)
+	if err != nil {
+		handleServerError(writer, err)
+		return
+	}
+
+	if render.render {
+		content.RenderRuleTemplates(rule, render.plainText)
 	}
 
 	err = responses.SendOK(writer, responses.BuildOkResponseWithData("report", *rule))
@@ -1186,72 +1280,29 @@ func handleFetchRuleContentError(writer http.ResponseWriter, err error) {
 	}
 }
 
-// checkInternalRulePermissions method checks if organizations for internal
-// rules are enabled if so, retrieves the org_id from request/token and returns
-// whether that ID is on the list of allowed organizations to access internal
-// rules
-func (server *HTTPServer) checkInternalRulePermissions(request *http.Request) error {
-	if !server.Config.EnableInternalRulesOrganizations || !server.Config.Auth {
-		return nil
-	}
-
-	requestOrgID, err := server.GetCurrentOrgID(request)
-	if err != nil {
-		log.Error().Err(err).Msg("error retrieving org_id from token")
-		return err
-	}
-
-	log.Debug().Msgf("Checking internal rule permissions for Organization ID: %v", requestOrgID)
-	for _, allowedID := range server.Config.InternalRulesOrganizations {
-		if requestOrgID == allowedID {
-			log.Info().Msgf("Organization %v is allowed access to internal rules", requestOrgID)
-			return nil
-		}
-	}
-
-	// If the loop ends without returning nil, then an authentication error should be raised
-	const message = "This organization is not allowed to access this recommendation"
-	return &AuthenticationError{ErrString: message}
-}
-
-// getGroupsConfig retrieves the groups configuration from a channel to get the
-// latest valid one
+// getGroupsConfig retrieves the latest valid groups configuration from the
+// groups store
 func (server HTTPServer) getGroupsConfig() (
 	ruleGroups []groups.Group,
 	err error,
 ) {
-	var errorFound bool
-	ruleGroups = []groups.Group{}
-
-	select {
-	case val, ok := <-server.ErrorFoundChannel:
-		if !ok {
-			log.Error().Msgf("errorFound channel is closed")
-			return
-		}
-		errorFound = val
-	default:
-		fmt.Println("errorFound channel is empty")
-		return
-	}
-
-	if errorFound {
-		err = <-server.ErrorChannel
-		if _, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
-			log.Error().Err(err).Send()
-		}
-		log.Error().Err(err).Msg("Error occurred during groups retrieval from content service")
+	if server.GroupsStore == nil {
+		err = services.ErrNoGroupsRetrieved
+		log.Error().Err(err).Msg("groups store is not initialized")
 		return nil, err
 	}
 
-	groupsConfig := <-server.GroupsChannel
-	if groupsConfig == nil {
-		err := errors.New("no groups retrieved")
+	ruleGroups, err = server.GroupsStore.Get()
+	if err == services.ErrNoGroupsRetrieved {
 		log.Error().Err(err).Msg("groups cannot be retrieved from content service. Check logs")
 		return nil, err
 	}
+	if err != nil {
+		log.Error().Err(err).Msg("Error occurred during groups retrieval from content service")
+		return nil, err
+	}
 
-	return groupsConfig, nil
+	return ruleGroups, nil
 }
 
 func isDisabledForOrgRule(aggregatorRule ctypes.RuleOnReport, systemWideDisabledRules map[types.RuleID]bool) bool {
@@ -1280,8 +1331,9 @@ func isDisabledRule(aggregatorRule ctypes.RuleOnReport, systemWideDisabledRules
 // - The rule has content from the content-service
 // - The disabled filter is not match
 // - The OSD elegible filter is not match
+// - The rule is visible to the requester
 func filterRulesInResponse(aggregatorReport []ctypes.RuleOnReport, filterOSD, getDisabled bool,
-	systemWideDisabledRules map[types.RuleID]bool) (
+	systemWideDisabledRules map[types.RuleID]bool, visibility services.RuleVisibility) (
 	okRules []types.RuleWithContentResponse,
 	noContentRulesCnt int,
 	disabledRulesCnt int,
@@ -1291,6 +1343,19 @@ func filterRulesInResponse(aggregatorReport []ctypes.RuleOnReport, filterOSD, ge
 	okRules = []types.RuleWithContentResponse{}
 	disabledRulesCnt, noContentRulesCnt = 0, 0
 
+	if len(aggregatorReport) == 0 {
+		return
+	}
+
+	// all rules of one report are rendered from the same content snapshot
+	snapshot, err := content.GetContentSnapshot()
+	if err != nil {
+		// error occured during communication with Content Service
+		log.Error().Err(err).Send()
+		contentError = err
+		return
+	}
+
 	for i := range aggregatorReport {
 		aggregatorRule := aggregatorReport[i]
 		if !getDisabled && isDisabledRule(aggregatorRule, systemWideDisabledRules) {
@@ -1299,19 +1364,13 @@ func filterRulesInResponse(aggregatorReport []ctypes.RuleOnReport, filterOSD, ge
 			continue
 		}
 
-		rule, filtered, err := content.FetchRuleContent(&aggregatorRule, filterOSD)
+		rule, filtered, err := snapshot.FetchRuleContent(&aggregatorRule, filterOSD)
 		if err != nil {
 			if !filtered {
 				// rule has not been filtered by OSDEligible field
 				log.Info().Msgf("no content rule ID %v|%v", aggregatorRule.Module, aggregatorRule.ErrorKey)
 				noContentRulesCnt++
 			}
-			if _, ok := err.(*content.RuleContentDirectoryTimeoutError); ok {
-				// error occured during communication with Content Service
-				log.Error().Err(err).Send()
-				contentError = err
-				return
-			}
 			continue
 		}
 
@@ -1321,6 +1380,11 @@ func filterRulesInResponse(aggregatorReport []ctypes.RuleOnReport, filterOSD, ge
 			continue
 		}
 
+		if !visibility.Allows(rule.RuleID, rule.Tags) {
+			log.Info().Msgf("not visible rule ID %v|%v", aggregatorRule.Module, aggregatorRule.ErrorKey)
+			continue
+		}
+
 		okRules = append(okRules, *rule)
 	}
 
@@ -1343,7 +1407,7 @@ func (server *HTTPServer) readListOfClusterDisabledRules(orgID types.OrgID) ([]c
 	)
 
 	// #nosec G107
-	resp, err := http.Get(aggregatorURL)
+	resp, err := server.ServicesConfig.AggregatorClient().Get(aggregatorURL)
 	if err != nil {
 		return nil, err
 	}
@@ -1390,7 +1454,7 @@ func (server *HTTPServer) readListOfDisabledRulesForClusters(
 	}
 
 	// #nosec G107
-	resp, err := http.Post(aggregatorURL, JSONContentType, bytes.NewBuffer(jsonMarshalled))
+	resp, err := server.ServicesConfig.AggregatorClient().Post(aggregatorURL, JSONContentType, bytes.NewBuffer(jsonMarshalled))
 	if err != nil {
 		log.Error().Err(err).Msgf("readListOfDisabledRulesForClusters problem getting response from aggregator")
 		if _, ok := err.(*url.Error); ok {
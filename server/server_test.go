@@ -604,9 +604,17 @@ var (
 
 	GetRecommendationsResponse1Rule2Cluster = struct {
 		Status          string                         `json:"status"`
+		Meta            map[string]interface{}         `json:"meta"`
 		Recommendations []types.RecommendationListView `json:"recommendations"`
 	}{
 		Status: "ok",
+		Meta: map[string]interface{}{
+			"count":    1,
+			"total":    1,
+			"filtered": 1,
+			"offset":   0,
+			"limit":    0,
+		},
 		Recommendations: []types.RecommendationListView{
 			{
 				RuleID:              testdata.Rule1CompositeID,
@@ -814,9 +822,17 @@ var (
 
 	GetRecommendationsResponse0Rules = struct {
 		Status          string                         `json:"status"`
+		Meta            map[string]interface{}         `json:"meta"`
 		Recommendations []types.RecommendationListView `json:"recommendations"`
 	}{
-		Status:          "ok",
+		Status: "ok",
+		Meta: map[string]interface{}{
+			"count":    0,
+			"total":    0,
+			"filtered": 0,
+			"offset":   0,
+			"limit":    0,
+		},
 		Recommendations: []types.RecommendationListView{},
 	}
 
@@ -926,7 +942,11 @@ var (
 		Clusters []types.ClusterListView `json:"data"`
 	}{
 		Meta: map[string]interface{}{
-			"count": 0,
+			"count":    0,
+			"total":    0,
+			"filtered": 0,
+			"offset":   0,
+			"limit":    0,
 		},
 		Status:   "ok",
 		Clusters: []types.ClusterListView{},
@@ -1250,8 +1270,6 @@ func TestServerStartError(t *testing.T) {
 		nil,
 		nil,
 		nil,
-		nil,
-		nil,
 	)
 
 	err := testServer.Start()
@@ -1259,7 +1277,7 @@ func TestServerStartError(t *testing.T) {
 }
 
 func TestAddCORSHeaders(t *testing.T) {
-	helpers.AssertAPIRequest(t, &helpers.DefaultServerConfigCORS, &helpers.DefaultServicesConfig, nil, nil, nil, &helpers.APIRequest{
+	helpers.AssertAPIRequest(t, &helpers.DefaultServerConfigCORS, &helpers.DefaultServicesConfig, nil, &helpers.APIRequest{
 		Method:   http.MethodOptions,
 		Endpoint: server.RuleGroupsEndpoint,
 		ExtraHeaders: http.Header{
@@ -1281,7 +1299,7 @@ func TestAddCORSHeaders(t *testing.T) {
 func TestHTTPServer_SetAMSInfoInReportNoAMSClient(t *testing.T) {
 	report := types.SmartProxyReportV2{}
 	config := helpers.DefaultServerConfig
-	testServer := helpers.CreateHTTPServer(&config, nil, nil, nil, nil, nil, nil)
+	testServer := helpers.CreateHTTPServer(&config, nil, nil, nil, nil)
 	testServer.SetAMSInfoInReport(testdata.ClusterName, &report)
 	assert.Equal(t, string(testdata.ClusterName), report.Meta.DisplayName)
 }
@@ -1294,7 +1312,7 @@ func TestHTTPServer_SetAMSInfoInReportAMSClientClusterIDFound(t *testing.T) {
 		testdata.OrgID,
 		data.ClusterInfoResult,
 	)
-	testServer := helpers.CreateHTTPServer(&config, nil, amsClientMock, nil, nil, nil, nil)
+	testServer := helpers.CreateHTTPServer(&config, nil, amsClientMock, nil, nil)
 	testServer.SetAMSInfoInReport(testdata.ClusterName, &report)
 	assert.Equal(t, data.ClusterDisplayName1, report.Meta.DisplayName)
 }
@@ -1302,7 +1320,7 @@ func TestHTTPServer_SetAMSInfoInReportAMSClientClusterIDFound(t *testing.T) {
 // TestInfoEndpointNoAuthToken checks that the info endpoint can be accessed without authenticating
 func TestInfoEndpointNoAuthToken(t *testing.T) {
 	t.Run("test the info endpoint v1", func(t *testing.T) {
-		helpers.AssertAPIRequest(t, &helpers.DefaultServerConfigXRH, &helpers.DefaultServicesConfig, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIRequest(t, &helpers.DefaultServerConfigXRH, &helpers.DefaultServicesConfig, nil, &helpers.APIRequest{
 			Method:   http.MethodGet,
 			Endpoint: server.InfoEndpoint,
 		}, &helpers.APIResponse{
@@ -1310,7 +1328,7 @@ func TestInfoEndpointNoAuthToken(t *testing.T) {
 		})
 	})
 	t.Run("test the info endpoint v2", func(t *testing.T) {
-		helpers.AssertAPIv2Request(t, &helpers.DefaultServerConfigXRH, &helpers.DefaultServicesConfig, nil, nil, nil, &helpers.APIRequest{
+		helpers.AssertAPIv2Request(t, &helpers.DefaultServerConfigXRH, &helpers.DefaultServicesConfig, nil, &helpers.APIRequest{
 			Method:   http.MethodGet,
 			Endpoint: server.InfoEndpoint,
 		}, &helpers.APIResponse{
@@ -18,7 +18,6 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
-	"time"
 
 	httputils "github.com/RedHatInsights/insights-operator-utils/http"
 	"github.com/RedHatInsights/insights-operator-utils/responses"
@@ -135,9 +134,7 @@ func (server *HTTPServer) fetchUpgradePrediction(
 		cluster,
 	)
 
-	httpClient := http.Client{
-		Timeout: 5 * time.Second,
-	}
+	httpClient := server.ServicesConfig.UpgradeRisksPredictionClient()
 
 	// #nosec G107
 	// nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
@@ -0,0 +1,106 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+// Enforcement of the rule visibility policy. Every handler returning rule
+// content or rule hits retrieves the visibility of the requester by
+// ruleVisibility and omits (or refuses to return) rules that are not
+// visible.
+
+import (
+	"encoding/json"
+	"net/http"
+
+	types "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+)
+
+// contextKeyAccountType is the key of the requester's account type (type of
+// the identity in x-rh-identity header) in request context
+const contextKeyAccountType = types.ContextKey("account_type")
+
+// ruleNotVisibleMessage is returned when the requester asks for a rule that
+// is not visible to the organization
+const ruleNotVisibleMessage = "This organization is not allowed to access this recommendation"
+
+// readAccountType reads type of the identity from decoded x-rh-identity
+// token. Empty string is returned when the type is not set.
+func readAccountType(decodedToken []byte) string {
+	var token struct {
+		Identity struct {
+			Type string `json:"type"`
+		} `json:"identity"`
+	}
+	if err := json.Unmarshal(decodedToken, &token); err != nil {
+		return ""
+	}
+	return token.Identity.Type
+}
+
+// getCurrentAccountType retrieves account type of the requester from request
+func getCurrentAccountType(request *http.Request) string {
+	accountType, _ := request.Context().Value(contextKeyAccountType).(string)
+	return accountType
+}
+
+// visibilityPolicy returns the active rule visibility policy. Nil is
+// returned when all rules are visible to everybody.
+func (server *HTTPServer) visibilityPolicy() *services.VisibilityPolicy {
+	if !server.Config.Auth {
+		return nil
+	}
+	if server.VisibilityPolicy != nil {
+		return server.VisibilityPolicy.Get()
+	}
+	if !server.Config.EnableInternalRulesOrganizations {
+		return nil
+	}
+	if server.InternalOrganizations != nil {
+		return server.InternalOrganizations.VisibilityPolicy()
+	}
+	return server.organizationsPolicy
+}
+
+// ruleVisibility returns rule classes visible to the requester. Only classes
+// visible to everybody are returned when the requester can't be identified.
+func (server *HTTPServer) ruleVisibility(request *http.Request) services.RuleVisibility {
+	policy := server.visibilityPolicy()
+	if policy == nil {
+		return services.RuleVisibility{}
+	}
+
+	orgID, err := server.GetCurrentOrgID(request)
+	if err != nil {
+		log.Error().Err(err).Msg("error retrieving org_id from token")
+		return policy.Visibility(0, "")
+	}
+
+	return policy.Visibility(orgID, getCurrentAccountType(request))
+}
+
+// checkRuleVisibility returns AuthenticationError when the rule with given
+// module and error keys tags is not visible
+func checkRuleVisibility(
+	visibility services.RuleVisibility, module types.RuleID, errorKeysTags ...[]string,
+) error {
+	if visibility.Allows(module, errorKeysTags...) {
+		return nil
+	}
+
+	log.Info().Msgf("Rule %v is not visible to the organization", module)
+	return &AuthenticationError{ErrString: ruleNotVisibleMessage}
+}
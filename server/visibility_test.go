@@ -0,0 +1,284 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iou_helpers "github.com/RedHatInsights/insights-operator-utils/tests/helpers"
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ctypes "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/server"
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+// previewRuleContent returns the second test rule with all error keys tagged
+// as preview
+func previewRuleContent() ctypes.RuleContent {
+	rule := testdata.RuleContent2
+	rule.ErrorKeys = make(map[string]ctypes.RuleErrorKeyContent)
+	for name, errorKey := range testdata.RuleContent2.ErrorKeys {
+		errorKey.Metadata.Tags = append([]string{"preview"}, errorKey.Metadata.Tags...)
+		rule.ErrorKeys[name] = errorKey
+	}
+	return rule
+}
+
+// createServerWithVisibilityPolicy creates server using visibility policy
+// with given content. The policy replaces internal rules organizations from
+// the configuration.
+func createServerWithVisibilityPolicy(t *testing.T, policy string) *server.HTTPServer {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	helpers.FailOnError(t, os.WriteFile(path, []byte(policy), 0o600))
+
+	store, err := services.NewVisibilityPolicyStore(path)
+	helpers.FailOnError(t, err)
+
+	testServer := helpers.CreateHTTPServer(&serverConfigInternalOrganizations2, nil, nil, nil, nil)
+	testServer.VisibilityPolicy = store
+	return testServer
+}
+
+// executeVisibilityRequest sends GET request with identity of organization 1
+// and type User to the server
+func executeVisibilityRequest(
+	t *testing.T, testServer *server.HTTPServer, endpoint string, response interface{},
+) int {
+	request := httptest.NewRequest(http.MethodGet, endpoint, http.NoBody)
+	request.Header.Set("x-rh-identity", goodXRHAuthToken)
+
+	result := iou_helpers.ExecuteRequest(testServer, request).Result()
+	defer func() {
+		assert.NoError(t, result.Body.Close())
+	}()
+
+	if response != nil && result.StatusCode == http.StatusOK {
+		helpers.FailOnError(t, json.NewDecoder(result.Body).Decode(response))
+	}
+	return result.StatusCode
+}
+
+// TestVisibilityPolicyRuleClasses checks that rule classes are visible
+// according to the policy file
+func TestVisibilityPolicyRuleClasses(t *testing.T) {
+	err := loadMockRuleContentDir(
+		createRuleContentDirectoryFromRuleContent(
+			[]ctypes.RuleContent{RuleContentInternal1, testdata.RuleContent1, previewRuleContent()},
+		),
+	)
+	helpers.FailOnError(t, err)
+
+	ruleIDsEndpoint := serverConfigInternalOrganizations2.APIv1Prefix + server.RuleIDs
+	ruleContentEndpoint := serverConfigInternalOrganizations2.APIv2Prefix +
+		strings.Replace(server.RuleContentV2, "{rule_id}", "%s", 1)
+
+	var ruleIDs struct {
+		Rules []ctypes.RuleID `json:"rules"`
+	}
+
+	t.Run("preview allowed to account type", func(t *testing.T) {
+		testServer := createServerWithVisibilityPolicy(t, `
+organizations:
+  2: [internal]
+account_types:
+  User: [preview]
+`)
+		assert.Equal(t, http.StatusOK, executeVisibilityRequest(t, testServer, ruleIDsEndpoint, &ruleIDs))
+		assert.ElementsMatch(t, []ctypes.RuleID{
+			testdata.Rule1.Module, testdata.Rule2.Module,
+		}, ruleIDs.Rules)
+
+		assert.Equal(t, http.StatusForbidden, executeVisibilityRequest(t, testServer,
+			strings.Replace(ruleContentEndpoint, "%s", internalRuleID, 1), nil,
+		))
+		assert.Equal(t, http.StatusOK, executeVisibilityRequest(t, testServer,
+			strings.Replace(ruleContentEndpoint, "%s", string(testdata.Rule2CompositeID), 1), nil,
+		))
+	})
+
+	t.Run("internal allowed to organization", func(t *testing.T) {
+		testServer := createServerWithVisibilityPolicy(t, `
+organizations:
+  1: [internal]
+`)
+		assert.Equal(t, http.StatusOK, executeVisibilityRequest(t, testServer, ruleIDsEndpoint, &ruleIDs))
+		assert.ElementsMatch(t, []ctypes.RuleID{
+			testdata.Rule1.Module, internalTestRuleModule,
+		}, ruleIDs.Rules)
+
+		assert.Equal(t, http.StatusOK, executeVisibilityRequest(t, testServer,
+			strings.Replace(ruleContentEndpoint, "%s", internalRuleID, 1), nil,
+		))
+		assert.Equal(t, http.StatusForbidden, executeVisibilityRequest(t, testServer,
+			strings.Replace(ruleContentEndpoint, "%s", string(testdata.Rule2CompositeID), 1), nil,
+		))
+	})
+}
+
+// TestVisibilityPolicyReloadETag checks that ETag changes when the policy
+// changes rules belonging to a class, even when the visible classes are the
+// same
+func TestVisibilityPolicyReloadETag(t *testing.T) {
+	err := loadMockRuleContentDir(
+		createRuleContentDirectoryFromRuleContent(
+			[]ctypes.RuleContent{testdata.RuleContent1, previewRuleContent()},
+		),
+	)
+	helpers.FailOnError(t, err)
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writePolicy := func(tag string) {
+		helpers.FailOnError(t, os.WriteFile(path, []byte(`
+classes:
+  preview:
+    tags: [`+tag+`]
+organizations:
+  2: [preview]
+`), 0o600))
+	}
+	writePolicy("preview")
+
+	store, err := services.NewVisibilityPolicyStore(path)
+	helpers.FailOnError(t, err)
+	testServer := helpers.CreateHTTPServer(&serverConfigInternalOrganizations2, nil, nil, nil, nil)
+	testServer.VisibilityPolicy = store
+
+	getRuleIDs := func(etag string) *http.Response {
+		request := httptest.NewRequest(http.MethodGet,
+			serverConfigInternalOrganizations2.APIv1Prefix+server.RuleIDs, http.NoBody)
+		request.Header.Set("x-rh-identity", goodXRHAuthToken)
+		if etag != "" {
+			request.Header.Set("If-None-Match", etag)
+		}
+		response := iou_helpers.ExecuteRequest(testServer, request).Result()
+		t.Cleanup(func() {
+			assert.NoError(t, response.Body.Close())
+		})
+		return response
+	}
+
+	response := getRuleIDs("")
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	etag := response.Header.Get("ETag")
+	assert.Equal(t, http.StatusNotModified, getRuleIDs(etag).StatusCode)
+
+	// the preview rule doesn't belong to the class anymore
+	writePolicy("beta")
+	helpers.FailOnError(t, store.Reload())
+
+	response = getRuleIDs(etag)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.NotEqual(t, etag, response.Header.Get("ETag"))
+
+	var ruleIDs struct {
+		Rules []ctypes.RuleID `json:"rules"`
+	}
+	helpers.FailOnError(t, json.NewDecoder(response.Body).Decode(&ruleIDs))
+	assert.ElementsMatch(t, []ctypes.RuleID{testdata.Rule1.Module, testdata.Rule2.Module}, ruleIDs.Rules)
+}
+
+// TestVisibilityPolicyInternalOrganizations checks that only internal rules
+// are restricted to internal rules organizations when no policy file is
+// used, preview rules are visible to everybody
+func TestVisibilityPolicyInternalOrganizations(t *testing.T) {
+	err := loadMockRuleContentDir(
+		createRuleContentDirectoryFromRuleContent(
+			[]ctypes.RuleContent{RuleContentInternal1, testdata.RuleContent1, previewRuleContent()},
+		),
+	)
+	helpers.FailOnError(t, err)
+
+	var ruleIDs struct {
+		Rules []ctypes.RuleID `json:"rules"`
+	}
+
+	testServer := helpers.CreateHTTPServer(&serverConfigInternalOrganizations2, nil, nil, nil, nil)
+	assert.Equal(t, http.StatusOK, executeVisibilityRequest(t, testServer,
+		serverConfigInternalOrganizations2.APIv1Prefix+server.RuleIDs, &ruleIDs,
+	))
+	assert.ElementsMatch(t, []ctypes.RuleID{testdata.Rule1.Module, testdata.Rule2.Module}, ruleIDs.Rules)
+
+	testServer = helpers.CreateHTTPServer(&serverConfigInternalOrganizations1, nil, nil, nil, nil)
+	assert.Equal(t, http.StatusOK, executeVisibilityRequest(t, testServer,
+		serverConfigInternalOrganizations1.APIv1Prefix+server.RuleIDs, &ruleIDs,
+	))
+	assert.ElementsMatch(t, []ctypes.RuleID{
+		testdata.Rule1.Module, testdata.Rule2.Module, internalTestRuleModule,
+	}, ruleIDs.Rules)
+}
+
+// TestInternalOrganizationsReload checks that reloaded list of internal rules
+// organizations is used and shown by the debug endpoint
+func TestInternalOrganizationsReload(t *testing.T) {
+	err := loadMockRuleContentDir(
+		createRuleContentDirectoryFromRuleContent(
+			[]ctypes.RuleContent{RuleContentInternal1, testdata.RuleContent1},
+		),
+	)
+	helpers.FailOnError(t, err)
+
+	path := filepath.Join(t.TempDir(), "organizations.csv")
+	helpers.FailOnError(t, os.WriteFile(path, []byte("OrgID\n1\n"), 0o600))
+	store, err := services.NewOrganizationsStore(path)
+	helpers.FailOnError(t, err)
+
+	config := serverConfigInternalOrganizations2
+	config.APIdbgPrefix = "/api/dbg/"
+	testServer := helpers.CreateHTTPServer(&config, nil, nil, nil, nil)
+	testServer.InternalOrganizations = store
+
+	var ruleIDs struct {
+		Rules []ctypes.RuleID `json:"rules"`
+	}
+	var organizations struct {
+		Enabled       bool           `json:"enabled"`
+		Organizations []ctypes.OrgID `json:"organizations"`
+		LoadedAt      string         `json:"loaded_at"`
+	}
+
+	assert.Equal(t, http.StatusOK, executeVisibilityRequest(t, testServer,
+		config.APIv1Prefix+server.RuleIDs, &ruleIDs,
+	))
+	assert.ElementsMatch(t, []ctypes.RuleID{testdata.Rule1.Module, internalTestRuleModule}, ruleIDs.Rules)
+
+	assert.Equal(t, http.StatusOK, executeVisibilityRequest(t, testServer,
+		config.APIdbgPrefix+server.DbgInternalOrganizationsEndpoint, &organizations,
+	))
+	assert.True(t, organizations.Enabled)
+	assert.Equal(t, []ctypes.OrgID{1}, organizations.Organizations)
+	assert.NotEmpty(t, organizations.LoadedAt)
+
+	helpers.FailOnError(t, os.WriteFile(path, []byte("OrgID\n2\n3\n"), 0o600))
+	helpers.FailOnError(t, store.Reload())
+
+	assert.Equal(t, http.StatusOK, executeVisibilityRequest(t, testServer,
+		config.APIv1Prefix+server.RuleIDs, &ruleIDs,
+	))
+	assert.Equal(t, []ctypes.RuleID{testdata.Rule1.Module}, ruleIDs.Rules)
+
+	assert.Equal(t, http.StatusOK, executeVisibilityRequest(t, testServer,
+		config.APIdbgPrefix+server.DbgInternalOrganizationsEndpoint, &organizations,
+	))
+	assert.Equal(t, []ctypes.OrgID{2, 3}, organizations.Organizations)
+}
@@ -26,6 +26,12 @@ type RedisConfiguration struct {
 	RedisDatabase       int    `mapstructure:"database" toml:"database"`
 	RedisTimeoutSeconds int    `mapstructure:"timeout_seconds" toml:"timeout_seconds"`
 	RedisPassword       string `mapstructure:"password" toml:"password"`
+	// RedisDisableScanFallback disables finding request IDs by SCAN
+	// command in addition to index of request IDs
+	RedisDisableScanFallback bool `mapstructure:"disable_scan_fallback" toml:"disable_scan_fallback"`
+	// RedisEventsChannel is the pub/sub channel writers publish keys of
+	// changed requests to, in addition to keyspace notifications
+	RedisEventsChannel string `mapstructure:"events_channel" toml:"events_channel"`
 }
 
 // Configuration represents configuration of services on which smart-proxy depends.
@@ -35,4 +41,13 @@ type Configuration struct {
 	UpgradeRisksPredictionEndpoint string        `mapstructure:"upgrade_risks_prediction" toml:"upgrade_risks_prediction"`
 	GroupsPollingTime              time.Duration `mapstructure:"groups_poll_time" toml:"groups_poll_time"`
 	ContentDirectoryTimeout        time.Duration `mapstructure:"content_directory_timeout" toml:"content_directory_timeout"`
+	ContentSource                  string        `mapstructure:"content_source" toml:"content_source"`
+	ContentPath                    string        `mapstructure:"content_path" toml:"content_path"`
+	ContentWatch                   bool          `mapstructure:"content_watch" toml:"content_watch"`
+	GroupsPath                     string        `mapstructure:"groups_path" toml:"groups_path"`
+	ContentHistorySize             int           `mapstructure:"content_history_size" toml:"content_history_size"`
+
+	AggregatorClientConf             UpstreamConfiguration `mapstructure:"aggregator_client" toml:"aggregator_client"`
+	ContentClientConf                UpstreamConfiguration `mapstructure:"content_client" toml:"content_client"`
+	UpgradeRisksPredictionClientConf UpstreamConfiguration `mapstructure:"upgrade_risks_prediction_client" toml:"upgrade_risks_prediction_client"`
 }
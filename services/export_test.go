@@ -14,6 +14,8 @@
 
 package services
 
+import "time"
+
 // Export for testing
 //
 // This source file contains name aliases of all package-private functions
@@ -27,3 +29,25 @@ package services
 var (
 	GetFromURL = getFromURL
 )
+
+// SetMemoryRedisClock replaces the clock used to expire keys of MemoryRedis
+func SetMemoryRedisClock(m *MemoryRedis, now func() time.Time) {
+	m.now = now
+}
+
+// PubSub is the part of Redis pub/sub connection used to receive request
+// events
+type PubSub = pubSub
+
+// NewRedisClientWithPubSub constructs Redis client receiving request events
+// from given pub/sub connection
+func NewRedisClientWithPubSub(pubsub PubSub, database int, eventsChannel string) *RedisClient {
+	return &RedisClient{
+		events: newRequestEventsHub(func() pubSub { return pubsub }, database, eventsChannel),
+	}
+}
+
+// CloseRequestEvents closes pub/sub connection of request events of the client
+func CloseRequestEvents(redis *RedisClient) error {
+	return redis.events.close()
+}
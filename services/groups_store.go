@@ -0,0 +1,113 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/RedHatInsights/insights-content-service/groups"
+)
+
+// ErrNoGroupsRetrieved is returned by GroupsStore when no groups have been
+// retrieved from content service yet
+var ErrNoGroupsRetrieved = errors.New("no groups retrieved")
+
+// GroupsStore keeps the latest valid groups configuration retrieved from
+// content service together with the result of the last poll. It's thread
+// safe, so it can be updated by the polling loop and read by REST API
+// handlers at the same time.
+type GroupsStore struct {
+	mutex       sync.RWMutex
+	groups      []groups.Group
+	lastErr     error
+	lastSuccess time.Time
+	lastError   time.Time
+	modifiedAt  time.Time
+}
+
+// GroupsStatus represents the result of the last polls of groups
+type GroupsStatus struct {
+	// LastSuccess is the time of the last successful poll
+	LastSuccess time.Time
+	// LastError is the time of the last failed poll
+	LastError time.Time
+	// Err is the error of the last poll, nil if it succeeded
+	Err error
+}
+
+// NewGroupsStore constructs an empty store of groups
+func NewGroupsStore() *GroupsStore {
+	return &GroupsStore{}
+}
+
+// Update records the result of a poll. When the poll failed, previously
+// retrieved groups are kept.
+func (s *GroupsStore) Update(retrievedGroups []groups.Group, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err != nil {
+		s.lastErr = err
+		s.lastError = time.Now()
+		return
+	}
+
+	if s.groups == nil || !reflect.DeepEqual(s.groups, retrievedGroups) {
+		s.modifiedAt = time.Now()
+	}
+	s.groups = retrievedGroups
+	s.lastErr = nil
+	s.lastSuccess = time.Now()
+}
+
+// Get returns the latest valid groups configuration, even when the last poll
+// failed. Error is returned only when no groups have been retrieved yet;
+// failures of polls are reported by Status.
+func (s *GroupsStore) Get() ([]groups.Group, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.groups != nil {
+		return s.groups, nil
+	}
+	if s.lastErr != nil {
+		return nil, s.lastErr
+	}
+	return nil, ErrNoGroupsRetrieved
+}
+
+// ModifiedAt returns the time when the current groups have been retrieved
+// for the first time. Polls returning the same groups don't change it.
+func (s *GroupsStore) ModifiedAt() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.modifiedAt
+}
+
+// Status returns the result of the last polls of groups
+func (s *GroupsStore) Status() GroupsStatus {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return GroupsStatus{
+		LastSuccess: s.lastSuccess,
+		LastError:   s.lastError,
+		Err:         s.lastErr,
+	}
+}
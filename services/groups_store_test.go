@@ -0,0 +1,133 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-content-service/groups"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+)
+
+var testGroups = []groups.Group{
+	{Name: "Performance", Tags: []string{"performance"}},
+	{Name: "Security", Tags: []string{"security"}},
+}
+
+func TestGroupsStoreEmpty(t *testing.T) {
+	store := services.NewGroupsStore()
+
+	retrievedGroups, err := store.Get()
+	assert.ErrorIs(t, err, services.ErrNoGroupsRetrieved)
+	assert.Nil(t, retrievedGroups)
+
+	status := store.Status()
+	assert.True(t, status.LastSuccess.IsZero())
+	assert.True(t, status.LastError.IsZero())
+	assert.NoError(t, status.Err)
+}
+
+func TestGroupsStoreUpdate(t *testing.T) {
+	store := services.NewGroupsStore()
+	store.Update(testGroups, nil)
+
+	retrievedGroups, err := store.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, testGroups, retrievedGroups)
+
+	status := store.Status()
+	assert.False(t, status.LastSuccess.IsZero())
+	assert.True(t, status.LastError.IsZero())
+}
+
+func TestGroupsStoreFailedPoll(t *testing.T) {
+	pollError := errors.New("content service is not available")
+
+	store := services.NewGroupsStore()
+	store.Update(testGroups, nil)
+	store.Update(nil, pollError)
+
+	// previously retrieved groups are still returned
+	retrievedGroups, err := store.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, testGroups, retrievedGroups)
+
+	status := store.Status()
+	assert.False(t, status.LastSuccess.IsZero())
+	assert.False(t, status.LastError.IsZero())
+	assert.ErrorIs(t, status.Err, pollError)
+
+	store.Update(testGroups[:1], nil)
+	retrievedGroups, err = store.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, testGroups[:1], retrievedGroups)
+	assert.NoError(t, store.Status().Err)
+}
+
+func TestGroupsStoreFailedFirstPoll(t *testing.T) {
+	pollError := errors.New("content service is not available")
+
+	store := services.NewGroupsStore()
+	store.Update(nil, pollError)
+
+	retrievedGroups, err := store.Get()
+	assert.ErrorIs(t, err, pollError)
+	assert.Nil(t, retrievedGroups)
+}
+
+func TestGroupsStoreModifiedAt(t *testing.T) {
+	store := services.NewGroupsStore()
+	assert.True(t, store.ModifiedAt().IsZero())
+
+	store.Update(testGroups, nil)
+	modifiedAt := store.ModifiedAt()
+	assert.False(t, modifiedAt.IsZero())
+
+	// neither the same groups nor failed poll modify the groups
+	store.Update([]groups.Group{testGroups[0], testGroups[1]}, nil)
+	store.Update(nil, errors.New("content service is not available"))
+	assert.Equal(t, modifiedAt, store.ModifiedAt())
+
+	time.Sleep(time.Millisecond)
+	store.Update(testGroups[:1], nil)
+	assert.True(t, store.ModifiedAt().After(modifiedAt))
+}
+
+// TestGroupsStoreConcurrentAccess is supposed to be run with -race flag
+func TestGroupsStoreConcurrentAccess(t *testing.T) {
+	store := services.NewGroupsStore()
+	store.Update(testGroups, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			store.Update(testGroups, nil)
+		}()
+		go func() {
+			defer wg.Done()
+			retrievedGroups, err := store.Get()
+			assert.NoError(t, err)
+			assert.Len(t, retrievedGroups, len(testGroups))
+		}()
+	}
+	wg.Wait()
+}
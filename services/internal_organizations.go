@@ -0,0 +1,173 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+// Allow-list of organizations that can access internal rules. The list is
+// read from CSV file with header and organization ID in the first column.
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	types "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+)
+
+// ParseOrganizationsCSV reads organization IDs from CSV data. The first line
+// is a header and it's skipped. Error is returned when any organization ID is
+// not a positive number.
+func ParseOrganizationsCSV(r io.Reader) ([]types.OrgID, error) {
+	orgIDs := make([]types.OrgID, 0)
+
+	reader := csv.NewReader(r)
+
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("error reading CSV file: %v", err)
+	}
+
+	for index, line := range lines {
+		if index == 0 {
+			continue // skip header
+		}
+
+		orgID, err := strconv.ParseUint(line[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"organization ID on line %v in CSV is not numerical. Found value: %v",
+				index+1, line[0],
+			)
+		}
+		if orgID == 0 {
+			return nil, fmt.Errorf("organization ID on line %v in CSV is not positive", index+1)
+		}
+
+		orgIDs = append(orgIDs, types.OrgID(orgID))
+	}
+
+	return orgIDs, nil
+}
+
+// LoadOrganizationsCSV reads organization IDs from CSV file
+func LoadOrganizationsCSV(path string) ([]types.OrgID, error) {
+	// path is provided by configuration, not by user
+	file, err := os.Open(filepath.Clean(path)) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Error().Err(err).Str("path", path).Msg("unable to close organizations file")
+		}
+	}()
+
+	return ParseOrganizationsCSV(file)
+}
+
+// OrganizationsStore keeps the latest valid list of internal rules
+// organizations read from CSV file. It's thread safe, so the list can be
+// reloaded while REST API handlers read it.
+type OrganizationsStore struct {
+	mutex         sync.RWMutex
+	path          string
+	organizations map[types.OrgID]struct{}
+	policy        *VisibilityPolicy
+	loadedAt      time.Time
+}
+
+// NewOrganizationsStore constructs the store and loads the list from given
+// CSV file. Error is returned when the list can't be loaded.
+func NewOrganizationsStore(path string) (*OrganizationsStore, error) {
+	store := &OrganizationsStore{path: path}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Reload reads the CSV file again. Previous list is kept when the file can't
+// be read or when it's not valid. Load time is kept when the list has not
+// changed.
+func (s *OrganizationsStore) Reload() error {
+	orgIDs, err := LoadOrganizationsCSV(s.path)
+	if err != nil {
+		log.Error().Err(err).Str("path", s.path).Msg("unable to load internal rules organizations")
+		return err
+	}
+
+	organizations := make(map[types.OrgID]struct{}, len(orgIDs))
+	for _, orgID := range orgIDs {
+		organizations[orgID] = struct{}{}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.organizations != nil && reflect.DeepEqual(s.organizations, organizations) {
+		log.Debug().Str("path", s.path).Msg("internal rules organizations have not changed")
+		return nil
+	}
+
+	policy := NewOrganizationsVisibilityPolicy(orgIDs)
+	s.organizations = organizations
+	s.policy = policy
+	s.loadedAt = time.Now().UTC()
+	log.Info().Str("path", s.path).Int("organizations", len(organizations)).Msg("internal rules organizations loaded")
+	return nil
+}
+
+// List returns sorted organization IDs from the list
+func (s *OrganizationsStore) List() []types.OrgID {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	orgIDs := make([]types.OrgID, 0, len(s.organizations))
+	for orgID := range s.organizations {
+		orgIDs = append(orgIDs, orgID)
+	}
+	sort.Slice(orgIDs, func(i, j int) bool { return orgIDs[i] < orgIDs[j] })
+	return orgIDs
+}
+
+// VisibilityPolicy returns rule visibility policy allowing all rule classes
+// to the organizations from the list
+func (s *OrganizationsStore) VisibilityPolicy() *VisibilityPolicy {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.policy
+}
+
+// LoadedAt returns the time the current list has been loaded
+func (s *OrganizationsStore) LoadedAt() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.loadedAt
+}
+
+// RunReloadLoop reloads the list periodically and whenever the CSV file
+// changes, until stop is closed
+func (s *OrganizationsStore) RunReloadLoop(stop <-chan struct{}) {
+	runFileReloadLoop(s.path, "internal rules organizations", s.Reload, stop)
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	types "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+func TestParseOrganizationsCSV(t *testing.T) {
+	orgIDs, err := services.ParseOrganizationsCSV(strings.NewReader("OrgID\n3\n1\n3\n"))
+	helpers.FailOnError(t, err)
+	assert.Equal(t, []types.OrgID{3, 1, 3}, orgIDs)
+
+	_, err = services.ParseOrganizationsCSV(strings.NewReader("OrgID\n1\n0\n"))
+	assert.EqualError(t, err, "organization ID on line 3 in CSV is not positive")
+
+	_, err = services.ParseOrganizationsCSV(strings.NewReader("OrgID\n-1\n"))
+	assert.EqualError(t, err, "organization ID on line 2 in CSV is not numerical. Found value: -1")
+}
+
+// TestOrganizationsStoreReload checks that invalid or unchanged list doesn't
+// replace the previous one
+func TestOrganizationsStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "organizations.csv")
+	writeTestFile(t, path, []byte("OrgID\n3\n1\n3\n"))
+
+	store, err := services.NewOrganizationsStore(path)
+	helpers.FailOnError(t, err)
+	loadedAt := store.LoadedAt()
+	assert.False(t, loadedAt.IsZero())
+	assert.Equal(t, []types.OrgID{1, 3}, store.List())
+	assert.True(t, store.VisibilityPolicy().Visibility(1, "").Allows(internalModule))
+
+	// the same list in different order is not reloaded
+	writeTestFile(t, path, []byte("OrgID\n1\n3\n"))
+	helpers.FailOnError(t, store.Reload())
+	helpers.FailOnError(t, store.Reload())
+	assert.Equal(t, loadedAt, store.LoadedAt())
+
+	writeTestFile(t, path, []byte("OrgID\n2\nstr\n"))
+	assert.Error(t, store.Reload())
+	assert.Equal(t, []types.OrgID{1, 3}, store.List())
+	assert.Equal(t, loadedAt, store.LoadedAt())
+
+	writeTestFile(t, path, []byte("OrgID\n2\n"))
+	helpers.FailOnError(t, store.Reload())
+	assert.Equal(t, []types.OrgID{2}, store.List())
+	assert.False(t, store.VisibilityPolicy().Visibility(1, "").Allows(internalModule))
+	assert.True(t, store.VisibilityPolicy().Visibility(2, "").Allows(internalModule))
+
+	_, err = services.NewOrganizationsStore(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,285 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+// Local sources of rule content, an alternative to Content Service. Rule
+// content can be read either from rules content directory tree (the same
+// layout as parsed by Content Service) or from a snapshot file containing
+// already parsed RuleContentDirectory encoded as gob (the format returned by
+// Content Service) or as JSON. Groups can be read from the groups
+// configuration file used by Content Service.
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	ics_content "github.com/RedHatInsights/insights-content-service/content"
+	"github.com/RedHatInsights/insights-content-service/groups"
+	types "github.com/RedHatInsights/insights-results-types"
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ContentSourceService reads rule content from Content Service
+	ContentSourceService = "service"
+	// ContentSourceDirectory reads rule content from local rules content
+	// directory tree
+	ContentSourceDirectory = "directory"
+	// ContentSourceSnapshot reads rule content from local snapshot file
+	// (gob or JSON encoded RuleContentDirectory)
+	ContentSourceSnapshot = "snapshot"
+
+	// jsonSnapshotExtension is the extension of snapshot files encoded as
+	// JSON, all other snapshot files are expected to be encoded by gob
+	jsonSnapshotExtension = ".json"
+
+	// contentWatchDebounce is the time without any file system event that
+	// needs to pass before change of the local content is reported, so a
+	// content being copied is not read in the middle of the copy
+	contentWatchDebounce = time.Second
+)
+
+// IsLocalContentSource returns true when rule content is not read from
+// Content Service
+func (c Configuration) IsLocalContentSource() bool {
+	return c.ContentSource == ContentSourceDirectory || c.ContentSource == ContentSourceSnapshot
+}
+
+// getLocalContent reads rule content from the configured local source
+func getLocalContent(conf Configuration) (*types.RuleContentDirectory, error) {
+	switch conf.ContentSource {
+	case ContentSourceDirectory:
+		return GetContentFromDirectory(conf.ContentPath)
+	case ContentSourceSnapshot:
+		return GetContentFromSnapshot(conf.ContentPath)
+	default:
+		return nil, fmt.Errorf("unknown content source '%s'", conf.ContentSource)
+	}
+}
+
+// GetContentFromDirectory parses rule content from local rules content
+// directory tree, the same way as Content Service does
+func GetContentFromDirectory(path string) (*types.RuleContentDirectory, error) {
+	log.Debug().Str("path", path).Msg("parsing rules static content from directory")
+
+	contentDir, _, err := ics_content.ParseRuleContentDir(path)
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Msg("error parsing rules content directory")
+		return nil, err
+	}
+
+	log.Info().Msgf("Got %d rules from directory %s", len(contentDir.Rules), path)
+	return &contentDir, nil
+}
+
+// GetContentFromSnapshot reads rule content from snapshot file. Files with
+// .json extension are decoded as JSON, all other files as gob.
+func GetContentFromSnapshot(path string) (*types.RuleContentDirectory, error) {
+	log.Debug().Str("path", path).Msg("reading rules static content from snapshot")
+
+	// path is provided by configuration, not by user
+	file, err := os.Open(filepath.Clean(path)) // #nosec G304
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Msg("unable to open rules content snapshot")
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Error().Err(err).Str("path", path).Msg("unable to close rules content snapshot")
+		}
+	}()
+
+	var contentDir types.RuleContentDirectory
+	if strings.EqualFold(filepath.Ext(path), jsonSnapshotExtension) {
+		err = json.NewDecoder(file).Decode(&contentDir)
+	} else {
+		err = gob.NewDecoder(file).Decode(&contentDir)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Msg("error trying to decode rules content snapshot")
+		return nil, err
+	}
+
+	log.Info().Msgf("Got %d rules from snapshot %s", len(contentDir.Rules), path)
+	return &contentDir, nil
+}
+
+// getGroupsFromFile reads groups from the groups configuration file. Groups
+// are sorted by name, so the result does not depend on the order of map
+// iteration.
+func getGroupsFromFile(path string) ([]groups.Group, error) {
+	groupsMap, err := groups.ParseGroupConfigFile(path)
+	if err != nil {
+		log.Error().Err(err).Str("path", path).Msg("error parsing groups configuration file")
+		return nil, err
+	}
+
+	groupsList := make([]groups.Group, 0, len(groupsMap))
+	for _, group := range groupsMap {
+		groupsList = append(groupsList, group)
+	}
+	sort.Slice(groupsList, func(i, j int) bool {
+		return groupsList[i].Name < groupsList[j].Name
+	})
+
+	log.Info().Msgf("Read %d groups from %s", len(groupsList), path)
+	return groupsList, nil
+}
+
+// ContentWatcher watches the local source of rule content and reports its
+// changes
+type ContentWatcher struct {
+	watcher *fsnotify.Watcher
+	path    string
+	changes chan struct{}
+	done    chan struct{}
+}
+
+// NewContentWatcher starts watching the local content source configured in
+// conf. Directory tree is watched recursively, snapshot file is watched via
+// its parent directory, so replacing the file by rename is noticed too.
+func NewContentWatcher(conf Configuration) (*ContentWatcher, error) {
+	if !conf.IsLocalContentSource() {
+		return nil, fmt.Errorf("content source '%s' can not be watched", conf.ContentSource)
+	}
+
+	return newContentWatcher(conf.ContentPath, conf.ContentSource == ContentSourceSnapshot)
+}
+
+// NewFileWatcher starts watching single file, the same way as the content
+// snapshot file is watched
+func NewFileWatcher(path string) (*ContentWatcher, error) {
+	return newContentWatcher(path, true)
+}
+
+// newContentWatcher starts watching given directory tree or single file
+func newContentWatcher(path string, singleFile bool) (*ContentWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	contentWatcher := &ContentWatcher{
+		watcher: watcher,
+		path:    filepath.Clean(path),
+		changes: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	if singleFile {
+		err = watcher.Add(filepath.Dir(contentWatcher.path))
+	} else {
+		err = contentWatcher.addDirectoryTree(contentWatcher.path)
+	}
+	if err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+
+	go contentWatcher.run(singleFile)
+
+	log.Info().Str("path", contentWatcher.path).Msg("watching local file system for changes")
+	return contentWatcher, nil
+}
+
+// Changes returns channel that receives a value when the content changes.
+// Changes that happen before the previous one has been received are merged.
+func (w *ContentWatcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Close stops watching the content
+func (w *ContentWatcher) Close() error {
+	close(w.done)
+	return w.watcher.Close()
+}
+
+// addDirectoryTree adds given directory and all its subdirectories to the
+// watcher, because file system notifications are not recursive
+func (w *ContentWatcher) addDirectoryTree(root string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		return w.watcher.Add(path)
+	})
+}
+
+// run processes file system events until the watcher is closed
+func (w *ContentWatcher) run(snapshot bool) {
+	// debounce timer is stopped until the first event is received
+	debounce := time.NewTimer(contentWatchDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if !w.isRelevant(event, snapshot) {
+				continue
+			}
+			log.Debug().Str("file", event.Name).Str("operation", event.Op.String()).Msg("rules content changed")
+			debounce.Reset(contentWatchDebounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Error().Err(err).Str("path", w.path).Msg("error watching rules content")
+		case <-debounce.C:
+			select {
+			case w.changes <- struct{}{}:
+			default:
+				// previous change has not been processed yet
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// isRelevant checks if the event changes the watched content. New
+// directories in watched tree are added to the watcher.
+func (w *ContentWatcher) isRelevant(event fsnotify.Event, snapshot bool) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+
+	if snapshot {
+		return filepath.Clean(event.Name) == w.path
+	}
+
+	if event.Op&fsnotify.Create != 0 {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := w.addDirectoryTree(event.Name); err != nil {
+				log.Error().Err(err).Str("path", event.Name).Msg("unable to watch new directory")
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+const testGroupsConfig = `
+performance:
+  name: Performance
+  description: High utilization, proactive tuning opportunities.
+  tags:
+    - performance
+security:
+  name: Security
+  description: Issues related to certificates, user management, security groups, specific port usage, etc.
+  tags:
+    - security
+`
+
+func writeTestFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	helpers.FailOnError(t, os.WriteFile(path, data, 0o600))
+}
+
+func TestGetContentFromGobSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "content.gob")
+	writeTestFile(t, path, helpers.MustGobSerialize(t, testdata.RuleContentDirectory3Rules))
+
+	contentDir, err := services.GetContent(services.Configuration{
+		ContentSource: services.ContentSourceSnapshot,
+		ContentPath:   path,
+	})
+	helpers.FailOnError(t, err)
+	assert.Len(t, contentDir.Rules, len(testdata.RuleContentDirectory3Rules.Rules))
+}
+
+func TestGetContentFromJSONSnapshot(t *testing.T) {
+	encoded, err := json.Marshal(testdata.RuleContentDirectory5Rules)
+	helpers.FailOnError(t, err)
+
+	path := filepath.Join(t.TempDir(), "content.json")
+	writeTestFile(t, path, encoded)
+
+	contentDir, err := services.GetContent(services.Configuration{
+		ContentSource: services.ContentSourceSnapshot,
+		ContentPath:   path,
+	})
+	helpers.FailOnError(t, err)
+	assert.Len(t, contentDir.Rules, len(testdata.RuleContentDirectory5Rules.Rules))
+}
+
+func TestGetContentFromInvalidSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "content.gob")
+	writeTestFile(t, path, []byte("this is not gob"))
+
+	_, err := services.GetContent(services.Configuration{
+		ContentSource: services.ContentSourceSnapshot,
+		ContentPath:   path,
+	})
+	assert.Error(t, err)
+
+	_, err = services.GetContent(services.Configuration{
+		ContentSource: services.ContentSourceSnapshot,
+		ContentPath:   filepath.Join(t.TempDir(), "missing.gob"),
+	})
+	assert.Error(t, err)
+}
+
+func TestGetContentFromMissingDirectory(t *testing.T) {
+	_, err := services.GetContent(services.Configuration{
+		ContentSource: services.ContentSourceDirectory,
+		ContentPath:   filepath.Join(t.TempDir(), "missing"),
+	})
+	assert.Error(t, err)
+}
+
+func TestGetContentUnknownSource(t *testing.T) {
+	_, err := services.GetContent(services.Configuration{
+		ContentSource: "ftp",
+	})
+	assert.EqualError(t, err, "unknown content source 'ftp'")
+}
+
+func TestGetGroupsFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "groups_config.yaml")
+	writeTestFile(t, path, []byte(testGroupsConfig))
+
+	retrievedGroups, err := services.GetGroups(services.Configuration{
+		GroupsPath: path,
+	})
+	helpers.FailOnError(t, err)
+
+	// groups are sorted by name
+	assert.Len(t, retrievedGroups, 2)
+	assert.Equal(t, "Performance", retrievedGroups[0].Name)
+	assert.Equal(t, []string{"performance"}, retrievedGroups[0].Tags)
+	assert.Equal(t, "Security", retrievedGroups[1].Name)
+}
+
+func TestContentWatcherSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "content.gob")
+	writeTestFile(t, path, helpers.MustGobSerialize(t, testdata.RuleContentDirectory3Rules))
+
+	watcher, err := services.NewContentWatcher(services.Configuration{
+		ContentSource: services.ContentSourceSnapshot,
+		ContentPath:   path,
+	})
+	helpers.FailOnError(t, err)
+	defer func() {
+		assert.NoError(t, watcher.Close())
+	}()
+
+	// other files in the same directory are ignored
+	writeTestFile(t, filepath.Join(dir, "other.txt"), []byte("other"))
+	select {
+	case <-watcher.Changes():
+		t.Fatal("change of unrelated file reported")
+	case <-time.After(2 * time.Second):
+	}
+
+	writeTestFile(t, path, helpers.MustGobSerialize(t, testdata.RuleContentDirectory5Rules))
+	select {
+	case <-watcher.Changes():
+	case <-time.After(5 * time.Second):
+		t.Fatal("change of snapshot not reported")
+	}
+}
+
+func TestContentWatcherServiceSource(t *testing.T) {
+	_, err := services.NewContentWatcher(services.Configuration{
+		ContentSource: services.ContentSourceService,
+	})
+	assert.Error(t, err)
+}
@@ -18,9 +18,11 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/RedHatInsights/insights-operator-utils/redis"
 	utypes "github.com/RedHatInsights/insights-operator-utils/types"
@@ -39,6 +41,9 @@ const (
 	ProcessedTimestampFieldName = "processed_timestamp"
 	// RuleHitsFieldName represent the name of hte field in Redis hash containing simplified rule hits
 	RuleHitsFieldName = "rule_hits"
+	// RuleHitsTemplateDataFieldName represents the name of the optional field in Redis hash containing
+	// JSON object with template data (extra_data) of rule hits keyed by the composite rule ID
+	RuleHitsTemplateDataFieldName = "rule_hits_template_data"
 	// ScanBatchCount is the number of records to go through in a single SCAN operation
 	ScanBatchCount = 1000
 
@@ -47,12 +52,22 @@ const (
 
 var (
 	// RequestIDsScanPattern is a glob-style pattern to find all matching keys. Uses ?* instead of * to avoid
-	// matching "organization:%v:cluster:%v:request:". [^:reports] is an exclude pattern to not match the
-	// simplified report keys
-	RequestIDsScanPattern = "organization:%v:cluster:%v:request:?*[^:reports]"
+	// matching "organization:%v:cluster:%v:request:". The pattern matches the simplified report keys too,
+	// they are filtered out by requestIDFromKey
+	RequestIDsScanPattern = "organization:%v:cluster:%v:request:?*"
+
+	// AllRequestsScanPattern is a glob-style pattern to find keys of all requests of all clusters
+	AllRequestsScanPattern = "organization:*:cluster:*:request:?*"
+
+	// RequestKey is a key marking existence of request for the cluster
+	RequestKey = "organization:%v:cluster:%v:request:%v"
 
 	// SimplifiedReportKey is a key under which the information about specific requests is stored
 	SimplifiedReportKey = "organization:%v:cluster:%v:request:%v:reports"
+
+	// RequestIDsIndexKey is a key of sorted set of request IDs for the cluster, scored by the received
+	// timestamp (in seconds since the epoch)
+	RequestIDsIndexKey = "organization:%v:cluster:%v:requests"
 )
 
 // RedisInterface represents interface for functions executed against a Redis server
@@ -74,15 +89,42 @@ type RedisInterface interface {
 		types.ClusterName,
 		types.RequestID,
 	) ([]types.RuleID, error)
+	GetRuleHitsWithTemplateDataForRequest(
+		types.OrgID,
+		types.ClusterName,
+		types.RequestID,
+	) ([]types.RuleID, map[types.RuleID]interface{}, error)
+	GetRequestProgress(
+		types.OrgID,
+		types.ClusterName,
+		types.RequestID,
+	) (types.RequestProgress, error)
+	SubscribeRequestEvents(
+		types.OrgID,
+		types.ClusterName,
+		types.RequestID,
+	) (*RequestSubscription, error)
 }
 
 // RedisClient is a local type which embeds the imported redis.Client to include its own functionality
 type RedisClient struct {
 	redis.Client
+	// DisableScanFallback disables listing request IDs by SCAN command
+	// in addition to index of request IDs
+	DisableScanFallback bool
+	// events shares one pub/sub connection among subscriptions of
+	// request events
+	events *requestEventsHub
 }
 
-// NewRedisClient creates a new Redis client based on configuration and returns RedisInterface
+// NewRedisClient creates a new Redis client based on configuration and returns RedisInterface.
+// In-memory implementation is returned when the endpoint starts with MemoryRedisEndpoint.
 func NewRedisClient(conf RedisConfiguration) (RedisInterface, error) {
+	if strings.HasPrefix(conf.RedisEndpoint, MemoryRedisEndpoint) {
+		log.Info().Msg("using in-memory Redis")
+		return newMemoryRedisFromEndpoint(conf.RedisEndpoint)
+	}
+
 	client, err := redis.CreateRedisClient(
 		conf.RedisEndpoint,
 		conf.RedisDatabase,
@@ -93,17 +135,128 @@ func NewRedisClient(conf RedisConfiguration) (RedisInterface, error) {
 		return nil, err
 	}
 
+	newPubSub := func() pubSub {
+		return client.Subscribe(context.Background())
+	}
+
 	return &RedisClient{
-		redis.Client{Connection: client},
+		Client:              redis.Client{Connection: client},
+		DisableScanFallback: conf.RedisDisableScanFallback,
+		events:              newRequestEventsHub(newPubSub, conf.RedisDatabase, conf.RedisEventsChannel),
 	}, nil
 }
 
-// GetRequestIDsForClusterID retrieves a list of request IDs from Redis.
-// "List" of request IDs is in the form of keys with empty values in the following structure:
-// organization:{org_id}:cluster:{cluster_id}:request:{request_id1}.
+// Close closes the connections to Redis server
+func (redis *RedisClient) Close() error {
+	if redis.events != nil {
+		if err := redis.events.close(); err != nil {
+			log.Error().Err(err).Msg("unable to close subscription of request events")
+		}
+	}
+	return redis.Client.Connection.Close()
+}
+
+// GetRequestIDsForClusterID retrieves a list of request IDs from Redis, ordered by the received
+// timestamp. Request IDs are read from the sorted set stored under RequestIDsIndexKey. Request IDs
+// whose request keys have expired are removed from the set. Unless the fallback is disabled, request
+// IDs are found by SCAN command too, because writers not maintaining the set may still store requests
+// of the cluster. "List" of request IDs is then in the form of keys with empty values in the
+// following structure: organization:{org_id}:cluster:{cluster_id}:request:{request_id1}. Request IDs
+// found only by SCAN follow the indexed ones.
 func (redis *RedisClient) GetRequestIDsForClusterID(
 	orgID types.OrgID,
 	clusterID types.ClusterName,
+) (requestIDs []types.RequestID, err error) {
+	requestIDs, found, err := redis.getIndexedRequestIDs(orgID, clusterID)
+	if err != nil || redis.DisableScanFallback {
+		return requestIDs, err
+	}
+
+	scannedRequestIDs, err := redis.scanRequestIDs(orgID, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		log.Debug().Msgf("index of request IDs for cluster_id %v not found, using SCAN", clusterID)
+		return scannedRequestIDs, nil
+	}
+
+	return mergeRequestIDs(requestIDs, scannedRequestIDs), nil
+}
+
+// mergeRequestIDs appends request IDs missing in the indexed ones
+func mergeRequestIDs(indexed, scanned []types.RequestID) []types.RequestID {
+	known := make(map[types.RequestID]struct{}, len(indexed))
+	for _, requestID := range indexed {
+		known[requestID] = struct{}{}
+	}
+
+	for _, requestID := range scanned {
+		// SCAN may return the same key more than once
+		if _, found := known[requestID]; !found {
+			known[requestID] = struct{}{}
+			indexed = append(indexed, requestID)
+		}
+	}
+	return indexed
+}
+
+// getIndexedRequestIDs reads request IDs from the index of the cluster. False is returned when the
+// index doesn't exist.
+func (redis *RedisClient) getIndexedRequestIDs(
+	orgID types.OrgID,
+	clusterID types.ClusterName,
+) (requestIDs []types.RequestID, found bool, err error) {
+	ctx := context.Background()
+	indexKey := fmt.Sprintf(RequestIDsIndexKey, orgID, clusterID)
+
+	members, err := redis.Client.Connection.ZRange(ctx, indexKey, 0, -1).Result()
+	if err != nil {
+		log.Error().Err(err).Msgf("failed to execute ZRANGE command for key '%v'", indexKey)
+		return nil, false, err
+	}
+	// empty sorted sets are deleted by Redis
+	if len(members) == 0 {
+		return nil, false, nil
+	}
+
+	// check that requests have not expired
+	commands, err := redis.Client.Connection.Pipelined(ctx, func(pipe redisV9.Pipeliner) error {
+		for _, member := range members {
+			pipe.Exists(ctx, fmt.Sprintf(RequestKey, orgID, clusterID, member))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Msg(redisCmdExecutionFailedMsg)
+		return nil, false, err
+	}
+
+	var expired []interface{}
+	for i, cmd := range commands {
+		if cmd.(*redisV9.IntCmd).Val() == 0 {
+			expired = append(expired, members[i])
+			continue
+		}
+		requestIDs = append(requestIDs, types.RequestID(members[i]))
+	}
+
+	if len(expired) > 0 {
+		log.Debug().Msgf("removing %d expired request IDs from index of cluster_id %v", len(expired), clusterID)
+		// expired request IDs are filtered out already, so the error is not fatal
+		if err := redis.Client.Connection.ZRem(ctx, indexKey, expired...).Err(); err != nil {
+			log.Error().Err(err).Msgf("failed to execute ZREM command for key '%v'", indexKey)
+		}
+	}
+
+	log.Debug().Msgf("retrieved %d request IDs for cluster_id %v from index: %v", len(requestIDs), clusterID, requestIDs)
+	return requestIDs, true, nil
+}
+
+// scanRequestIDs finds request IDs of the cluster by SCAN command
+func (redis *RedisClient) scanRequestIDs(
+	orgID types.OrgID,
+	clusterID types.ClusterName,
 ) (requestIDs []types.RequestID, err error) {
 	ctx := context.Background()
 
@@ -120,11 +273,10 @@ func (redis *RedisClient) GetRequestIDsForClusterID(
 			return nil, err
 		}
 
-		// get last part of key == request_id
 		for _, key := range keys {
-			keySliced := strings.Split(key, ":")
-			requestID := keySliced[len(keySliced)-1]
-			requestIDs = append(requestIDs, types.RequestID(requestID))
+			if requestID, ok := requestIDFromKey(key); ok {
+				requestIDs = append(requestIDs, requestID)
+			}
 		}
 
 		if cursor == 0 {
@@ -136,6 +288,152 @@ func (redis *RedisClient) GetRequestIDsForClusterID(
 	return
 }
 
+// requestIDFromKey returns the last part of request key == request_id. False is returned for
+// simplified report keys.
+func requestIDFromKey(key string) (types.RequestID, bool) {
+	if strings.HasSuffix(key, ":reports") {
+		return "", false
+	}
+	keySliced := strings.Split(key, ":")
+	return types.RequestID(keySliced[len(keySliced)-1]), true
+}
+
+// BuildRequestIDsIndex adds requests stored in Redis to the indexes of request IDs of their clusters.
+// It's meant to be run when writers start maintaining the indexes, so requests stored before are
+// indexed too. Indexes expire together with the last request added. Number of indexed requests is
+// returned.
+func (redis *RedisClient) BuildRequestIDsIndex() (int, error) {
+	ctx := context.Background()
+
+	// TTL of each index, zero when some request of the cluster never expires
+	indexTTLs := make(map[string]time.Duration)
+	indexed := 0
+
+	var cursor uint64
+	for {
+		keys, nextCursor, err := redis.Client.Connection.Scan(ctx, cursor, AllRequestsScanPattern, ScanBatchCount).Result()
+		if err != nil {
+			log.Error().Err(err).Msgf("failed to execute SCAN command for key '%v' and cursor '%d'", AllRequestsScanPattern, cursor)
+			return indexed, err
+		}
+		cursor = nextCursor
+
+		count, err := redis.indexRequests(ctx, keys, indexTTLs)
+		indexed += count
+		if err != nil {
+			return indexed, err
+		}
+
+		if cursor == 0 {
+			break
+		}
+	}
+
+	_, err := redis.Client.Connection.Pipelined(ctx, func(pipe redisV9.Pipeliner) error {
+		for indexKey, ttl := range indexTTLs {
+			if ttl > 0 {
+				pipe.Expire(ctx, indexKey, ttl)
+			} else {
+				pipe.Persist(ctx, indexKey)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Msg(redisCmdExecutionFailedMsg)
+	}
+	return indexed, err
+}
+
+// indexRequests adds requests with given keys to the indexes. Keys that are
+// not request keys or that belong to requests without simplified report are
+// skipped. TTL of each updated index is updated in indexTTLs.
+func (redis *RedisClient) indexRequests(
+	ctx context.Context, keys []string, indexTTLs map[string]time.Duration,
+) (int, error) {
+	type request struct {
+		key      string
+		indexKey string
+		id       types.RequestID
+	}
+
+	var requests []request
+	for _, key := range keys {
+		// organization:{org_id}:cluster:{cluster_id}:request:{request_id}
+		keySliced := strings.Split(key, ":")
+		if len(keySliced) != 6 || keySliced[0] != "organization" || keySliced[2] != "cluster" || keySliced[4] != "request" {
+			continue
+		}
+		requests = append(requests, request{
+			key:      key,
+			indexKey: fmt.Sprintf(RequestIDsIndexKey, keySliced[1], keySliced[3]),
+			id:       types.RequestID(keySliced[5]),
+		})
+	}
+	if len(requests) == 0 {
+		return 0, nil
+	}
+
+	commands, err := redis.Client.Connection.Pipelined(ctx, func(pipe redisV9.Pipeliner) error {
+		for _, request := range requests {
+			pipe.HMGet(ctx, request.key+":reports", ReceivedTimestampFieldName)
+			pipe.PTTL(ctx, request.key)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Msg(redisCmdExecutionFailedMsg)
+		return 0, err
+	}
+
+	indexed := 0
+	_, err = redis.Client.Connection.Pipelined(ctx, func(pipe redisV9.Pipeliner) error {
+		for i, request := range requests {
+			values := commands[2*i].(*redisV9.SliceCmd).Val()
+			if len(values) == 0 {
+				continue
+			}
+			received, ok := values[0].(string)
+			if !ok {
+				log.Debug().Msgf("request %v has no simplified report, skipping", request.key)
+				continue
+			}
+			receivedAt, err := time.Parse(time.RFC3339, received)
+			if err != nil {
+				log.Error().Err(err).Msgf("invalid received timestamp of request %v", request.key)
+				receivedAt = time.Unix(0, 0)
+			}
+
+			ttl := commands[2*i+1].(*redisV9.DurationCmd).Val()
+			if ttl == -2 {
+				// the request expired in the meantime
+				continue
+			}
+			// the index expires together with the last request, it never
+			// expires when any of the requests doesn't
+			if ttl < 0 {
+				ttl = 0
+			}
+			currentTTL, found := indexTTLs[request.indexKey]
+			if !found || (currentTTL != 0 && (ttl == 0 || ttl > currentTTL)) {
+				indexTTLs[request.indexKey] = ttl
+			}
+
+			pipe.ZAdd(ctx, request.indexKey, redisV9.Z{
+				Score:  float64(receivedAt.Unix()),
+				Member: string(request.id),
+			})
+			indexed++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Msg(redisCmdExecutionFailedMsg)
+		return 0, err
+	}
+	return indexed, nil
+}
+
 // GetTimestampsForRequestIDs retrieves the 'received' and 'processed' timestamps of each Request
 // for given list of Request IDs. It doesn't retrieve the whole Hash, but only the fields we need.
 // It utilizes Redis pipelines in order to avoid multiple client-server round trips.
@@ -230,11 +528,82 @@ func (redis *RedisClient) GetRuleHitsForRequest(
 
 	log.Debug().Msgf("rule hits CSV retrieved from Redis: %v", simplifiedReport.RuleHitsCSV)
 
+	ruleHits = parseRuleHitsCSV(simplifiedReport.RuleHitsCSV)
+	return
+}
+
+// simplifiedReportWithTemplateData represents fields of simplified report
+// hash read when rule hits are requested together with their template data
+type simplifiedReportWithTemplateData struct {
+	RequestID        string `redis:"request_id"`
+	RuleHitsCSV      string `redis:"rule_hits"`
+	TemplateDataJSON string `redis:"rule_hits_template_data"`
+}
+
+// GetRuleHitsWithTemplateDataForRequest is used to get the rule_hits field
+// together with template data of the rule hits from Hash type stored in
+// Redis. Nil template data are returned when they haven't been stored by the
+// writer or when they can't be parsed.
+func (redis *RedisClient) GetRuleHitsWithTemplateDataForRequest(
+	orgID types.OrgID,
+	clusterID types.ClusterName,
+	requestID types.RequestID,
+) (ruleHits []types.RuleID, templateData map[types.RuleID]interface{}, err error) {
+	var simplifiedReport simplifiedReportWithTemplateData
+
+	ctx := context.Background()
+	key := fmt.Sprintf(SimplifiedReportKey, orgID, clusterID, requestID)
+
+	cmd := redis.Client.Connection.HMGet(
+		ctx, key, RequestIDFieldName, RuleHitsFieldName, RuleHitsTemplateDataFieldName,
+	)
+	if err = cmd.Err(); err != nil {
+		log.Error().Err(err).Msg(redisCmdExecutionFailedMsg)
+		return
+	}
+
+	err = cmd.Scan(&simplifiedReport)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to scan result map into a struct")
+		return
+	}
+
+	// report not found in storage
+	if simplifiedReport.RequestID == "" {
+		err = &utypes.ItemNotFoundError{ItemID: requestID}
+		log.Error().Err(err).Msgf("request data for request_id %v not found in Redis", requestID)
+		return
+	}
+
+	ruleHits = parseRuleHitsCSV(simplifiedReport.RuleHitsCSV)
+	templateData = parseRuleHitsTemplateData(requestID, simplifiedReport.TemplateDataJSON)
+	return
+}
+
+// parseRuleHitsTemplateData parses template data of rule hits stored in
+// Redis. Nil is returned when the data are missing or not valid, so the
+// report can be returned without them.
+func parseRuleHitsTemplateData(requestID types.RequestID, templateDataJSON string) map[types.RuleID]interface{} {
+	if templateDataJSON == "" {
+		return nil
+	}
+
+	var templateData map[types.RuleID]interface{}
+	if err := json.Unmarshal([]byte(templateDataJSON), &templateData); err != nil {
+		log.Error().Err(err).Msgf("invalid template data of rule hits for request_id %v", requestID)
+		return nil
+	}
+	return templateData
+}
+
+// parseRuleHitsCSV splits rule hits CSV stored in Redis into rule IDs. Rule
+// IDs in invalid format are skipped.
+func parseRuleHitsCSV(ruleHitsCSV string) (ruleHits []types.RuleID) {
+	ruleIDRegex := regexp.MustCompile(`^([a-zA-Z_0-9.]+)[|]([a-zA-Z_0-9.]+)$`)
+
 	// validate rule IDs coming from Redis
-	ruleHitsSplit := strings.Split(simplifiedReport.RuleHitsCSV, ",")
+	ruleHitsSplit := strings.Split(ruleHitsCSV, ",")
 	for _, ruleHit := range ruleHitsSplit {
-		ruleIDRegex := regexp.MustCompile(`^([a-zA-Z_0-9.]+)[|]([a-zA-Z_0-9.]+)$`)
-
 		isRuleIDValid := ruleIDRegex.MatchString(ruleHit)
 		if !isRuleIDValid {
 			log.Error().Msgf("rule_id [%v] retrieved from Redis is in invalid format", ruleHit)
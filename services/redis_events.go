@@ -0,0 +1,307 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+// Progress of on-demand data gathering requests and notifications about its
+// changes. Notifications are received from Redis keyspace notifications of
+// the request keys (they need to be enabled by notify-keyspace-events option
+// of Redis server) and from the pub/sub channel writers publish keys of
+// changed requests to. Notifications only wake up the reader, the progress
+// itself is always read from the stored request.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	redisV9 "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// keyspaceChannel is the channel of Redis keyspace notifications of given key
+const keyspaceChannel = "__keyspace@%d__:%s"
+
+// RequestSubscription notifies about changes of one request. A value is sent
+// to C whenever the request may have changed, C is closed when the
+// subscription ends.
+type RequestSubscription struct {
+	C     <-chan struct{}
+	close func() error
+}
+
+// Close ends the subscription
+func (s *RequestSubscription) Close() error {
+	return s.close()
+}
+
+// GetRequestProgress reads how far the processing of given request got
+func (redis *RedisClient) GetRequestProgress(
+	orgID types.OrgID,
+	clusterID types.ClusterName,
+	requestID types.RequestID,
+) (progress types.RequestProgress, err error) {
+	ctx := context.Background()
+
+	pipe := redis.Client.Connection.Pipeline()
+	existsCmd := pipe.Exists(ctx, fmt.Sprintf(RequestKey, orgID, clusterID, requestID))
+	reportCmd := pipe.HMGet(
+		ctx, fmt.Sprintf(SimplifiedReportKey, orgID, clusterID, requestID),
+		RequestIDFieldName, ReceivedTimestampFieldName, ProcessedTimestampFieldName, RuleHitsFieldName,
+	)
+
+	if _, err = pipe.Exec(ctx); err != nil {
+		log.Error().Err(err).Msg(redisCmdExecutionFailedMsg)
+		return
+	}
+
+	values := reportCmd.Val()
+	if len(values) != 4 {
+		err = fmt.Errorf("unexpected number of fields of request %v: %d", requestID, len(values))
+		log.Error().Err(err).Msg(redisCmdExecutionFailedMsg)
+		return
+	}
+
+	// fields missing in the hash are returned as nil values
+	field := func(i int) (string, bool) {
+		value, ok := values[i].(string)
+		return value, ok
+	}
+
+	_, reportStored := field(0)
+	progress.ReceivedAt, _ = field(1)
+	progress.ProcessedAt, progress.Processed = field(2)
+	_, ruleHitsStored := field(3)
+
+	progress.ReportReady = reportStored && ruleHitsStored
+	progress.Received = existsCmd.Val() > 0 || reportStored
+	return
+}
+
+// SubscribeRequestEvents subscribes to keyspace notifications of the keys of
+// given request and to the events channel, if it's configured. All
+// subscriptions share one pub/sub connection.
+func (redis *RedisClient) SubscribeRequestEvents(
+	orgID types.OrgID,
+	clusterID types.ClusterName,
+	requestID types.RequestID,
+) (*RequestSubscription, error) {
+	if redis.events == nil {
+		return nil, errors.New("request events are not available")
+	}
+
+	return redis.events.subscribe(
+		fmt.Sprintf(RequestKey, orgID, clusterID, requestID),
+		fmt.Sprintf(SimplifiedReportKey, orgID, clusterID, requestID),
+	)
+}
+
+// pubSub is the part of Redis pub/sub connection used by requestEventsHub
+type pubSub interface {
+	Subscribe(ctx context.Context, channels ...string) error
+	Unsubscribe(ctx context.Context, channels ...string) error
+	Channel(opts ...redisV9.ChannelOption) <-chan *redisV9.Message
+	Close() error
+}
+
+// requestEventsHub shares one pub/sub connection among all subscriptions of
+// request events. The connection is opened by the first subscription, a
+// channel is subscribed while any subscription needs it and notifications
+// are fanned out to subscriptions of the keys they are about.
+type requestEventsHub struct {
+	mutex     sync.Mutex
+	newPubSub func() pubSub
+	pubsub    pubSub
+	// keyspacePrefix is the prefix of keyspace notifications channels
+	keyspacePrefix string
+	eventsChannel  string
+	// channels is the number of subscriptions needing each channel
+	channels map[string]int
+	// waiters are notification channels of subscriptions by keys
+	waiters map[string]map[chan struct{}]struct{}
+	closed  bool
+}
+
+// newRequestEventsHub constructs the hub for keyspace notifications of given
+// database and for given events channel
+func newRequestEventsHub(newPubSub func() pubSub, database int, eventsChannel string) *requestEventsHub {
+	return &requestEventsHub{
+		newPubSub:      newPubSub,
+		keyspacePrefix: fmt.Sprintf(keyspaceChannel, database, ""),
+		eventsChannel:  eventsChannel,
+		channels:       make(map[string]int),
+		waiters:        make(map[string]map[chan struct{}]struct{}),
+	}
+}
+
+// subscribe registers new subscription of changes of given keys
+func (h *requestEventsHub) subscribe(keys ...string) (*RequestSubscription, error) {
+	notifications := make(chan struct{}, 1)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.closed {
+		return nil, errors.New("request events hub is closed")
+	}
+	if h.pubsub == nil {
+		h.pubsub = h.newPubSub()
+		go h.dispatch(h.pubsub.Channel())
+	}
+
+	for _, key := range keys {
+		if h.waiters[key] == nil {
+			h.waiters[key] = make(map[chan struct{}]struct{})
+		}
+		h.waiters[key][notifications] = struct{}{}
+	}
+
+	var newChannels []string
+	for _, channel := range h.channelsOf(keys) {
+		h.channels[channel]++
+		if h.channels[channel] == 1 {
+			newChannels = append(newChannels, channel)
+		}
+	}
+
+	if len(newChannels) > 0 {
+		if err := h.pubsub.Subscribe(context.Background(), newChannels...); err != nil {
+			log.Error().Err(err).Msg("unable to subscribe to request events")
+			h.remove(notifications, keys)
+			return nil, err
+		}
+	}
+
+	var once sync.Once
+	closeSubscription := func() error {
+		once.Do(func() {
+			h.mutex.Lock()
+			defer h.mutex.Unlock()
+			h.remove(notifications, keys)
+		})
+		return nil
+	}
+	return &RequestSubscription{C: notifications, close: closeSubscription}, nil
+}
+
+// channelsOf returns channels notifying about changes of given keys
+func (h *requestEventsHub) channelsOf(keys []string) []string {
+	channels := make([]string, 0, len(keys)+1)
+	for _, key := range keys {
+		channels = append(channels, h.keyspacePrefix+key)
+	}
+	if h.eventsChannel != "" {
+		channels = append(channels, h.eventsChannel)
+	}
+	return channels
+}
+
+// remove unregisters the subscription and unsubscribes channels no other
+// subscription needs. It has to be called with the mutex locked.
+func (h *requestEventsHub) remove(notifications chan struct{}, keys []string) {
+	if _, found := h.waiters[keys[0]][notifications]; !found {
+		// removed already, when the hub has been closed
+		return
+	}
+
+	for _, key := range keys {
+		delete(h.waiters[key], notifications)
+		if len(h.waiters[key]) == 0 {
+			delete(h.waiters, key)
+		}
+	}
+	close(notifications)
+
+	var unusedChannels []string
+	for _, channel := range h.channelsOf(keys) {
+		h.channels[channel]--
+		if h.channels[channel] == 0 {
+			delete(h.channels, channel)
+			unusedChannels = append(unusedChannels, channel)
+		}
+	}
+
+	if len(unusedChannels) > 0 {
+		if err := h.pubsub.Unsubscribe(context.Background(), unusedChannels...); err != nil {
+			log.Error().Err(err).Msg("unable to unsubscribe from request events")
+		}
+	}
+}
+
+// dispatch notifies subscriptions of the keys messages are about. All
+// subscriptions end when the pub/sub connection is closed.
+func (h *requestEventsHub) dispatch(messages <-chan *redisV9.Message) {
+	for message := range messages {
+		var key string
+		switch {
+		// events channel is shared by all requests
+		case message.Channel == h.eventsChannel:
+			key = message.Payload
+		case strings.HasPrefix(message.Channel, h.keyspacePrefix):
+			key = strings.TrimPrefix(message.Channel, h.keyspacePrefix)
+		default:
+			continue
+		}
+
+		h.mutex.Lock()
+		for notifications := range h.waiters[key] {
+			notify(notifications)
+		}
+		h.mutex.Unlock()
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.closed = true
+	ended := make(map[chan struct{}]struct{})
+	for _, waiters := range h.waiters {
+		for notifications := range waiters {
+			ended[notifications] = struct{}{}
+		}
+	}
+	for notifications := range ended {
+		close(notifications)
+	}
+	h.waiters = make(map[string]map[chan struct{}]struct{})
+	h.channels = make(map[string]int)
+}
+
+// close closes the pub/sub connection, if it's open
+func (h *requestEventsHub) close() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+	if h.pubsub == nil {
+		return nil
+	}
+	return h.pubsub.Close()
+}
+
+// notify sends notification to the channel unless there is one pending
+// already
+func notify(notifications chan<- struct{}) {
+	select {
+	case notifications <- struct{}{}:
+	default:
+	}
+}
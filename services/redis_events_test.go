@@ -0,0 +1,260 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	redisV9 "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+func expectRequestProgress(
+	t *testing.T, requestExists int64, reportFields []interface{}, expected types.RequestProgress,
+) {
+	client, server := helpers.GetMockRedis()
+
+	server.ExpectExists(fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID1")).
+		SetVal(requestExists)
+	server.ExpectHMGet(
+		fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName1, "requestID1"),
+		services.RequestIDFieldName, services.ReceivedTimestampFieldName,
+		services.ProcessedTimestampFieldName, services.RuleHitsFieldName,
+	).SetVal(reportFields)
+
+	progress, err := client.GetRequestProgress(testdata.OrgID, testdata.ClusterName1, "requestID1")
+	helpers.FailOnError(t, err)
+	assert.Equal(t, expected, progress)
+
+	helpers.RedisExpectationsMet(t, server)
+}
+
+func TestGetRequestProgress(t *testing.T) {
+	t.Run("not received", func(t *testing.T) {
+		expectRequestProgress(t, 0, []interface{}{nil, nil, nil, nil}, types.RequestProgress{})
+	})
+
+	t.Run("received", func(t *testing.T) {
+		expectRequestProgress(t, 1, []interface{}{nil, nil, nil, nil}, types.RequestProgress{Received: true})
+	})
+
+	t.Run("processed", func(t *testing.T) {
+		expectRequestProgress(t, 1,
+			[]interface{}{"requestID1", "2023-01-02T15:04:05Z", "2023-01-02T15:05:05Z", nil},
+			types.RequestProgress{
+				Received:    true,
+				Processed:   true,
+				ReceivedAt:  "2023-01-02T15:04:05Z",
+				ProcessedAt: "2023-01-02T15:05:05Z",
+			},
+		)
+	})
+
+	// the request key can expire before the report
+	t.Run("report ready without rule hits", func(t *testing.T) {
+		expectRequestProgress(t, 0,
+			[]interface{}{"requestID1", "2023-01-02T15:04:05Z", "2023-01-02T15:05:05Z", ""},
+			types.RequestProgress{
+				Received:    true,
+				Processed:   true,
+				ReportReady: true,
+				ReceivedAt:  "2023-01-02T15:04:05Z",
+				ProcessedAt: "2023-01-02T15:05:05Z",
+			},
+		)
+	})
+}
+
+func TestGetRequestProgressError(t *testing.T) {
+	client, server := helpers.GetMockRedis()
+
+	server.ExpectExists(fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID1")).
+		SetErr(errTest)
+
+	_, err := client.GetRequestProgress(testdata.OrgID, testdata.ClusterName1, "requestID1")
+	assert.Error(t, err)
+}
+
+func TestMemoryRedisRequestEvents(t *testing.T) {
+	memoryRedis := services.NewMemoryRedis()
+
+	subscription, err := memoryRedis.SubscribeRequestEvents(testdata.OrgID, testdata.ClusterName1, "requestID1")
+	helpers.FailOnError(t, err)
+
+	progress, err := memoryRedis.GetRequestProgress(testdata.OrgID, testdata.ClusterName1, "requestID1")
+	helpers.FailOnError(t, err)
+	assert.Equal(t, types.RequestProgress{}, progress)
+
+	// keys of other requests don't notify the subscription
+	memoryRedis.AddRequest(memoryRequest(testdata.ClusterName2, "requestID1"), 0)
+	assert.Len(t, subscription.C, 0)
+
+	memoryRedis.Set(fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID1"), nil, 0)
+	<-subscription.C
+
+	progress, err = memoryRedis.GetRequestProgress(testdata.OrgID, testdata.ClusterName1, "requestID1")
+	helpers.FailOnError(t, err)
+	assert.Equal(t, types.RequestProgress{Received: true}, progress)
+
+	memoryRedis.AddRequest(memoryRequest(testdata.ClusterName1, "requestID1"), 0)
+	<-subscription.C
+
+	progress, err = memoryRedis.GetRequestProgress(testdata.OrgID, testdata.ClusterName1, "requestID1")
+	helpers.FailOnError(t, err)
+	assert.Equal(t, types.RequestProgress{
+		Received:    true,
+		Processed:   true,
+		ReportReady: true,
+		ReceivedAt:  "2023-01-02T15:04:05Z",
+		ProcessedAt: "2023-01-02T15:05:05Z",
+	}, progress)
+
+	helpers.FailOnError(t, subscription.Close())
+	_, open := <-subscription.C
+	assert.False(t, open)
+	helpers.FailOnError(t, subscription.Close())
+}
+
+// fakePubSub records channels subscribed by request events subscriptions
+type fakePubSub struct {
+	mutex    sync.Mutex
+	channels map[string]struct{}
+	messages chan *redisV9.Message
+}
+
+func newFakePubSub() *fakePubSub {
+	return &fakePubSub{
+		channels: make(map[string]struct{}),
+		messages: make(chan *redisV9.Message),
+	}
+}
+
+func (p *fakePubSub) Subscribe(_ context.Context, channels ...string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, channel := range channels {
+		if _, found := p.channels[channel]; found {
+			return fmt.Errorf("channel %v subscribed already", channel)
+		}
+		p.channels[channel] = struct{}{}
+	}
+	return nil
+}
+
+func (p *fakePubSub) Unsubscribe(_ context.Context, channels ...string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, channel := range channels {
+		delete(p.channels, channel)
+	}
+	return nil
+}
+
+func (p *fakePubSub) Channel(...redisV9.ChannelOption) <-chan *redisV9.Message {
+	return p.messages
+}
+
+func (p *fakePubSub) Close() error {
+	close(p.messages)
+	return nil
+}
+
+// subscribed returns sorted subscribed channels
+func (p *fakePubSub) subscribed() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	channels := make([]string, 0, len(p.channels))
+	for channel := range p.channels {
+		channels = append(channels, channel)
+	}
+	sort.Strings(channels)
+	return channels
+}
+
+func TestRedisRequestEvents(t *testing.T) {
+	pubsub := newFakePubSub()
+	client := services.NewRedisClientWithPubSub(pubsub, 2, "requests")
+
+	requestKey := fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID1")
+	reportKey := fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName1, "requestID1")
+	otherReportKey := fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName2, "requestID1")
+
+	// channels are subscribed once for all subscriptions of the request
+	first, err := client.SubscribeRequestEvents(testdata.OrgID, testdata.ClusterName1, "requestID1")
+	helpers.FailOnError(t, err)
+	second, err := client.SubscribeRequestEvents(testdata.OrgID, testdata.ClusterName1, "requestID1")
+	helpers.FailOnError(t, err)
+	other, err := client.SubscribeRequestEvents(testdata.OrgID, testdata.ClusterName2, "requestID1")
+	helpers.FailOnError(t, err)
+	assert.Len(t, pubsub.subscribed(), 5)
+	assert.Contains(t, pubsub.subscribed(), "__keyspace@2__:"+requestKey)
+	assert.Contains(t, pubsub.subscribed(), "requests")
+
+	// keyspace notifications are sent to subscriptions of the key only
+	pubsub.messages <- &redisV9.Message{Channel: "__keyspace@2__:" + requestKey, Payload: "set"}
+	<-first.C
+	<-second.C
+
+	// events channel is shared by all requests, payload is the changed key
+	pubsub.messages <- &redisV9.Message{Channel: "requests", Payload: otherReportKey}
+	<-other.C
+	assert.Len(t, first.C, 0)
+	assert.Len(t, second.C, 0)
+
+	pubsub.messages <- &redisV9.Message{Channel: "requests", Payload: reportKey}
+	<-first.C
+	<-second.C
+	assert.Len(t, other.C, 0)
+
+	// channels are unsubscribed when the last subscription of the request
+	// is closed
+	helpers.FailOnError(t, first.Close())
+	assert.Len(t, pubsub.subscribed(), 5)
+	helpers.FailOnError(t, second.Close())
+	helpers.FailOnError(t, second.Close())
+	assert.Equal(t, []string{
+		fmt.Sprintf("__keyspace@2__:"+services.RequestKey, testdata.OrgID, testdata.ClusterName2, "requestID1"),
+		"__keyspace@2__:" + otherReportKey,
+		"requests",
+	}, pubsub.subscribed())
+
+	// subscriptions end when the connection is closed
+	helpers.FailOnError(t, services.CloseRequestEvents(client))
+	_, open := <-other.C
+	assert.False(t, open)
+	helpers.FailOnError(t, other.Close())
+
+	_, err = client.SubscribeRequestEvents(testdata.OrgID, testdata.ClusterName1, "requestID1")
+	assert.Error(t, err)
+}
+
+func TestRedisRequestEventsNotAvailable(t *testing.T) {
+	client, _ := helpers.GetMockRedis()
+
+	_, err := client.SubscribeRequestEvents(testdata.OrgID, testdata.ClusterName1, "requestID1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,388 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+// In-memory implementation of RedisInterface. It's meant for local runs and
+// tests, so the on-demand data gathering endpoints can be used without Redis
+// server. Data are stored under the same keys and with the same fields as in
+// Redis and keys expire the same way as keys with TTL do.
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+// MemoryRedisEndpoint is the Redis endpoint selecting the in-memory
+// implementation. It can be followed by path to JSON file with requests the
+// storage is seeded with, for example memory:///tmp/requests.json.
+const MemoryRedisEndpoint = "memory://"
+
+// MemoryRequest represents one request stored in MemoryRedis
+type MemoryRequest struct {
+	OrgID     types.OrgID       `json:"org_id"`
+	ClusterID types.ClusterName `json:"cluster_id"`
+	RequestID types.RequestID   `json:"request_id"`
+	Received  time.Time         `json:"received"`
+	Processed time.Time         `json:"processed"`
+	RuleHits  []types.RuleID    `json:"rule_hits"`
+	// TemplateData contains template data of rule hits keyed by the
+	// composite rule ID, they are not stored when nil
+	TemplateData map[types.RuleID]interface{} `json:"template_data,omitempty"`
+}
+
+// memoryRedisEntry is a value stored under one key, either hash fields or
+// sorted set members with their scores. Zero expiresAt means that the key
+// never expires.
+type memoryRedisEntry struct {
+	fields    map[string]string
+	scores    map[string]float64
+	expiresAt time.Time
+}
+
+// MemoryRedis is thread safe in-memory implementation of RedisInterface
+type MemoryRedis struct {
+	mutex   sync.RWMutex
+	entries map[string]memoryRedisEntry
+	now     func() time.Time
+	// listeners are notification channels of request subscriptions with
+	// prefix of keys they are interested in
+	listeners map[chan struct{}]string
+}
+
+// NewMemoryRedis constructs empty in-memory storage
+func NewMemoryRedis() *MemoryRedis {
+	return &MemoryRedis{
+		entries:   make(map[string]memoryRedisEntry),
+		now:       time.Now,
+		listeners: make(map[chan struct{}]string),
+	}
+}
+
+// newMemoryRedisFromEndpoint constructs in-memory storage and seeds it with
+// requests from the file given in endpoint, if any
+func newMemoryRedisFromEndpoint(endpoint string) (*MemoryRedis, error) {
+	memoryRedis := NewMemoryRedis()
+
+	path := strings.TrimPrefix(endpoint, MemoryRedisEndpoint)
+	if path == "" {
+		return memoryRedis, nil
+	}
+
+	// path is provided by configuration, not by user
+	data, err := os.ReadFile(filepath.Clean(path)) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+
+	var requests []MemoryRequest
+	if err := json.Unmarshal(data, &requests); err != nil {
+		return nil, fmt.Errorf("invalid Redis seed file %s: %v", path, err)
+	}
+
+	for _, request := range requests {
+		memoryRedis.AddRequest(request, 0)
+	}
+	log.Info().Int("requests", len(requests)).Msg("in-memory Redis seeded")
+	return memoryRedis, nil
+}
+
+// HealthCheck always succeeds, the storage is always available
+func (m *MemoryRedis) HealthCheck() error {
+	return nil
+}
+
+// Set stores fields under given key. The key expires after ttl, zero ttl
+// means that the key never expires.
+func (m *MemoryRedis) Set(key string, fields map[string]string, ttl time.Duration) {
+	entry := memoryRedisEntry{fields: make(map[string]string, len(fields))}
+	for name, value := range fields {
+		entry.fields[name] = value
+	}
+	if ttl > 0 {
+		entry.expiresAt = m.now().Add(ttl)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.deleteExpired()
+	m.entries[key] = entry
+
+	for notifications, prefix := range m.listeners {
+		if strings.HasPrefix(key, prefix) {
+			notify(notifications)
+		}
+	}
+}
+
+// fields returns fields of simplified report hash of the request
+func (m *MemoryRequest) fields() map[string]string {
+	ruleHits := make([]string, len(m.RuleHits))
+	for i, ruleHit := range m.RuleHits {
+		ruleHits[i] = string(ruleHit)
+	}
+
+	fields := map[string]string{
+		RequestIDFieldName:          string(m.RequestID),
+		ReceivedTimestampFieldName:  m.Received.UTC().Format(time.RFC3339),
+		ProcessedTimestampFieldName: m.Processed.UTC().Format(time.RFC3339),
+		RuleHitsFieldName:           strings.Join(ruleHits, ","),
+	}
+
+	if m.TemplateData != nil {
+		templateData, err := json.Marshal(m.TemplateData)
+		if err != nil {
+			log.Error().Err(err).Msgf("unable to store template data of request %v", m.RequestID)
+		} else {
+			fields[RuleHitsTemplateDataFieldName] = string(templateData)
+		}
+	}
+	return fields
+}
+
+// AddRequest stores the request the same way as it's stored in Redis, under
+// request key and simplified report key, and adds it to the index of request
+// IDs of the cluster. Both keys expire after ttl, zero ttl means that they
+// never expire. The index expires together with the last request.
+func (m *MemoryRedis) AddRequest(request MemoryRequest, ttl time.Duration) {
+	m.Set(fmt.Sprintf(RequestKey, request.OrgID, request.ClusterID, request.RequestID), nil, ttl)
+	m.Set(fmt.Sprintf(SimplifiedReportKey, request.OrgID, request.ClusterID, request.RequestID), request.fields(), ttl)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	indexKey := fmt.Sprintf(RequestIDsIndexKey, request.OrgID, request.ClusterID)
+	index, found := m.entries[indexKey]
+	if !found {
+		index = memoryRedisEntry{scores: make(map[string]float64)}
+		if ttl > 0 {
+			index.expiresAt = m.now().Add(ttl)
+		}
+	}
+	index.scores[string(request.RequestID)] = float64(request.Received.Unix())
+	if ttl == 0 {
+		index.expiresAt = time.Time{}
+	} else if expiresAt := m.now().Add(ttl); !index.expiresAt.IsZero() && expiresAt.After(index.expiresAt) {
+		index.expiresAt = expiresAt
+	}
+	m.entries[indexKey] = index
+}
+
+// Flush removes all keys
+func (m *MemoryRedis) Flush() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.entries = make(map[string]memoryRedisEntry)
+}
+
+// get returns fields stored under the key. False is returned when the key
+// doesn't exist or it has expired.
+func (m *MemoryRedis) get(key string) (map[string]string, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	entry, found := m.entries[key]
+	if !found || m.expired(entry) {
+		return nil, false
+	}
+	return entry.fields, true
+}
+
+// expired checks if the entry has expired. Expired entries are not visible
+// and they are deleted when a key is set.
+func (m *MemoryRedis) expired(entry memoryRedisEntry) bool {
+	return !entry.expiresAt.IsZero() && !m.now().Before(entry.expiresAt)
+}
+
+// deleteExpired deletes expired entries, the mutex has to be locked by the
+// caller
+func (m *MemoryRedis) deleteExpired() {
+	for key, entry := range m.entries {
+		if m.expired(entry) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+// GetRequestIDsForClusterID returns IDs of requests stored for the cluster.
+// Request IDs are read from the index of the cluster and ordered by the
+// received timestamp. Request keys are searched too, sorted request IDs
+// missing in the index follow the indexed ones.
+func (m *MemoryRedis) GetRequestIDsForClusterID(
+	orgID types.OrgID,
+	clusterID types.ClusterName,
+) (requestIDs []types.RequestID, err error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	index, found := m.entries[fmt.Sprintf(RequestIDsIndexKey, orgID, clusterID)]
+	if found && !m.expired(index) {
+		for member := range index.scores {
+			entry, found := m.entries[fmt.Sprintf(RequestKey, orgID, clusterID, member)]
+			if found && !m.expired(entry) {
+				requestIDs = append(requestIDs, types.RequestID(member))
+			}
+		}
+
+		sort.Slice(requestIDs, func(i, j int) bool {
+			scoreI, scoreJ := index.scores[string(requestIDs[i])], index.scores[string(requestIDs[j])]
+			if scoreI != scoreJ {
+				return scoreI < scoreJ
+			}
+			return requestIDs[i] < requestIDs[j]
+		})
+	}
+
+	var scannedRequestIDs []types.RequestID
+	prefix := fmt.Sprintf(RequestKey, orgID, clusterID, "")
+	for key, entry := range m.entries {
+		if !strings.HasPrefix(key, prefix) || m.expired(entry) {
+			continue
+		}
+
+		requestID, ok := requestIDFromKey(key)
+		if ok && requestID != "" {
+			scannedRequestIDs = append(scannedRequestIDs, requestID)
+		}
+	}
+
+	sort.Slice(scannedRequestIDs, func(i, j int) bool { return scannedRequestIDs[i] < scannedRequestIDs[j] })
+	return mergeRequestIDs(requestIDs, scannedRequestIDs), nil
+}
+
+// GetTimestampsForRequestIDs returns the 'received' and 'processed'
+// timestamps of given requests. Missing requests are omitted or returned as
+// not valid, the same way as by RedisClient.
+func (m *MemoryRedis) GetTimestampsForRequestIDs(
+	orgID types.OrgID,
+	clusterID types.ClusterName,
+	requestIDs []types.RequestID,
+	omitMissing bool,
+) (requestStatuses []types.RequestStatus, err error) {
+	for _, requestID := range requestIDs {
+		fields, found := m.get(fmt.Sprintf(SimplifiedReportKey, orgID, clusterID, requestID))
+		if !found || fields[RequestIDFieldName] == "" {
+			if omitMissing {
+				continue
+			}
+			requestStatuses = append(requestStatuses, types.RequestStatus{
+				RequestID: string(requestID),
+				Valid:     false,
+			})
+			continue
+		}
+
+		requestStatuses = append(requestStatuses, types.RequestStatus{
+			RequestID: fields[RequestIDFieldName],
+			Valid:     true,
+			Received:  fields[ReceivedTimestampFieldName],
+			Processed: fields[ProcessedTimestampFieldName],
+		})
+	}
+
+	return
+}
+
+// GetRuleHitsForRequest returns rule hits of given request
+func (m *MemoryRedis) GetRuleHitsForRequest(
+	orgID types.OrgID,
+	clusterID types.ClusterName,
+	requestID types.RequestID,
+) (ruleHits []types.RuleID, err error) {
+	fields, found := m.get(fmt.Sprintf(SimplifiedReportKey, orgID, clusterID, requestID))
+	if !found || fields[RequestIDFieldName] == "" {
+		err = &utypes.ItemNotFoundError{ItemID: requestID}
+		log.Error().Err(err).Msgf("request data for request_id %v not found in memory", requestID)
+		return
+	}
+
+	return parseRuleHitsCSV(fields[RuleHitsFieldName]), nil
+}
+
+// GetRuleHitsWithTemplateDataForRequest returns rule hits of given request
+// together with their template data, if they have been stored
+func (m *MemoryRedis) GetRuleHitsWithTemplateDataForRequest(
+	orgID types.OrgID,
+	clusterID types.ClusterName,
+	requestID types.RequestID,
+) (ruleHits []types.RuleID, templateData map[types.RuleID]interface{}, err error) {
+	fields, found := m.get(fmt.Sprintf(SimplifiedReportKey, orgID, clusterID, requestID))
+	if !found || fields[RequestIDFieldName] == "" {
+		err = &utypes.ItemNotFoundError{ItemID: requestID}
+		log.Error().Err(err).Msgf("request data for request_id %v not found in memory", requestID)
+		return
+	}
+
+	ruleHits = parseRuleHitsCSV(fields[RuleHitsFieldName])
+	templateData = parseRuleHitsTemplateData(requestID, fields[RuleHitsTemplateDataFieldName])
+	return
+}
+
+// GetRequestProgress reads how far the processing of given request got
+func (m *MemoryRedis) GetRequestProgress(
+	orgID types.OrgID,
+	clusterID types.ClusterName,
+	requestID types.RequestID,
+) (progress types.RequestProgress, err error) {
+	_, requestStored := m.get(fmt.Sprintf(RequestKey, orgID, clusterID, requestID))
+	fields, _ := m.get(fmt.Sprintf(SimplifiedReportKey, orgID, clusterID, requestID))
+
+	_, reportStored := fields[RequestIDFieldName]
+	_, ruleHitsStored := fields[RuleHitsFieldName]
+
+	progress.ReceivedAt = fields[ReceivedTimestampFieldName]
+	progress.ProcessedAt, progress.Processed = fields[ProcessedTimestampFieldName]
+	progress.ReportReady = reportStored && ruleHitsStored
+	progress.Received = requestStored || reportStored
+	return
+}
+
+// SubscribeRequestEvents subscribes to changes of keys of given request
+func (m *MemoryRedis) SubscribeRequestEvents(
+	orgID types.OrgID,
+	clusterID types.ClusterName,
+	requestID types.RequestID,
+) (*RequestSubscription, error) {
+	notifications := make(chan struct{}, 1)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.listeners[notifications] = fmt.Sprintf(RequestKey, orgID, clusterID, requestID)
+
+	closeSubscription := func() error {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		if _, found := m.listeners[notifications]; found {
+			delete(m.listeners, notifications)
+			close(notifications)
+		}
+		return nil
+	}
+	return &RequestSubscription{C: notifications, close: closeSubscription}, nil
+}
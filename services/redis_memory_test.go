@@ -0,0 +1,205 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
+	data "github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+var (
+	memoryReceived  = time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)
+	memoryProcessed = memoryReceived.Add(time.Minute)
+)
+
+func memoryRequest(clusterID types.ClusterName, requestID types.RequestID) services.MemoryRequest {
+	return services.MemoryRequest{
+		OrgID:     testdata.OrgID,
+		ClusterID: clusterID,
+		RequestID: requestID,
+		Received:  memoryReceived,
+		Processed: memoryProcessed,
+		RuleHits:  []types.RuleID{data.Rule1CompositeID, data.Rule2CompositeID},
+	}
+}
+
+func TestMemoryRedisRequests(t *testing.T) {
+	memoryRedis := services.NewMemoryRedis()
+	helpers.FailOnError(t, memoryRedis.HealthCheck())
+
+	memoryRedis.AddRequest(memoryRequest(testdata.ClusterName1, "requestID2"), 0)
+	memoryRedis.AddRequest(memoryRequest(testdata.ClusterName1, "requestID1"), 0)
+	memoryRedis.AddRequest(memoryRequest(testdata.ClusterName2, "requestID3"), 0)
+
+	requestIDs, err := memoryRedis.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, []types.RequestID{"requestID1", "requestID2"}, requestIDs)
+
+	requestIDs, err = memoryRedis.GetRequestIDsForClusterID(testdata.OrgID+1, testdata.ClusterName1)
+	helpers.FailOnError(t, err)
+	assert.Empty(t, requestIDs)
+
+	statuses, err := memoryRedis.GetTimestampsForRequestIDs(
+		testdata.OrgID, testdata.ClusterName1, []types.RequestID{"requestID1", "requestID3"}, false,
+	)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, []types.RequestStatus{
+		{
+			RequestID: "requestID1",
+			Valid:     true,
+			Received:  memoryReceived.Format(time.RFC3339),
+			Processed: memoryProcessed.Format(time.RFC3339),
+		},
+		{RequestID: "requestID3", Valid: false},
+	}, statuses)
+
+	statuses, err = memoryRedis.GetTimestampsForRequestIDs(
+		testdata.OrgID, testdata.ClusterName1, []types.RequestID{"requestID3"}, true,
+	)
+	helpers.FailOnError(t, err)
+	assert.Empty(t, statuses)
+
+	ruleHits, err := memoryRedis.GetRuleHitsForRequest(testdata.OrgID, testdata.ClusterName1, "requestID2")
+	helpers.FailOnError(t, err)
+	assert.Equal(t, []types.RuleID{data.Rule1CompositeID, data.Rule2CompositeID}, ruleHits)
+
+	_, err = memoryRedis.GetRuleHitsForRequest(testdata.OrgID, testdata.ClusterName1, "requestID3")
+	assert.IsType(t, &utypes.ItemNotFoundError{}, err)
+
+	ruleHits, templateData, err := memoryRedis.GetRuleHitsWithTemplateDataForRequest(
+		testdata.OrgID, testdata.ClusterName1, "requestID2",
+	)
+	helpers.FailOnError(t, err)
+	assert.Len(t, ruleHits, 2)
+	assert.Nil(t, templateData)
+
+	request := memoryRequest(testdata.ClusterName2, "requestID4")
+	request.TemplateData = map[types.RuleID]interface{}{data.Rule1CompositeID: map[string]interface{}{"key": "value"}}
+	memoryRedis.AddRequest(request, 0)
+	_, templateData, err = memoryRedis.GetRuleHitsWithTemplateDataForRequest(
+		testdata.OrgID, testdata.ClusterName2, "requestID4",
+	)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, request.TemplateData, templateData)
+
+	_, _, err = memoryRedis.GetRuleHitsWithTemplateDataForRequest(testdata.OrgID, testdata.ClusterName1, "requestID3")
+	assert.IsType(t, &utypes.ItemNotFoundError{}, err)
+
+	memoryRedis.Flush()
+	requestIDs, err = memoryRedis.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	helpers.FailOnError(t, err)
+	assert.Empty(t, requestIDs)
+}
+
+func TestMemoryRedisExpiration(t *testing.T) {
+	now := memoryProcessed
+	memoryRedis := services.NewMemoryRedis()
+	services.SetMemoryRedisClock(memoryRedis, func() time.Time { return now })
+
+	memoryRedis.AddRequest(memoryRequest(testdata.ClusterName1, "requestID1"), time.Hour)
+	memoryRedis.AddRequest(memoryRequest(testdata.ClusterName1, "requestID2"), 2*time.Hour)
+
+	now = now.Add(time.Hour)
+
+	requestIDs, err := memoryRedis.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, []types.RequestID{"requestID2"}, requestIDs)
+
+	_, err = memoryRedis.GetRuleHitsForRequest(testdata.OrgID, testdata.ClusterName1, "requestID1")
+	assert.IsType(t, &utypes.ItemNotFoundError{}, err)
+
+	statuses, err := memoryRedis.GetTimestampsForRequestIDs(
+		testdata.OrgID, testdata.ClusterName1, []types.RequestID{"requestID1", "requestID2"}, true,
+	)
+	helpers.FailOnError(t, err)
+	assert.Len(t, statuses, 1)
+}
+
+func TestNewRedisClientMemory(t *testing.T) {
+	conf := helpers.DefaultRedisConf
+	conf.RedisEndpoint = services.MemoryRedisEndpoint
+	client, err := services.NewRedisClient(conf)
+	helpers.FailOnError(t, err)
+	assert.IsType(t, &services.MemoryRedis{}, client)
+
+	seed, err := json.Marshal([]services.MemoryRequest{memoryRequest(testdata.ClusterName1, "requestID1")})
+	helpers.FailOnError(t, err)
+	path := filepath.Join(t.TempDir(), "requests.json")
+	writeTestFile(t, path, seed)
+
+	conf.RedisEndpoint = services.MemoryRedisEndpoint + path
+	client, err = services.NewRedisClient(conf)
+	helpers.FailOnError(t, err)
+
+	requestIDs, err := client.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, []types.RequestID{"requestID1"}, requestIDs)
+
+	writeTestFile(t, path, []byte("not a JSON"))
+	_, err = services.NewRedisClient(conf)
+	assert.Error(t, err)
+
+	conf.RedisEndpoint = services.MemoryRedisEndpoint + filepath.Join(t.TempDir(), "missing.json")
+	_, err = services.NewRedisClient(conf)
+	assert.Error(t, err)
+}
+
+func TestMemoryRedisRequestIDsIndex(t *testing.T) {
+	now := memoryProcessed
+	memoryRedis := services.NewMemoryRedis()
+	services.SetMemoryRedisClock(memoryRedis, func() time.Time { return now })
+
+	// request IDs are ordered by the received timestamp
+	request := memoryRequest(testdata.ClusterName1, "requestID1")
+	request.Received = memoryReceived.Add(time.Second)
+	memoryRedis.AddRequest(request, time.Hour)
+	memoryRedis.AddRequest(memoryRequest(testdata.ClusterName1, "requestID2"), 2*time.Hour)
+
+	requestIDs, err := memoryRedis.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, []types.RequestID{"requestID2", "requestID1"}, requestIDs)
+
+	// expired requests are omitted from the index
+	now = now.Add(time.Hour)
+	requestIDs, err = memoryRedis.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, []types.RequestID{"requestID2"}, requestIDs)
+
+	// the index expires together with the last request, request keys
+	// stored without the index are found then
+	now = now.Add(time.Hour)
+	memoryRedis.Set(fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID3"), nil, 0)
+	requestIDs, err = memoryRedis.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, []types.RequestID{"requestID3"}, requestIDs)
+
+	// request keys missing in the index follow the indexed requests
+	memoryRedis.AddRequest(memoryRequest(testdata.ClusterName1, "requestID4"), time.Hour)
+	requestIDs, err = memoryRedis.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, []types.RequestID{"requestID4", "requestID3"}, requestIDs)
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
 	"github.com/RedHatInsights/insights-results-smart-proxy/tests/testdata"
 	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+	redisV9 "github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -66,6 +67,7 @@ func TestRedisGetRequestIDsForClusterID_Empty(t *testing.T) {
 	client, server := helpers.GetMockRedis()
 
 	expectedKey := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName1)
+	helpers.ExpectRequestIDsIndexMissing(server, testdata.OrgID, testdata.ClusterName1)
 	server.ExpectScan(0, expectedKey, services.ScanBatchCount).SetVal([]string{}, 0)
 
 	requestIDs, err := client.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
@@ -85,6 +87,7 @@ func TestRedisGetRequestIDsForClusterID_ResultsSinglePage(t *testing.T) {
 		expectedResponseKeys[i] = fmt.Sprintf("organization:%v:cluster:%v:request:requestID%v", testdata.OrgID, testdata.ClusterName1, i)
 	}
 	// all results are in a single page -- cursor == 0, so no more calls are expected
+	helpers.ExpectRequestIDsIndexMissing(server, testdata.OrgID, testdata.ClusterName1)
 	server.ExpectScan(0, expectedKey, services.ScanBatchCount).SetVal(expectedResponseKeys, 0)
 
 	requestIDs, err := client.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
@@ -104,6 +107,7 @@ func TestRedisGetRequestIDsForClusterID_ResultsMultiplePages(t *testing.T) {
 	}
 
 	expectedKey := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName1)
+	helpers.ExpectRequestIDsIndexMissing(server, testdata.OrgID, testdata.ClusterName1)
 	server.ExpectScan(0, expectedKey, services.ScanBatchCount).SetVal([]string{expectedResponseKeys[0], expectedResponseKeys[1]}, 42)
 	// returned cursor is expected to be used in the next call
 	server.ExpectScan(42, expectedKey, services.ScanBatchCount).SetVal([]string{expectedResponseKeys[2]}, 8)
@@ -123,6 +127,7 @@ func TestRedisGetRequestIDsForClusterID_Error(t *testing.T) {
 	client, server := helpers.GetMockRedis()
 
 	expectedKey := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName1)
+	helpers.ExpectRequestIDsIndexMissing(server, testdata.OrgID, testdata.ClusterName1)
 	server.ExpectScan(0, expectedKey, services.ScanBatchCount).SetErr(errTest)
 
 	requestIDs, err := client.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
@@ -142,6 +147,7 @@ func TestRedisGetRequestIDsForClusterID_ErrorInFollowingCalls(t *testing.T) {
 		expectedResponseKeys[i] = fmt.Sprintf("organization:%v:cluster:%v:request:requestID%v", testdata.OrgID, testdata.ClusterName1, i)
 	}
 
+	helpers.ExpectRequestIDsIndexMissing(server, testdata.OrgID, testdata.ClusterName1)
 	server.ExpectScan(0, expectedKey, services.ScanBatchCount).SetVal([]string{expectedResponseKeys[0], expectedResponseKeys[1]}, 42)
 	server.ExpectScan(42, expectedKey, services.ScanBatchCount).SetErr(errTest)
 
@@ -153,6 +159,143 @@ func TestRedisGetRequestIDsForClusterID_ErrorInFollowingCalls(t *testing.T) {
 	helpers.RedisExpectationsMet(t, server)
 }
 
+func TestRedisGetRequestIDsForClusterID_ScanSkipsReports(t *testing.T) {
+	client, server := helpers.GetMockRedis()
+
+	expectedKey := fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName1)
+	helpers.ExpectRequestIDsIndexMissing(server, testdata.OrgID, testdata.ClusterName1)
+	server.ExpectScan(0, expectedKey, services.ScanBatchCount).SetVal([]string{
+		fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requests"),
+		fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName1, "requests"),
+	}, 0)
+
+	requestIDs, err := client.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RequestID{"requests"}, requestIDs)
+
+	helpers.RedisExpectationsMet(t, server)
+}
+
+func TestRedisGetRequestIDsForClusterID_Index(t *testing.T) {
+	client, server := helpers.GetMockRedis()
+
+	indexKey := fmt.Sprintf(services.RequestIDsIndexKey, testdata.OrgID, testdata.ClusterName1)
+	server.ExpectZRange(indexKey, 0, -1).SetVal([]string{"requestID1", "requestID2", "requestID3"})
+	server.ExpectExists(fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID1")).SetVal(1)
+	server.ExpectExists(fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID2")).SetVal(0)
+	server.ExpectExists(fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID3")).SetVal(1)
+	// expired request is removed from the index
+	server.ExpectZRem(indexKey, "requestID2").SetVal(1)
+	// request stored by writer not maintaining the index is found by SCAN
+	server.ExpectScan(0, fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName1),
+		services.ScanBatchCount).SetVal([]string{
+		fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID4"),
+		fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID3"),
+		fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID1"),
+		fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID4"),
+	}, 0)
+
+	requestIDs, err := client.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RequestID{"requestID1", "requestID3", "requestID4"}, requestIDs)
+
+	helpers.RedisExpectationsMet(t, server)
+}
+
+func TestRedisGetRequestIDsForClusterID_IndexScanError(t *testing.T) {
+	client, server := helpers.GetMockRedis()
+
+	indexKey := fmt.Sprintf(services.RequestIDsIndexKey, testdata.OrgID, testdata.ClusterName1)
+	server.ExpectZRange(indexKey, 0, -1).SetVal([]string{"requestID1"})
+	server.ExpectExists(fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID1")).SetVal(1)
+	server.ExpectScan(0, fmt.Sprintf(services.RequestIDsScanPattern, testdata.OrgID, testdata.ClusterName1),
+		services.ScanBatchCount).SetErr(errTest)
+
+	requestIDs, err := client.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	assert.Error(t, err)
+	assert.Len(t, requestIDs, 0)
+
+	helpers.RedisExpectationsMet(t, server)
+}
+
+func TestRedisGetRequestIDsForClusterID_IndexError(t *testing.T) {
+	client, server := helpers.GetMockRedis()
+
+	indexKey := fmt.Sprintf(services.RequestIDsIndexKey, testdata.OrgID, testdata.ClusterName1)
+	server.ExpectZRange(indexKey, 0, -1).SetErr(errTest)
+
+	requestIDs, err := client.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	assert.Error(t, err)
+	assert.Len(t, requestIDs, 0)
+
+	helpers.RedisExpectationsMet(t, server)
+}
+
+func TestRedisGetRequestIDsForClusterID_ScanFallbackDisabled(t *testing.T) {
+	client, server := helpers.GetMockRedis()
+	client.DisableScanFallback = true
+
+	helpers.ExpectRequestIDsIndexMissing(server, testdata.OrgID, testdata.ClusterName1)
+
+	requestIDs, err := client.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	assert.NoError(t, err)
+	assert.Len(t, requestIDs, 0)
+
+	helpers.RedisExpectationsMet(t, server)
+}
+
+func TestRedisGetRequestIDsForClusterID_IndexScanFallbackDisabled(t *testing.T) {
+	client, server := helpers.GetMockRedis()
+	client.DisableScanFallback = true
+
+	indexKey := fmt.Sprintf(services.RequestIDsIndexKey, testdata.OrgID, testdata.ClusterName1)
+	server.ExpectZRange(indexKey, 0, -1).SetVal([]string{"requestID1"})
+	server.ExpectExists(fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID1")).SetVal(1)
+
+	requestIDs, err := client.GetRequestIDsForClusterID(testdata.OrgID, testdata.ClusterName1)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RequestID{"requestID1"}, requestIDs)
+
+	helpers.RedisExpectationsMet(t, server)
+}
+
+func TestRedisBuildRequestIDsIndex(t *testing.T) {
+	client, server := helpers.GetMockRedis()
+	server.MatchExpectationsInOrder(false)
+
+	requestKey1 := fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID1")
+	requestKey2 := fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName1, "requestID2")
+	requestKey3 := fmt.Sprintf(services.RequestKey, testdata.OrgID, testdata.ClusterName2, "requestID3")
+	indexKey1 := fmt.Sprintf(services.RequestIDsIndexKey, testdata.OrgID, testdata.ClusterName1)
+	received := time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	server.ExpectScan(0, services.AllRequestsScanPattern, services.ScanBatchCount).SetVal([]string{
+		requestKey1, requestKey1 + ":reports", requestKey2,
+	}, 42)
+	server.ExpectHMGet(requestKey1+":reports", services.ReceivedTimestampFieldName).SetVal([]interface{}{received.Format(time.RFC3339)})
+	server.ExpectPTTL(requestKey1).SetVal(time.Hour)
+	server.ExpectHMGet(requestKey2+":reports", services.ReceivedTimestampFieldName).SetVal([]interface{}{received.Format(time.RFC3339)})
+	server.ExpectPTTL(requestKey2).SetVal(2 * time.Hour)
+	server.ExpectZAdd(indexKey1, redisV9.Z{Score: float64(received.Unix()), Member: "requestID1"}).SetVal(1)
+	server.ExpectZAdd(indexKey1, redisV9.Z{Score: float64(received.Unix()), Member: "requestID2"}).SetVal(1)
+
+	server.ExpectScan(42, services.AllRequestsScanPattern, services.ScanBatchCount).SetVal([]string{
+		requestKey3, indexKey1,
+	}, 0)
+	// simplified report is missing, so the request is skipped
+	server.ExpectHMGet(requestKey3+":reports", services.ReceivedTimestampFieldName).SetVal([]interface{}{nil})
+	server.ExpectPTTL(requestKey3).SetVal(time.Hour)
+
+	// the index expires together with the last request
+	server.ExpectExpire(indexKey1, 2*time.Hour).SetVal(true)
+
+	indexed, err := client.BuildRequestIDsIndex()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, indexed)
+
+	helpers.RedisExpectationsMet(t, server)
+}
+
 func TestGetTimestampsForRequestIDs_OKFound(t *testing.T) {
 	client, server := helpers.GetMockRedis()
 
@@ -428,3 +571,70 @@ func TestGetRuleHitsForRequest_OKScanError(t *testing.T) {
 
 	helpers.RedisExpectationsMet(t, server)
 }
+
+func TestGetRuleHitsWithTemplateDataForRequest_OKFound(t *testing.T) {
+	client, server := helpers.GetMockRedis()
+
+	expectedKey := fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName1, "requestID123")
+	templateData := fmt.Sprintf(`{"%v": {"nodes": ["node1"]}}`, data.Rule1CompositeID)
+
+	server.ExpectHMGet(
+		expectedKey, services.RequestIDFieldName, services.RuleHitsFieldName, services.RuleHitsTemplateDataFieldName,
+	).SetVal([]interface{}{"requestID123", testRuleHits, templateData})
+
+	ruleHits, ruleHitsTemplateData, err := client.GetRuleHitsWithTemplateDataForRequest(
+		testdata.OrgID, testdata.ClusterName1, "requestID123",
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RuleID{data.Rule1CompositeID, data.Rule2CompositeID}, ruleHits)
+	assert.Equal(t, map[types.RuleID]interface{}{
+		data.Rule1CompositeID: map[string]interface{}{"nodes": []interface{}{"node1"}},
+	}, ruleHitsTemplateData)
+
+	helpers.RedisExpectationsMet(t, server)
+}
+
+// TestGetRuleHitsWithTemplateDataForRequest_NoTemplateData checks that
+// missing or invalid template data are not an error
+func TestGetRuleHitsWithTemplateDataForRequest_NoTemplateData(t *testing.T) {
+	client, server := helpers.GetMockRedis()
+
+	expectedKey := fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName1, "requestID123")
+
+	for _, templateData := range []interface{}{nil, "not a JSON"} {
+		server.ExpectHMGet(
+			expectedKey, services.RequestIDFieldName, services.RuleHitsFieldName, services.RuleHitsTemplateDataFieldName,
+		).SetVal([]interface{}{"requestID123", testRuleHits, templateData})
+
+		ruleHits, ruleHitsTemplateData, err := client.GetRuleHitsWithTemplateDataForRequest(
+			testdata.OrgID, testdata.ClusterName1, "requestID123",
+		)
+		assert.NoError(t, err)
+		assert.Len(t, ruleHits, 2)
+		assert.Nil(t, ruleHitsTemplateData)
+	}
+
+	helpers.RedisExpectationsMet(t, server)
+}
+
+func TestGetRuleHitsWithTemplateDataForRequest_OKNotFound(t *testing.T) {
+	client, server := helpers.GetMockRedis()
+
+	expectedKey := fmt.Sprintf(services.SimplifiedReportKey, testdata.OrgID, testdata.ClusterName1, "requestID123")
+
+	server.ExpectHMGet(
+		expectedKey, services.RequestIDFieldName, services.RuleHitsFieldName, services.RuleHitsTemplateDataFieldName,
+	).SetVal([]interface{}{nil, nil, nil})
+
+	_, _, err := client.GetRuleHitsWithTemplateDataForRequest(testdata.OrgID, testdata.ClusterName1, "requestID123")
+	assert.IsType(t, err, &utypes.ItemNotFoundError{})
+
+	server.ExpectHMGet(
+		expectedKey, services.RequestIDFieldName, services.RuleHitsFieldName, services.RuleHitsTemplateDataFieldName,
+	).SetErr(errTest)
+
+	_, _, err = client.GetRuleHitsWithTemplateDataForRequest(testdata.OrgID, testdata.ClusterName1, "requestID123")
+	assert.Error(t, err)
+
+	helpers.RedisExpectationsMet(t, server)
+}
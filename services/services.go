@@ -37,7 +37,7 @@ const (
 	GroupsEndpoint = "groups"
 )
 
-func getFromURL(endpoint string) (*http.Response, error) {
+func getFromURL(client *http.Client, endpoint string) (*http.Response, error) {
 	parsedURL, err := url.Parse(endpoint)
 	if err != nil {
 		log.Error().Err(err).Msgf("Error during endpoint %s URL parsing", endpoint)
@@ -46,7 +46,7 @@ func getFromURL(endpoint string) (*http.Response, error) {
 
 	log.Debug().Msgf("Connecting to %s", parsedURL.String())
 
-	resp, err := http.Get(parsedURL.String())
+	resp, err := client.Get(parsedURL.String())
 	if err != nil {
 		log.Error().Err(err).Msgf("Error during retrieve of %s", parsedURL.String())
 		return nil, err
@@ -55,8 +55,13 @@ func getFromURL(endpoint string) (*http.Response, error) {
 	return resp, nil
 }
 
-// GetGroups get the list of groups from content-service
+// GetGroups get the list of groups from content-service or from local groups
+// configuration file when it's configured
 func GetGroups(conf Configuration) ([]groups.Group, error) {
+	if conf.GroupsPath != "" {
+		return getGroupsFromFile(conf.GroupsPath)
+	}
+
 	type groupsResponse struct {
 		Status string         `json:"status"`
 		Groups []groups.Group `json:"groups"`
@@ -65,7 +70,7 @@ func GetGroups(conf Configuration) ([]groups.Group, error) {
 
 	log.Debug().Msg("Updating groups information")
 
-	resp, err := getFromURL(conf.ContentBaseEndpoint + GroupsEndpoint) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
+	resp, err := getFromURL(conf.ContentClient(), conf.ContentBaseEndpoint+GroupsEndpoint) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
 
 	if err != nil {
 		// Log already shown
@@ -85,10 +90,15 @@ func GetGroups(conf Configuration) ([]groups.Group, error) {
 	return receivedMsg.Groups, nil
 }
 
-// GetContent get the static rule content from content-service
+// GetContent get the static rule content from content-service or from local
+// content source when it's configured
 func GetContent(conf Configuration) (*types.RuleContentDirectory, error) {
+	if conf.ContentSource != "" && conf.ContentSource != ContentSourceService {
+		return getLocalContent(conf)
+	}
+
 	log.Debug().Msg("getting rules static content")
-	resp, err := getFromURL(conf.ContentBaseEndpoint + ContentEndpoint) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
+	resp, err := getFromURL(conf.ContentClient(), conf.ContentBaseEndpoint+ContentEndpoint) //nolint:bodyclose // TODO: remove once the bodyclose library fixes this bug
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,378 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+// Shared HTTP client layer used to access upstream services (Insights Results
+// Aggregator, Content Service, Upgrade Risks Prediction service). Each
+// upstream has its own client with connect and read timeouts, bounded retries
+// with jittered backoff for idempotent requests and a circuit breaker.
+//
+// Errors produced by the client (including the open circuit breaker) are
+// returned from http.Client methods wrapped in *url.Error, so the existing
+// error handling that maps *url.Error to "service unavailable" errors works
+// unchanged.
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// AggregatorServiceName is the name of Insights Results Aggregator upstream
+	AggregatorServiceName = "aggregator"
+	// ContentServiceName is the name of Content Service upstream
+	ContentServiceName = "content-service"
+	// UpgradeRisksPredictionServiceName is the name of the data-eng upgrade
+	// risks prediction upstream
+	UpgradeRisksPredictionServiceName = "upgrade-risks-prediction"
+
+	// defaultConnectTimeout is used when connect timeout is not configured
+	defaultConnectTimeout = 5 * time.Second
+	// defaultReadTimeout is used when read timeout is not configured
+	defaultReadTimeout = 30 * time.Second
+	// defaultUpgradeRisksReadTimeout is used for upgrade risks prediction
+	// service when read timeout is not configured
+	defaultUpgradeRisksReadTimeout = 5 * time.Second
+	// defaultRetryBackoff is the base backoff used between retries
+	defaultRetryBackoff = 100 * time.Millisecond
+	// defaultBreakerCooldown is the time the circuit breaker stays open
+	defaultBreakerCooldown = 30 * time.Second
+
+	serviceTag = "service"
+)
+
+// ErrCircuitOpen is returned (wrapped in *url.Error) when the circuit breaker
+// for given upstream service is open and the request has not been sent
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// UpstreamConfiguration represents configuration of the HTTP client used to
+// access one upstream service
+type UpstreamConfiguration struct {
+	ConnectTimeout   time.Duration `mapstructure:"connect_timeout" toml:"connect_timeout"`
+	ReadTimeout      time.Duration `mapstructure:"read_timeout" toml:"read_timeout"`
+	MaxRetries       int           `mapstructure:"max_retries" toml:"max_retries"`
+	RetryBackoff     time.Duration `mapstructure:"retry_backoff" toml:"retry_backoff"`
+	BreakerThreshold int           `mapstructure:"breaker_threshold" toml:"breaker_threshold"`
+	BreakerCooldown  time.Duration `mapstructure:"breaker_cooldown" toml:"breaker_cooldown"`
+}
+
+// upstreamKey identifies one shared upstream client
+type upstreamKey struct {
+	name string
+	conf UpstreamConfiguration
+}
+
+var (
+	upstreamClients      = make(map[upstreamKey]*http.Client)
+	upstreamClientsMutex sync.Mutex
+)
+
+// AggregatorClient returns the shared HTTP client for Insights Results Aggregator
+func (conf Configuration) AggregatorClient() *http.Client {
+	return GetUpstreamClient(AggregatorServiceName, conf.AggregatorClientConf)
+}
+
+// ContentClient returns the shared HTTP client for Content Service
+func (conf Configuration) ContentClient() *http.Client {
+	return GetUpstreamClient(ContentServiceName, conf.ContentClientConf)
+}
+
+// UpgradeRisksPredictionClient returns the shared HTTP client for the upgrade
+// risks prediction service
+func (conf Configuration) UpgradeRisksPredictionClient() *http.Client {
+	upstreamConf := conf.UpgradeRisksPredictionClientConf
+	if upstreamConf.ReadTimeout <= 0 {
+		upstreamConf.ReadTimeout = defaultUpgradeRisksReadTimeout
+	}
+	return GetUpstreamClient(UpgradeRisksPredictionServiceName, upstreamConf)
+}
+
+// GetUpstreamClient returns HTTP client for given upstream service. Clients
+// are shared, so the circuit breaker state and connection pool are common
+// for all callers using the same service name and configuration.
+func GetUpstreamClient(name string, conf UpstreamConfiguration) *http.Client {
+	key := upstreamKey{name: name, conf: conf}
+
+	upstreamClientsMutex.Lock()
+	defer upstreamClientsMutex.Unlock()
+
+	client, found := upstreamClients[key]
+	if !found {
+		client = NewUpstreamClient(name, conf)
+		upstreamClients[key] = client
+	}
+
+	return client
+}
+
+// NewUpstreamClient constructs new HTTP client for given upstream service
+func NewUpstreamClient(name string, conf UpstreamConfiguration) *http.Client {
+	if conf.ConnectTimeout <= 0 {
+		conf.ConnectTimeout = defaultConnectTimeout
+	}
+	if conf.ReadTimeout <= 0 {
+		conf.ReadTimeout = defaultReadTimeout
+	}
+	if conf.RetryBackoff <= 0 {
+		conf.RetryBackoff = defaultRetryBackoff
+	}
+	if conf.BreakerCooldown <= 0 {
+		conf.BreakerCooldown = defaultBreakerCooldown
+	}
+
+	return &http.Client{
+		Transport: &upstreamTransport{
+			name: name,
+			conf: conf,
+			breaker: circuitBreaker{
+				name:      name,
+				threshold: conf.BreakerThreshold,
+				cooldown:  conf.BreakerCooldown,
+			},
+		},
+	}
+}
+
+// upstreamTransport is http.RoundTripper implementing timeouts, retries and
+// circuit breaker for one upstream service
+type upstreamTransport struct {
+	name      string
+	conf      UpstreamConfiguration
+	breaker   circuitBreaker
+	transport *http.Transport
+	once      sync.Once
+}
+
+// base returns the transport used to send requests. When the default
+// transport has been replaced (for example by HTTP mocking libraries in
+// tests), it is used as is, otherwise a dedicated transport with configured
+// timeouts is derived from it.
+func (t *upstreamTransport) base() http.RoundTripper {
+	defaultTransport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return http.DefaultTransport
+	}
+
+	t.once.Do(func() {
+		dialer := &net.Dialer{
+			Timeout:   t.conf.ConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}
+		t.transport = defaultTransport.Clone()
+		t.transport.DialContext = dialer.DialContext
+		t.transport.TLSHandshakeTimeout = t.conf.ConnectTimeout
+		t.transport.ResponseHeaderTimeout = t.conf.ReadTimeout
+	})
+
+	return t.transport
+}
+
+// RoundTrip implements http.RoundTripper interface
+func (t *upstreamTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	retries := 0
+	if isIdempotent(request) {
+		retries = t.conf.MaxRetries
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptRequest := request
+		if attempt > 0 {
+			var err error
+			attemptRequest, err = rewindRequest(request)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		allowed, probe := t.breaker.allow()
+		if !allowed {
+			log.Warn().Str(serviceTag, t.name).Msg("circuit breaker is open, request not sent")
+			return nil, ErrCircuitOpen
+		}
+
+		response, err := t.roundTripAttempt(attemptRequest)
+		failed := isUpstreamFailure(response, err)
+		t.breaker.record(!failed, probe)
+
+		if !failed || attempt >= retries {
+			return response, err
+		}
+
+		if response != nil {
+			CloseResponseBody(response)
+		}
+
+		delay := backoff(t.conf.RetryBackoff, attempt)
+		log.Warn().Err(err).Str(serviceTag, t.name).Int("attempt", attempt+1).
+			Msgf("request to upstream service failed, retrying in %s", delay)
+
+		select {
+		case <-time.After(delay):
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		}
+	}
+}
+
+// roundTripAttempt sends the request once. The attempt, including reading of
+// the response body, has to finish before the connect timeout and the read
+// timeout elapse, so stalled upstream can't hold the caller forever.
+func (t *upstreamTransport) roundTripAttempt(request *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(request.Context(), t.conf.ConnectTimeout+t.conf.ReadTimeout)
+
+	response, err := t.base().RoundTrip(request.WithContext(ctx))
+	if err != nil || response.Body == nil {
+		cancel()
+		return response, err
+	}
+
+	// the deadline is released when the caller closes the body
+	response.Body = &cancelOnCloseBody{ReadCloser: response.Body, cancel: cancel}
+	return response, nil
+}
+
+// cancelOnCloseBody is response body releasing context of the request when
+// it's closed
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and releases context of the request
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// isIdempotent returns true for requests that can be safely retried
+func isIdempotent(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return request.Body == nil || request.Body == http.NoBody || request.GetBody != nil
+	}
+	return false
+}
+
+// rewindRequest prepares copy of the request with fresh body to be resent
+func rewindRequest(request *http.Request) (*http.Request, error) {
+	clone := request.Clone(request.Context())
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// isUpstreamFailure decides whether the result of one attempt means that the
+// upstream service is not able to handle requests
+func isUpstreamFailure(response *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch response.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff computes exponential backoff with jitter for given attempt
+func backoff(base time.Duration, attempt int) time.Duration {
+	delay := base << uint(attempt)
+	half := int64(delay / 2)
+	// #nosec G404
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// circuitBreaker opens after given number of consecutive failures and lets a
+// single probe request through after cooldown period elapses
+type circuitBreaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mutex     sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow returns true if a request can be sent to upstream service. The
+// second value is true when the request is the probe let through the open
+// breaker, its result has to be recorded as a probe.
+func (b *circuitBreaker) allow() (allowed, probe bool) {
+	if b.threshold <= 0 {
+		return true, false
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.failures < b.threshold {
+		return true, false
+	}
+
+	// breaker is open
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false, false
+	}
+
+	// half-open state, let one request through
+	b.probing = true
+	return true, true
+}
+
+// record stores the result of one request. Only the result of the probe
+// lets another probe through, requests sent before the breaker opened don't.
+func (b *circuitBreaker) record(success, probe bool) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+
+	if success {
+		if b.failures >= b.threshold {
+			log.Info().Str(serviceTag, b.name).Msg("circuit breaker closed")
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+		log.Error().Str(serviceTag, b.name).Int("failures", b.failures).
+			Msgf("circuit breaker opened for %s", b.cooldown)
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+)
+
+var testUpstreamConf = services.UpstreamConfiguration{
+	ConnectTimeout:   time.Second,
+	ReadTimeout:      time.Second,
+	MaxRetries:       2,
+	RetryBackoff:     time.Millisecond,
+	BreakerThreshold: 3,
+	BreakerCooldown:  time.Minute,
+}
+
+// newCountingServer starts HTTP server responding with given status codes, one
+// for each request. The last status code is repeated when the list is exhausted
+func newCountingServer(counter *int32, statuses ...int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := int(atomic.AddInt32(counter, 1)) - 1
+		if n >= len(statuses) {
+			n = len(statuses) - 1
+		}
+		w.WriteHeader(statuses[n])
+	}))
+}
+
+func TestUpstreamClientRetriesIdempotentRequests(t *testing.T) {
+	var counter int32
+	server := newCountingServer(&counter, http.StatusServiceUnavailable, http.StatusOK)
+	defer server.Close()
+
+	client := services.NewUpstreamClient("test", testUpstreamConf)
+
+	response, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	defer services.CloseResponseBody(response)
+
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&counter))
+}
+
+func TestUpstreamClientDoesNotRetryPost(t *testing.T) {
+	var counter int32
+	server := newCountingServer(&counter, http.StatusServiceUnavailable, http.StatusOK)
+	defer server.Close()
+
+	client := services.NewUpstreamClient("test", testUpstreamConf)
+
+	response, err := client.Post(server.URL, "application/json", http.NoBody)
+	assert.NoError(t, err)
+	defer services.CloseResponseBody(response)
+
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&counter))
+}
+
+func TestUpstreamClientCircuitBreakerOpens(t *testing.T) {
+	var counter int32
+	server := newCountingServer(&counter, http.StatusBadGateway)
+	defer server.Close()
+
+	conf := testUpstreamConf
+	conf.MaxRetries = 0
+	client := services.NewUpstreamClient("test", conf)
+
+	for i := 0; i < conf.BreakerThreshold; i++ {
+		response, err := client.Get(server.URL)
+		assert.NoError(t, err)
+		services.CloseResponseBody(response)
+	}
+
+	// breaker is open now, request must not reach the server
+	_, err := client.Get(server.URL) //nolint:bodyclose
+	assert.Error(t, err)
+
+	var urlErr *url.Error
+	assert.True(t, errors.As(err, &urlErr))
+	assert.ErrorIs(t, err, services.ErrCircuitOpen)
+	assert.Equal(t, int32(conf.BreakerThreshold), atomic.LoadInt32(&counter))
+}
+
+func TestUpstreamClientCircuitBreakerHalfOpen(t *testing.T) {
+	var counter int32
+	server := newCountingServer(&counter,
+		http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK)
+	defer server.Close()
+
+	conf := testUpstreamConf
+	conf.MaxRetries = 0
+	conf.BreakerCooldown = 10 * time.Millisecond
+	client := services.NewUpstreamClient("test", conf)
+
+	for i := 0; i < conf.BreakerThreshold; i++ {
+		response, err := client.Get(server.URL)
+		assert.NoError(t, err)
+		services.CloseResponseBody(response)
+	}
+
+	time.Sleep(2 * conf.BreakerCooldown)
+
+	// probe request is let through and closes the breaker
+	response, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	defer services.CloseResponseBody(response)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response2, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	defer services.CloseResponseBody(response2)
+	assert.Equal(t, http.StatusOK, response2.StatusCode)
+}
+
+func TestUpstreamClientReadTimeoutCoversBody(t *testing.T) {
+	stalled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		// headers are sent, but the body never comes
+		select {
+		case <-stalled:
+		case <-request.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(stalled)
+
+	conf := testUpstreamConf
+	conf.ConnectTimeout = 50 * time.Millisecond
+	conf.ReadTimeout = 50 * time.Millisecond
+	client := services.NewUpstreamClient("test", conf)
+
+	response, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	defer services.CloseResponseBody(response)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	started := time.Now()
+	_, err = io.ReadAll(response.Body)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(started), 5*time.Second)
+}
+
+// TestUpstreamClientCircuitBreakerSingleProbe checks that request sent before
+// the breaker opened doesn't let another probe through while the probe is in
+// flight
+func TestUpstreamClientCircuitBreakerSingleProbe(t *testing.T) {
+	received := make(chan string, 4)
+	release := map[string]chan struct{}{
+		"/slow-fail": make(chan struct{}),
+		"/slow-ok":   make(chan struct{}),
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, request *http.Request) {
+		received <- request.URL.Path
+		if wait, found := release[request.URL.Path]; found {
+			<-wait
+		}
+		if request.URL.Path == "/slow-ok" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	conf := testUpstreamConf
+	conf.MaxRetries = 0
+	conf.BreakerThreshold = 1
+	conf.BreakerCooldown = 10 * time.Millisecond
+	client := services.NewUpstreamClient("test", conf)
+
+	get := func(path string, done chan<- error) {
+		response, err := client.Get(server.URL + path)
+		if err == nil {
+			services.CloseResponseBody(response)
+		}
+		done <- err
+	}
+
+	// slow request is sent while the breaker is closed
+	slowDone := make(chan error, 1)
+	go get("/slow-fail", slowDone)
+	assert.Equal(t, "/slow-fail", <-received)
+
+	// the breaker opens
+	failDone := make(chan error, 1)
+	get("/fail", failDone)
+	assert.NoError(t, <-failDone)
+	assert.Equal(t, "/fail", <-received)
+	time.Sleep(2 * conf.BreakerCooldown)
+
+	// the probe is let through
+	probeDone := make(chan error, 1)
+	go get("/slow-ok", probeDone)
+	assert.Equal(t, "/slow-ok", <-received)
+
+	// the slow request finishes while the probe is in flight
+	close(release["/slow-fail"])
+	assert.NoError(t, <-slowDone)
+	time.Sleep(2 * conf.BreakerCooldown)
+
+	rejectedDone := make(chan error, 1)
+	get("/fail", rejectedDone)
+	assert.ErrorIs(t, <-rejectedDone, services.ErrCircuitOpen)
+
+	// the probe closes the breaker
+	close(release["/slow-ok"])
+	assert.NoError(t, <-probeDone)
+	okDone := make(chan error, 1)
+	get("/slow-ok", okDone)
+	assert.NoError(t, <-okDone)
+	assert.Len(t, received, 1)
+}
+
+func TestGetUpstreamClientIsShared(t *testing.T) {
+	client1 := services.GetUpstreamClient("shared", testUpstreamConf)
+	client2 := services.GetUpstreamClient("shared", testUpstreamConf)
+	client3 := services.GetUpstreamClient("other", testUpstreamConf)
+
+	assert.Same(t, client1, client2)
+	assert.NotSame(t, client1, client3)
+}
@@ -0,0 +1,435 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+// Rule visibility policy. Rules are classified into rule classes by tags of
+// their error keys or by patterns matching their modules. The policy decides
+// which classes are visible to organizations and account types. Rules that
+// don't belong to any class are visible to everybody, rules belonging to
+// several classes are visible only when all of them are allowed.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	types "github.com/RedHatInsights/insights-results-types"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// RuleClassInternal is the class of rules intended for Red Hat
+	// internal use
+	RuleClassInternal = "internal"
+	// RuleClassPreview is the class of rules that are not generally
+	// available yet (preview or beta)
+	RuleClassPreview = "preview"
+	// RuleClassOCS is the class of OpenShift Container Storage rules
+	RuleClassOCS = "ocs"
+
+	// internalModulePattern and ocsModulePattern match modules the same
+	// way as content.IsRuleInternal, so the second part of the module
+	// decides whether the rule is internal or OCS rule
+	internalModulePattern = `^[^.]+\.internal(\.|$)`
+	ocsModulePattern      = `^[^.]+\.ocs(\.|$)`
+
+	// fileReloadInterval is the period of reloading the policy file and
+	// the internal organizations file. Files are reloaded as soon as they
+	// change too, unless they can't be watched.
+	fileReloadInterval = time.Minute
+)
+
+// RuleClassSelector selects rules belonging to a rule class. Rule belongs to
+// the class when any of its error keys tags is listed in Tags or when its
+// module matches any of Modules regular expressions.
+type RuleClassSelector struct {
+	Tags    []string `yaml:"tags" json:"tags"`
+	Modules []string `yaml:"modules" json:"modules"`
+
+	modules []*regexp.Regexp
+}
+
+// compile compiles regular expressions of the selector
+func (s *RuleClassSelector) compile() error {
+	s.modules = make([]*regexp.Regexp, 0, len(s.Modules))
+	for _, pattern := range s.Modules {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return err
+		}
+		s.modules = append(s.modules, compiled)
+	}
+	return nil
+}
+
+// matches returns true when the rule belongs to the class
+func (s *RuleClassSelector) matches(module types.RuleID, tags []string) bool {
+	for _, tag := range tags {
+		for _, classTag := range s.Tags {
+			if tag == classTag {
+				return true
+			}
+		}
+	}
+	for _, pattern := range s.modules {
+		if pattern.MatchString(string(module)) {
+			return true
+		}
+	}
+	return false
+}
+
+// VisibilityPolicy maps organizations and account types to rule classes
+// visible to them
+type VisibilityPolicy struct {
+	// Classes are selectors of rule classes by class name. Default
+	// classes are used when not set.
+	Classes map[string]*RuleClassSelector `yaml:"classes" json:"classes"`
+	// Default lists classes visible to everybody
+	Default []string `yaml:"default" json:"default"`
+	// Organizations lists classes visible to given organizations
+	Organizations map[types.OrgID][]string `yaml:"organizations" json:"organizations"`
+	// AccountTypes lists classes visible to given account types (type of
+	// the identity in x-rh-identity header)
+	AccountTypes map[string][]string `yaml:"account_types" json:"account_types"`
+
+	// hash identifies the content of the policy, so changes of class
+	// selectors and grants can be detected
+	hash string
+}
+
+// DefaultRuleClasses returns selectors of rule classes used when the policy
+// file does not define them
+func DefaultRuleClasses() map[string]*RuleClassSelector {
+	return compileRuleClasses(map[string]*RuleClassSelector{
+		RuleClassInternal: {
+			Tags:    []string{"internal"},
+			Modules: []string{internalModulePattern},
+		},
+		RuleClassPreview: {
+			Tags: []string{"preview", "beta"},
+		},
+		RuleClassOCS: {
+			Modules: []string{ocsModulePattern},
+		},
+	})
+}
+
+// legacyRuleClasses returns selectors of rule classes used with the list of
+// internal rules organizations, when there is no policy file. Only modules
+// are matched, so exactly the rules restricted by content.IsRuleInternal are
+// restricted.
+func legacyRuleClasses() map[string]*RuleClassSelector {
+	return compileRuleClasses(map[string]*RuleClassSelector{
+		RuleClassInternal: {
+			Modules: []string{internalModulePattern},
+		},
+		RuleClassOCS: {
+			Modules: []string{ocsModulePattern},
+		},
+	})
+}
+
+// compileRuleClasses compiles selectors of built-in rule classes
+func compileRuleClasses(classes map[string]*RuleClassSelector) map[string]*RuleClassSelector {
+	for _, selector := range classes {
+		// built-in patterns are valid
+		_ = selector.compile()
+	}
+	return classes
+}
+
+// NewOrganizationsVisibilityPolicy returns policy that allows internal and
+// OCS rules (selected by their modules) to given organizations only. Other
+// rules, including the ones tagged as preview, are visible to everybody.
+func NewOrganizationsVisibilityPolicy(orgIDs []types.OrgID) *VisibilityPolicy {
+	classes := legacyRuleClasses()
+	allClasses := make([]string, 0, len(classes))
+	for class := range classes {
+		allClasses = append(allClasses, class)
+	}
+	sort.Strings(allClasses)
+
+	organizations := make(map[types.OrgID][]string, len(orgIDs))
+	for _, orgID := range orgIDs {
+		organizations[orgID] = allClasses
+	}
+
+	policy := &VisibilityPolicy{
+		Classes:       classes,
+		Organizations: organizations,
+	}
+	policy.computeHash()
+	return policy
+}
+
+// LoadVisibilityPolicy reads visibility policy from YAML file and validates
+// it
+func LoadVisibilityPolicy(path string) (*VisibilityPolicy, error) {
+	// path is provided by configuration, not by user
+	data, err := os.ReadFile(filepath.Clean(path)) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+
+	var policy VisibilityPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, err
+	}
+
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+	policy.computeHash()
+	return &policy, nil
+}
+
+// computeHash computes hash of class selectors and grants of the policy
+func (p *VisibilityPolicy) computeHash() {
+	// maps are encoded with sorted keys, so equal policies have equal hash
+	encoded, err := json.Marshal(p)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to compute hash of rule visibility policy")
+		return
+	}
+	sum := sha256.Sum256(encoded)
+	p.hash = hex.EncodeToString(sum[:])
+}
+
+// Hash returns hash identifying class selectors and grants of the policy
+func (p *VisibilityPolicy) Hash() string {
+	return p.hash
+}
+
+// validate compiles the class selectors and checks that only known classes
+// are granted
+func (p *VisibilityPolicy) validate() error {
+	if p.Classes == nil {
+		p.Classes = DefaultRuleClasses()
+	}
+
+	for class, selector := range p.Classes {
+		if selector == nil || (len(selector.Tags) == 0 && len(selector.Modules) == 0) {
+			return fmt.Errorf("rule class '%s' does not select any rules", class)
+		}
+		if err := selector.compile(); err != nil {
+			return fmt.Errorf("invalid module pattern of rule class '%s': %v", class, err)
+		}
+	}
+
+	checkClasses := func(grantee string, classes []string) error {
+		for _, class := range classes {
+			if _, found := p.Classes[class]; !found {
+				return fmt.Errorf("unknown rule class '%s' granted to %s", class, grantee)
+			}
+		}
+		return nil
+	}
+
+	if err := checkClasses("everybody", p.Default); err != nil {
+		return err
+	}
+	for orgID, classes := range p.Organizations {
+		if err := checkClasses(fmt.Sprintf("organization %d", orgID), classes); err != nil {
+			return err
+		}
+	}
+	for accountType, classes := range p.AccountTypes {
+		if err := checkClasses(fmt.Sprintf("account type '%s'", accountType), classes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Visibility returns rule classes visible to given organization and account
+// type
+func (p *VisibilityPolicy) Visibility(orgID types.OrgID, accountType string) RuleVisibility {
+	allowed := make(map[string]bool)
+	for _, classes := range [][]string{p.Default, p.Organizations[orgID], p.AccountTypes[accountType]} {
+		for _, class := range classes {
+			allowed[class] = true
+		}
+	}
+	return RuleVisibility{policy: p, allowed: allowed}
+}
+
+// Classify returns sorted names of classes the rule belongs to
+func (p *VisibilityPolicy) Classify(module types.RuleID, tags []string) []string {
+	classes := []string{}
+	for class, selector := range p.Classes {
+		if selector.matches(module, tags) {
+			classes = append(classes, class)
+		}
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+// RuleVisibility represents rule classes visible to one requester. Zero value
+// allows all rules.
+type RuleVisibility struct {
+	policy  *VisibilityPolicy
+	allowed map[string]bool
+}
+
+// Allows returns true when the rule with given module is visible. Tags of
+// every error key of the rule are passed separately and the rule is visible
+// only when all its error keys are visible. Rule without error keys is
+// classified by its module only.
+func (v RuleVisibility) Allows(module types.RuleID, errorKeysTags ...[]string) bool {
+	if v.policy == nil {
+		return true
+	}
+
+	if len(errorKeysTags) == 0 {
+		errorKeysTags = [][]string{nil}
+	}
+
+	for _, tags := range errorKeysTags {
+		for _, class := range v.policy.Classify(module, tags) {
+			if !v.allowed[class] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Key returns string identifying visible classes and the policy selecting
+// rules of the classes, so responses filtered by different visibility can be
+// distinguished (in ETags for example)
+func (v RuleVisibility) Key() string {
+	if v.policy == nil {
+		return "all"
+	}
+
+	classes := make([]string, 0, len(v.allowed))
+	for class := range v.allowed {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	return "policy:" + v.policy.hash + ";classes:" + strings.Join(classes, ",")
+}
+
+// VisibilityPolicyStore keeps the latest valid visibility policy read from
+// the policy file. It's thread safe, so the policy can be reloaded while
+// REST API handlers read it.
+type VisibilityPolicyStore struct {
+	mutex    sync.RWMutex
+	path     string
+	policy   *VisibilityPolicy
+	loadedAt time.Time
+}
+
+// NewVisibilityPolicyStore constructs the store and loads the policy from
+// given file. Error is returned when the policy can't be loaded.
+func NewVisibilityPolicyStore(path string) (*VisibilityPolicyStore, error) {
+	store := &VisibilityPolicyStore{path: path}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Reload reads the policy file again. Previous policy is kept when the file
+// can't be read or when it's not valid. Load time is kept when the policy has
+// not changed.
+func (s *VisibilityPolicyStore) Reload() error {
+	policy, err := LoadVisibilityPolicy(s.path)
+	if err != nil {
+		log.Error().Err(err).Str("path", s.path).Msg("unable to load rule visibility policy")
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.policy != nil && s.policy.Hash() == policy.Hash() {
+		log.Debug().Str("path", s.path).Msg("rule visibility policy has not changed")
+		return nil
+	}
+
+	s.policy = policy
+	s.loadedAt = time.Now().UTC()
+	log.Info().Str("path", s.path).Msg("rule visibility policy loaded")
+	return nil
+}
+
+// Get returns the latest valid policy
+func (s *VisibilityPolicyStore) Get() *VisibilityPolicy {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.policy
+}
+
+// LoadedAt returns the time the current policy has been loaded
+func (s *VisibilityPolicyStore) LoadedAt() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.loadedAt
+}
+
+// RunReloadLoop reloads the policy periodically and whenever the policy file
+// changes, until stop is closed
+func (s *VisibilityPolicyStore) RunReloadLoop(stop <-chan struct{}) {
+	runFileReloadLoop(s.path, "rule visibility policy", s.Reload, stop)
+}
+
+// runFileReloadLoop calls reload periodically and whenever the file changes,
+// until stop is closed. Errors are expected to be logged by reload.
+func runFileReloadLoop(path, description string, reload func() error, stop <-chan struct{}) {
+	ticker := time.NewTicker(fileReloadInterval)
+	defer ticker.Stop()
+
+	// nil channel blocks forever, so it's safe to select on it when the
+	// file is not watched
+	var changes <-chan struct{}
+	watcher, err := NewFileWatcher(path)
+	if err != nil {
+		log.Error().Err(err).Msgf("unable to watch %s, it will be reloaded periodically only", description)
+	} else {
+		defer func() {
+			if err := watcher.Close(); err != nil {
+				log.Error().Err(err).Msgf("unable to stop watching %s", description)
+			}
+		}()
+		changes = watcher.Changes()
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-changes:
+			log.Info().Msgf("%s changed, reloading", description)
+		case <-stop:
+			return
+		}
+
+		// error has been logged already, previous data are kept
+		_ = reload()
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	types "github.com/RedHatInsights/insights-results-types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+)
+
+const (
+	externalModule = "ccx_rules_ocp.external.rules.node_installer_degraded"
+	internalModule = "ccx_rules_ocp.internal.rules.debug"
+	ocsModule      = "ccx_rules_ocp.ocs.rules.storage"
+
+	testVisibilityPolicy = `
+default: [preview]
+organizations:
+  1: [internal]
+  2: [internal, ocs]
+account_types:
+  Associate: [ocs]
+`
+)
+
+func TestOrganizationsVisibilityPolicy(t *testing.T) {
+	policy := services.NewOrganizationsVisibilityPolicy([]types.OrgID{1})
+
+	assert.Equal(t, []string{}, policy.Classify(externalModule, []string{"openshift"}))
+	assert.Equal(t, []string{"internal"}, policy.Classify(internalModule, nil))
+	assert.Equal(t, []string{"ocs"}, policy.Classify(ocsModule, nil))
+	// rules are restricted by their modules only, as by content.IsRuleInternal
+	assert.Equal(t, []string{}, policy.Classify(externalModule, []string{"internal"}))
+	assert.Equal(t, []string{}, policy.Classify(externalModule, []string{"preview", "beta"}))
+	assert.Equal(t, []string{"internal"}, policy.Classify(internalModule, []string{"beta"}))
+	// only the second part of the module is checked
+	assert.Equal(t, []string{}, policy.Classify("ccx_rules_ocp.external.internal", nil))
+
+	allowed := policy.Visibility(1, "")
+	assert.True(t, allowed.Allows(internalModule))
+	assert.True(t, allowed.Allows(ocsModule, []string{"preview"}))
+
+	notAllowed := policy.Visibility(2, "")
+	assert.True(t, notAllowed.Allows(externalModule, []string{"openshift"}))
+	assert.True(t, notAllowed.Allows(externalModule, []string{"preview"}))
+	assert.False(t, notAllowed.Allows(internalModule))
+	assert.False(t, notAllowed.Allows(ocsModule, []string{"openshift"}))
+
+	assert.NotEqual(t, allowed.Key(), notAllowed.Key())
+}
+
+func TestDefaultRuleClasses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writeTestFile(t, path, []byte("organizations:\n  1: [internal, preview, ocs]\n"))
+
+	policy, err := services.LoadVisibilityPolicy(path)
+	helpers.FailOnError(t, err)
+
+	assert.Equal(t, []string{"internal"}, policy.Classify(externalModule, []string{"internal"}))
+	assert.Equal(t, []string{"internal", "preview"}, policy.Classify(internalModule, []string{"beta"}))
+	assert.Equal(t, []string{"ocs"}, policy.Classify(ocsModule, nil))
+
+	notAllowed := policy.Visibility(2, "")
+	assert.False(t, notAllowed.Allows(externalModule, []string{"preview"}))
+	// rule is visible only when all its error keys are visible
+	assert.False(t, notAllowed.Allows(externalModule, []string{"openshift"}, []string{"preview"}))
+}
+
+func TestRuleVisibilityZeroValue(t *testing.T) {
+	visibility := services.RuleVisibility{}
+	assert.True(t, visibility.Allows(internalModule, []string{"preview"}))
+	assert.Equal(t, "all", visibility.Key())
+}
+
+func TestLoadVisibilityPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writeTestFile(t, path, []byte(testVisibilityPolicy))
+
+	policy, err := services.LoadVisibilityPolicy(path)
+	helpers.FailOnError(t, err)
+
+	// default classes are used when the policy doesn't define them
+	assert.Equal(t, []string{"internal"}, policy.Classify(internalModule, nil))
+
+	testCases := []struct {
+		orgID       types.OrgID
+		accountType string
+		module      types.RuleID
+		tags        []string
+		allowed     bool
+	}{
+		{3, "", externalModule, []string{"preview"}, true},
+		{3, "", internalModule, nil, false},
+		{1, "", internalModule, nil, true},
+		{1, "", ocsModule, nil, false},
+		{2, "", ocsModule, nil, true},
+		{3, "Associate", ocsModule, nil, true},
+		{3, "User", ocsModule, nil, false},
+	}
+	for _, testCase := range testCases {
+		visibility := policy.Visibility(testCase.orgID, testCase.accountType)
+		assert.Equal(t, testCase.allowed, visibility.Allows(testCase.module, testCase.tags), testCase)
+	}
+}
+
+func TestLoadVisibilityPolicyCustomClasses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writeTestFile(t, path, []byte(`
+classes:
+  beta:
+    tags: [beta]
+    modules: ['^ccx_rules_ocp\.beta\.']
+organizations:
+  1: [beta]
+`))
+
+	policy, err := services.LoadVisibilityPolicy(path)
+	helpers.FailOnError(t, err)
+
+	assert.Equal(t, []string{"beta"}, policy.Classify("ccx_rules_ocp.beta.rules.new", nil))
+	// classes not defined by the policy are not used
+	assert.Equal(t, []string{}, policy.Classify(internalModule, nil))
+
+	assert.True(t, policy.Visibility(1, "").Allows("ccx_rules_ocp.beta.rules.new"))
+	assert.False(t, policy.Visibility(2, "").Allows(externalModule, []string{"beta"}))
+}
+
+func TestLoadVisibilityPolicyErrors(t *testing.T) {
+	for _, policy := range []string{
+		"default: [unknown]",
+		"organizations: {1: [unknown]}",
+		"account_types: {User: [unknown]}",
+		"classes: {beta: {modules: ['(']}}",
+		"classes: {beta: {}}",
+		"organizations: not a map",
+	} {
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+		writeTestFile(t, path, []byte(policy))
+
+		_, err := services.LoadVisibilityPolicy(path)
+		assert.Error(t, err, policy)
+	}
+
+	_, err := services.LoadVisibilityPolicy(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+// TestVisibilityPolicyStoreReload checks that invalid or unchanged policy
+// doesn't replace the previous one
+func TestVisibilityPolicyStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	writeTestFile(t, path, []byte(testVisibilityPolicy))
+
+	store, err := services.NewVisibilityPolicyStore(path)
+	helpers.FailOnError(t, err)
+	loadedAt := store.LoadedAt()
+	assert.False(t, loadedAt.IsZero())
+	assert.True(t, store.Get().Visibility(1, "").Allows(internalModule))
+
+	// unchanged policy is not replaced
+	policy := store.Get()
+	helpers.FailOnError(t, store.Reload())
+	assert.Same(t, policy, store.Get())
+	assert.Equal(t, loadedAt, store.LoadedAt())
+
+	writeTestFile(t, path, []byte("default: [unknown]"))
+	assert.Error(t, store.Reload())
+	assert.True(t, store.Get().Visibility(1, "").Allows(internalModule))
+	assert.Equal(t, loadedAt, store.LoadedAt())
+
+	writeTestFile(t, path, []byte("organizations: {2: [internal]}"))
+	helpers.FailOnError(t, store.Reload())
+	assert.False(t, store.Get().Visibility(1, "").Allows(internalModule))
+
+	_, err = services.NewVisibilityPolicyStore(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
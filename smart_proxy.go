@@ -19,16 +19,19 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
-	"github.com/RedHatInsights/insights-content-service/groups"
 	"github.com/RedHatInsights/insights-operator-utils/logger"
 	"github.com/RedHatInsights/insights-operator-utils/metrics"
 	"github.com/rs/zerolog/log"
@@ -50,7 +53,15 @@ const (
 	ExitStatusOK = iota
 	// ExitStatusServerError means that the HTTP server cannot be initialized
 	ExitStatusServerError
+	// ExitStatusContentError means that the rule content can't be retrieved
+	// or it contains errors
+	ExitStatusContentError
+	// ExitStatusRedisError means that the command sent to Redis failed
+	ExitStatusRedisError
 	defaultConfigFileName = "config"
+
+	// defaultShutdownTimeout is used when shutdown timeout is not configured
+	defaultShutdownTimeout = 25 * time.Second
 )
 
 const helpMessageTemplate = `
@@ -69,6 +80,8 @@ The commands are:
     print-config        prints current configuration set by files & env variables
     print-env           prints env variables
     print-version-info  prints version info
+    validate-content    validates rule content from the configured content source
+    index-requests      adds requests stored in Redis to indexes of request IDs (debug)
 
 `
 
@@ -105,28 +118,101 @@ func printEnv() ExitCode {
 	return ExitStatusOK
 }
 
+// validateContent function retrieves rule content and groups the same way
+// the service does and prints all problems found in the content. Non-zero
+// exit code is returned when the content contains any error.
+func validateContent() ExitCode {
+	servicesCfg := conf.GetServicesConfiguration()
+
+	contentDir, err := services.GetContent(servicesCfg)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to retrieve rule content")
+		return ExitStatusContentError
+	}
+
+	groupsList, err := services.GetGroups(servicesCfg)
+	if err != nil {
+		log.Error().Err(err).Msg("unable to retrieve groups")
+		return ExitStatusContentError
+	}
+
+	errorsCount, warningsCount := 0, 0
+	for _, issue := range proxy_content.ValidateRuleContent(contentDir, groupsList) {
+		fmt.Println(issue)
+		if issue.Severity == proxy_content.ValidationError {
+			errorsCount++
+		} else {
+			warningsCount++
+		}
+	}
+
+	fmt.Printf("%d rules validated: %d errors, %d warnings\n",
+		len(contentDir.Rules), errorsCount, warningsCount)
+
+	if errorsCount > 0 {
+		return ExitStatusContentError
+	}
+	return ExitStatusOK
+}
+
+// indexRequests function adds requests stored in Redis to the indexes of
+// request IDs of their clusters, so the requests stored before writers
+// started maintaining the indexes are found without SCAN command.
+func indexRequests() ExitCode {
+	redisClient, err := services.NewRedisClient(conf.GetRedisConfiguration())
+	if err != nil {
+		log.Error().Err(err).Msg("unable to create Redis client")
+		return ExitStatusRedisError
+	}
+	defer closeConnections(redisClient)
+
+	client, ok := redisClient.(*services.RedisClient)
+	if !ok {
+		fmt.Println("requests are indexed by the in-memory storage already")
+		return ExitStatusOK
+	}
+
+	indexed, err := client.BuildRequestIDsIndex()
+	if err != nil {
+		log.Error().Err(err).Msg("unable to index requests")
+		return ExitStatusRedisError
+	}
+
+	fmt.Printf("%d requests indexed\n", indexed)
+	return ExitStatusOK
+}
+
+// clusterListStore returns the storage for cached lists of clusters. Nil is
+// returned for the default (in-memory) storage.
+func clusterListStore(
+	amsConfig amsclient.Configuration, redisClient services.RedisInterface,
+) amsclient.ClusterListStore {
+	if !amsConfig.CacheRedis {
+		return nil
+	}
+
+	client, ok := redisClient.(*services.RedisClient)
+	if !ok || client == nil {
+		log.Warn().Msg("Redis is not available, cluster lists will be cached in memory")
+		return nil
+	}
+
+	return amsclient.NewRedisClusterListStore(client.Client.Connection)
+}
+
 // startService function starts service and returns error code.
 func startServer() ExitCode {
-	_ = conf.GetSetupConfiguration()
+	setupCfg := conf.GetSetupConfiguration()
 	serverCfg := conf.GetServerConfiguration()
 	metricsCfg := conf.GetMetricsConfiguration()
 	servicesCfg := conf.GetServicesConfiguration()
 	amsConfig := conf.GetAMSClientConfiguration()
 	redisConf := conf.GetRedisConfiguration()
-	groupsChannel := make(chan []groups.Group)
-	errorFoundChannel := make(chan bool)
-	errorChannel := make(chan error)
+	groupsStore := services.NewGroupsStore()
 
 	if metricsCfg.Namespace != "" {
 		metrics.AddAPIMetricsWithNamespace(metricsCfg.Namespace)
-	}
-
-	amsClient, err := amsclient.NewAMSClient(amsConfig)
-	if err != nil {
-		log.Error().Err(err).Msg("Cannot init the AMSClient, using old approach")
-		amsClient = nil
-	} else {
-		log.Info().Msg("AMSClient successfully created")
+		amsclient.AddMetricsWithNamespace(metricsCfg.Namespace)
 	}
 
 	redisClient, err := services.NewRedisClient(redisConf)
@@ -142,22 +228,140 @@ func startServer() ExitCode {
 		}
 	}
 
-	serverInstance = server.New(serverCfg, servicesCfg, amsClient, redisClient, groupsChannel, errorFoundChannel, errorChannel)
+	amsClient, err := amsclient.NewAMSClient(amsConfig)
+	if err != nil {
+		log.Error().Err(err).Msg("Cannot init the AMSClient, using old approach")
+		amsClient = nil
+	} else {
+		log.Info().Msg("AMSClient successfully created")
+		if amsConfig.CacheTTL > 0 {
+			amsClient = amsclient.NewCachingAMSClient(
+				amsClient, amsConfig, clusterListStore(amsConfig, redisClient),
+			)
+		}
+	}
+
+	serverInstance = server.New(serverCfg, servicesCfg, amsClient, redisClient, groupsStore)
+
+	if serverCfg.VisibilityPolicyFile != "" {
+		serverInstance.VisibilityPolicy, err = services.NewVisibilityPolicyStore(serverCfg.VisibilityPolicyFile)
+		if err != nil {
+			log.Error().Err(err).Msg("Rule visibility policy can not be loaded")
+			closeConnections(redisClient, amsClient)
+			return ExitStatusServerError
+		}
+	}
+
+	if serverCfg.EnableInternalRulesOrganizations && setupCfg.InternalRulesOrganizationsCSVFile != "" {
+		serverInstance.InternalOrganizations, err = services.NewOrganizationsStore(
+			setupCfg.InternalRulesOrganizationsCSVFile,
+		)
+		if err != nil {
+			log.Error().Err(err).Msg("Internal rules organizations can not be loaded")
+			closeConnections(redisClient, amsClient)
+			return ExitStatusServerError
+		}
+	}
 
 	// fill-in additional info used by /info endpoint handler
 	fillInInfoParams(serverInstance.InfoParams)
 
 	proxy_content.SetContentDirectoryTimeout(servicesCfg.ContentDirectoryTimeout)
-	go updateGroupInfo(servicesCfg, groupsChannel, errorFoundChannel, errorChannel)
+	proxy_content.SetContentHistorySize(servicesCfg.ContentHistorySize)
+	stopLoops := make(chan struct{})
+	go updateGroupInfo(servicesCfg, groupsStore, stopLoops)
+	if serverInstance.VisibilityPolicy != nil {
+		go serverInstance.VisibilityPolicy.RunReloadLoop(stopLoops)
+	}
+	if serverInstance.InternalOrganizations != nil {
+		go serverInstance.InternalOrganizations.RunReloadLoop(stopLoops)
+	}
 	go proxy_content.RunUpdateContentLoop(servicesCfg)
 
-	err = serverInstance.Start()
-	if err != nil {
-		log.Error().Err(err).Msg("HTTP(s) start error")
-		return ExitStatusServerError
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	defer signal.Stop(signals)
+
+	serverErrors := make(chan error, 1)
+	go func() {
+		serverErrors <- serverInstance.Start()
+	}()
+
+	exitCode := ExitCode(ExitStatusOK)
+	select {
+	case err = <-serverErrors:
+		if err != nil {
+			log.Error().Err(err).Msg("HTTP(s) start error")
+			exitCode = ExitStatusServerError
+		}
+	case sig := <-signals:
+		log.Info().Str("signal", sig.String()).Msg("Signal received, shutting down")
+		if err = shutdownServer(serverInstance, serverCfg.ShutdownTimeout); err != nil {
+			exitCode = ExitStatusServerError
+		}
 	}
 
-	return ExitStatusOK
+	stopBackgroundLoops(stopLoops, serverCfg.ShutdownTimeout)
+	closeConnections(redisClient, amsClient)
+
+	return exitCode
+}
+
+// shutdownServer gracefully stops the HTTP server, waiting for in-flight
+// requests at most for the given timeout
+func shutdownServer(serverInstance *server.HTTPServer, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := serverInstance.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("HTTP(s) server has not been stopped gracefully")
+		return err
+	}
+
+	log.Info().Msg("HTTP(s) server stopped")
+	return nil
+}
+
+// stopBackgroundLoops stops polling of groups and rule content and reloading
+// of the rule visibility policy and internal rules organizations. Content loop can be in the middle of an
+// update, so it is waited at most for the given timeout.
+func stopBackgroundLoops(stopLoops chan struct{}, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	close(stopLoops)
+
+	stopped := make(chan struct{})
+	go func() {
+		proxy_content.StopUpdateContentLoop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		log.Info().Msg("Background loops stopped")
+	case <-time.After(timeout):
+		log.Warn().Msg("Content update loop has not been stopped in time")
+	}
+}
+
+// closeConnections closes connections to Redis and AMS API, if the clients
+// hold any
+func closeConnections(clients ...interface{}) {
+	for _, client := range clients {
+		closer, ok := client.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			log.Error().Err(err).Msgf("Unable to close connection of %T", client)
+		}
+	}
 }
 
 // fillInInfoParams function fills-in additional info used by /info endpoint
@@ -170,60 +374,50 @@ func fillInInfoParams(params map[string]string) {
 	params["UtilsVersion"] = UtilsVersion
 }
 
-// updateGroupInfo function is run in a goroutine. It runs forever, waiting for 1 of 2 events: a Ticker or a channel
-// * If ticker comes first, the groups configuration is updated, doing a request to the content-service
-// * If the channel comes first, the latest valid groups configuration is send through the channel
+// updateGroupInfo function is run in a goroutine. It updates the groups
+// configuration in the store right away and then on each tick of the ticker,
+// doing a request to the content-service, until the stop channel is closed.
 func updateGroupInfo(servicesConf services.Configuration,
-	groupsChannel chan []groups.Group,
-	errorFoundChannel chan bool,
-	errorChannel chan error) {
-	var currentGroups []groups.Group
-	var currentError error
-	var currentErrorFound bool
-	retrievedGroups, err := services.GetGroups(servicesConf)
-
-	if err != nil {
-		log.Error().Err(err).Msg("Error retrieving groups")
-		currentErrorFound = true
-		var e *url.Error
-		if errors.As(err, &e) {
-			currentError = &server.ContentServiceUnavailableError{}
-		} else {
-			currentError = err
-		}
-	} else {
-		currentErrorFound = false
-		currentGroups = retrievedGroups
-	}
+	groupsStore *services.GroupsStore,
+	stop <-chan struct{}) {
+	refreshGroups(servicesConf, groupsStore)
 
 	uptimeTicker := time.NewTicker(servicesConf.GroupsPollingTime)
 	log.Info().Msgf("Updating groups configuration each %f seconds", servicesConf.GroupsPollingTime.Seconds())
 
 	for {
 		select {
+		case <-stop:
+			uptimeTicker.Stop()
+			log.Info().Msg("Groups configuration updates stopped")
+			return
 		case <-uptimeTicker.C:
-			retrievedGroups, err = services.GetGroups(servicesConf)
-			currentGroups, currentErrorFound, currentError = handleGroupError(err, currentGroups, retrievedGroups)
-		case errorFoundChannel <- currentErrorFound:
-		case errorChannel <- currentError:
-		case groupsChannel <- currentGroups:
+			refreshGroups(servicesConf, groupsStore)
 		}
 	}
 }
 
-// handleGroupError handles error after retrieving groups info in updateGroupInfo
-func handleGroupError(err error,
-	currentGroups []groups.Group,
-	newGroups []groups.Group) ([]groups.Group, bool, error) {
-	if err != nil {
-		log.Error().Err(err).Msg("Error retrieving groups")
-		var e *url.Error
-		if errors.As(err, &e) {
-			return currentGroups, true, &server.ContentServiceUnavailableError{}
-		}
-		return currentGroups, true, err
+// refreshGroups retrieves groups from content-service and records the result
+// in the store. The latest valid groups are kept in the store on error.
+func refreshGroups(servicesConf services.Configuration, groupsStore *services.GroupsStore) {
+	retrievedGroups, err := services.GetGroups(servicesConf)
+	groupsStore.Update(retrievedGroups, handleGroupError(err))
+}
+
+// handleGroupError handles error after retrieving groups info in
+// refreshGroups. Errors caused by unreachable content-service are reported as
+// ContentServiceUnavailableError.
+func handleGroupError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	log.Error().Err(err).Msg("Error retrieving groups")
+	var e *url.Error
+	if errors.As(err, &e) {
+		return &server.ContentServiceUnavailableError{}
 	}
-	return newGroups, false, nil
+	return err
 }
 
 // handleCommand select the function to be called depending on command argument
@@ -247,6 +441,12 @@ func handleCommand(command string) ExitCode {
 	case "print-env":
 		printEnv()
 		return ExitStatusOK
+
+	case "validate-content":
+		return validateContent()
+
+	case "index-requests":
+		return indexRequests()
 	}
 
 	return ExitStatusOK
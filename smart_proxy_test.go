@@ -19,10 +19,14 @@ limitations under the License.
 package main_test
 
 import (
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/RedHatInsights/insights-operator-utils/tests/helpers"
+	"github.com/RedHatInsights/insights-results-aggregator-data/testdata"
+	ctypes "github.com/RedHatInsights/insights-results-types"
 	"github.com/stretchr/testify/assert"
 
 	main "github.com/RedHatInsights/insights-results-smart-proxy"
@@ -115,3 +119,82 @@ func TestHandleCommand(t *testing.T) {
 	assert.Equal(t, int(main.HandleCommand("print-config")), main.ExitStatusOK)
 	assert.Equal(t, int(main.HandleCommand("print-env")), main.ExitStatusOK)
 }
+
+// writeContentSnapshot stores JSON snapshot of the rule content and groups
+// configuration into temporary directory and configures them as the content
+// source
+func writeContentSnapshot(t *testing.T, contentDir ctypes.RuleContentDirectory, groupsConfig string) {
+	dir := t.TempDir()
+
+	encoded, err := json.Marshal(contentDir)
+	helpers.FailOnError(t, err)
+	helpers.FailOnError(t, os.WriteFile(filepath.Join(dir, "content.json"), encoded, 0o600))
+	helpers.FailOnError(t, os.WriteFile(filepath.Join(dir, "groups.yaml"), []byte(groupsConfig), 0o600))
+
+	setEnvSettings(t, map[string]string{
+		"INSIGHTS_RESULTS_SMART_PROXY__SERVICES__CONTENT_SOURCE": "snapshot",
+		"INSIGHTS_RESULTS_SMART_PROXY__SERVICES__CONTENT_PATH":   filepath.Join(dir, "content.json"),
+		"INSIGHTS_RESULTS_SMART_PROXY__SERVICES__GROUPS_PATH":    filepath.Join(dir, "groups.yaml"),
+	})
+}
+
+const testGroupsConfig = `
+security:
+  name: Security
+  description: Security issues
+  tags:
+    - security
+`
+
+// TestValidateContent checks that content with unknown tags only is valid
+func TestValidateContent(t *testing.T) {
+	writeContentSnapshot(t, testdata.RuleContentDirectory3Rules, testGroupsConfig)
+
+	assert.Equal(t, main.ExitStatusOK, int(main.HandleCommand("validate-content")))
+}
+
+// TestValidateContentErrors checks that content with error key that would be
+// skipped is reported by non-zero exit code
+func TestValidateContentErrors(t *testing.T) {
+	errorKey := testdata.RuleContent1.ErrorKeys[testdata.ErrorKey1]
+	errorKey.Metadata.Status = "unknown"
+
+	rule := testdata.RuleContent1
+	rule.ErrorKeys = map[string]ctypes.RuleErrorKeyContent{
+		testdata.ErrorKey1: errorKey,
+	}
+
+	writeContentSnapshot(t, ctypes.RuleContentDirectory{
+		Config: testdata.RuleContentDirectory3Rules.Config,
+		Rules:  map[string]ctypes.RuleContent{"rc1": rule},
+	}, testGroupsConfig)
+
+	assert.Equal(t, main.ExitStatusContentError, int(main.HandleCommand("validate-content")))
+}
+
+// TestValidateContentMissingSnapshot checks that content that can't be read
+// is reported by non-zero exit code
+func TestValidateContentMissingSnapshot(t *testing.T) {
+	setEnvSettings(t, map[string]string{
+		"INSIGHTS_RESULTS_SMART_PROXY__SERVICES__CONTENT_SOURCE": "snapshot",
+		"INSIGHTS_RESULTS_SMART_PROXY__SERVICES__CONTENT_PATH":   filepath.Join(t.TempDir(), "missing.json"),
+	})
+
+	assert.Equal(t, main.ExitStatusContentError, int(main.ValidateContent()))
+}
+
+// TestIndexRequests checks that indexing requests in the in-memory storage
+// succeeds and that unreachable Redis server is reported by non-zero exit
+// code
+func TestIndexRequests(t *testing.T) {
+	setEnvSettings(t, map[string]string{
+		"INSIGHTS_RESULTS_SMART_PROXY__REDIS__ENDPOINT": "memory://",
+	})
+	assert.Equal(t, main.ExitStatusOK, int(main.HandleCommand("index-requests")))
+
+	setEnvSettings(t, map[string]string{
+		"INSIGHTS_RESULTS_SMART_PROXY__REDIS__ENDPOINT":        "localhost:1",
+		"INSIGHTS_RESULTS_SMART_PROXY__REDIS__TIMEOUT_SECONDS": "1",
+	})
+	assert.Equal(t, main.ExitStatusRedisError, int(main.IndexRequests()))
+}
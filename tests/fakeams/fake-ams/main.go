@@ -0,0 +1,61 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Entry point of the fake AMS API server. It serves organizations and
+// subscriptions read from a fixture file and is meant to be used for local
+// development (see docker-compose.yml) only.
+//
+// Smart Proxy can be configured to use it this way:
+//
+//	[amsclient]
+//	url = "http://localhost:8010"
+//	token_url = "http://localhost:8010/token"
+//	client_id = "any"
+//	client_secret = "any"
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/fakeams"
+)
+
+func main() {
+	address := flag.String("address", ":8010", "address the server listens on")
+	fixturePath := flag.String("fixture", "tests/fakeams/fixture.yaml", "YAML or JSON file with organizations and clusters")
+	flag.Parse()
+
+	fixture, err := fakeams.LoadFixture(*fixturePath)
+	if err != nil {
+		log.Error().Err(err).Str("fixture", *fixturePath).Msg("unable to load fixture")
+		os.Exit(1)
+	}
+
+	log.Info().Str("address", *address).Int("organizations", len(fixture.Organizations)).Msg("starting fake AMS API")
+
+	server := &http.Server{
+		Addr:              *address,
+		Handler:           fakeams.NewHandler(fixture),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := server.ListenAndServe(); err != nil {
+		log.Error().Err(err).Msg("fake AMS API server error")
+		os.Exit(1)
+	}
+}
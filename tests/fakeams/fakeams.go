@@ -0,0 +1,399 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakeams contains an in-process fake of AMS (accounts management)
+// API. Only the organizations and subscriptions list endpoints are
+// implemented, together with a token endpoint, so the real AMS client can be
+// used against the fake without OCM credentials.
+//
+// Organizations and their subscriptions (clusters) are read from a fixture in
+// YAML or JSON format:
+//
+//	organizations:
+//	  - id: 1NKVU4otCIulgoMtgtyA6wajxkQ
+//	    external_id: 1234
+//	    subscriptions:
+//	      - id: 1ABCD2abEFcdefGhijkH3lmnopI
+//	        cluster_id: 1ABCD2abEFcdefGhijkH3lmnopI
+//	        external_cluster_id: 34c3ecc5-624a-49a5-bab8-4fdc5e51a266
+//	        display_name: my cluster
+//	        managed: false
+//	        status: Active
+//
+// Subscriptions can contain any attribute supported by AMS API, the
+// organization_id attribute is filled in automatically.
+package fakeams
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	// OrganizationsEndpoint is the path of organizations list endpoint
+	OrganizationsEndpoint = "/api/accounts_mgmt/v1/organizations"
+	// SubscriptionsEndpoint is the path of subscriptions list endpoint
+	SubscriptionsEndpoint = "/api/accounts_mgmt/v1/subscriptions"
+	// TokenEndpoint is the path of the endpoint issuing access tokens. It
+	// can be used as token URL of the AMS client
+	TokenEndpoint = "/token"
+
+	// defaultPageSize is used when page size is not provided in request
+	defaultPageSize = 100
+
+	organizationIDField = "organization_id"
+	contentTypeHeader   = "Content-Type"
+	jsonContentType     = "application/json"
+)
+
+// clauseRegex matches one clause of the search query, for example
+// "external_id = 1234" or "status not in ('Archived','Reserved')"
+var clauseRegex = regexp.MustCompile(`^\s*(\w+)\s+(=|!=|is|not in|in)\s+(.+?)\s*$`)
+
+// Fixture represents the data served by the fake AMS API
+type Fixture struct {
+	Organizations []Organization `json:"organizations" yaml:"organizations"`
+}
+
+// Organization represents one organization with its subscriptions
+type Organization struct {
+	ID            string                   `json:"id" yaml:"id"`
+	ExternalID    types.OrgID              `json:"external_id" yaml:"external_id"`
+	Subscriptions []map[string]interface{} `json:"subscriptions" yaml:"subscriptions"`
+}
+
+// LoadFixture reads fixture from YAML or JSON file
+func LoadFixture(path string) (Fixture, error) {
+	var fixture Fixture
+
+	// #nosec G304
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fixture, err
+	}
+
+	// JSON is a subset of YAML, so both formats can be parsed the same way
+	err = yaml.Unmarshal(content, &fixture)
+	return fixture, err
+}
+
+// NewServer starts new HTTP server serving given fixture. The server needs
+// to be closed by the caller.
+func NewServer(fixture Fixture) *httptest.Server {
+	return httptest.NewServer(NewHandler(fixture))
+}
+
+// NewHandler constructs HTTP handler serving given fixture
+func NewHandler(fixture Fixture) http.Handler {
+	h := &handler{fixture: fixture}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(OrganizationsEndpoint, h.listOrganizations)
+	mux.HandleFunc(SubscriptionsEndpoint, h.listSubscriptions)
+	mux.HandleFunc(TokenEndpoint, h.issueToken)
+	return mux
+}
+
+type handler struct {
+	fixture Fixture
+}
+
+// listOrganizations handles organizations list requests
+func (h *handler) listOrganizations(writer http.ResponseWriter, request *http.Request) {
+	items := make([]map[string]interface{}, 0, len(h.fixture.Organizations))
+	for _, organization := range h.fixture.Organizations {
+		items = append(items, map[string]interface{}{
+			"kind":        "Organization",
+			"id":          organization.ID,
+			"external_id": fmt.Sprint(organization.ExternalID),
+		})
+	}
+
+	h.sendList(writer, request, "OrganizationList", items)
+}
+
+// listSubscriptions handles subscriptions list requests
+func (h *handler) listSubscriptions(writer http.ResponseWriter, request *http.Request) {
+	items := make([]map[string]interface{}, 0)
+	for _, organization := range h.fixture.Organizations {
+		for _, subscription := range organization.Subscriptions {
+			item := map[string]interface{}{
+				"kind":              "Subscription",
+				organizationIDField: organization.ID,
+			}
+			for key, value := range subscription {
+				item[key] = value
+			}
+			items = append(items, item)
+		}
+	}
+
+	h.sendList(writer, request, "SubscriptionList", items)
+}
+
+// sendList filters and paginates items according to the query parameters
+// and sends them to the client
+func (h *handler) sendList(
+	writer http.ResponseWriter, request *http.Request, kind string, items []map[string]interface{},
+) {
+	if request.Method != http.MethodGet {
+		sendError(writer, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := request.URL.Query()
+
+	page, err := readPositiveIntParam(query.Get("page"), 1)
+	if err != nil {
+		sendError(writer, http.StatusBadRequest, "invalid page: "+err.Error())
+		return
+	}
+
+	size, err := readPositiveIntParam(query.Get("size"), defaultPageSize)
+	if err != nil {
+		sendError(writer, http.StatusBadRequest, "invalid size: "+err.Error())
+		return
+	}
+
+	filtered, err := search(items, query.Get("search"))
+	if err != nil {
+		sendError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start := (page - 1) * size
+	end := start + size
+	if start > len(filtered) {
+		start = len(filtered)
+	}
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+
+	pageItems := make([]map[string]interface{}, 0, end-start)
+	for _, item := range filtered[start:end] {
+		pageItems = append(pageItems, selectFields(item, query.Get("fields")))
+	}
+
+	log.Debug().
+		Str("kind", kind).
+		Str("search", query.Get("search")).
+		Int("page", page).
+		Int("size", len(pageItems)).
+		Msg("fake AMS API list request")
+
+	sendJSON(writer, http.StatusOK, map[string]interface{}{
+		"kind":  kind,
+		"page":  page,
+		"size":  len(pageItems),
+		"total": len(filtered),
+		"items": pageItems,
+	})
+}
+
+// issueToken handles token requests. Any credentials are accepted and an
+// unsigned access token is returned.
+func (h *handler) issueToken(writer http.ResponseWriter, _ *http.Request) {
+	sendJSON(writer, http.StatusOK, map[string]interface{}{
+		"access_token": MakeAccessToken(time.Hour),
+		"token_type":   "bearer",
+		"expires_in":   int(time.Hour.Seconds()),
+	})
+}
+
+// MakeAccessToken generates unsigned access token valid for given time. The
+// AMS client does not verify token signatures, so the token can be used as
+// the AMS client token when connecting to the fake server.
+func MakeAccessToken(validity time.Duration) string {
+	encode := func(value interface{}) string {
+		// marshalling of maps with string keys can't fail
+		data, _ := json.Marshal(value)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	now := time.Now()
+	header := map[string]interface{}{
+		"alg": "none",
+		"typ": "JWT",
+	}
+	claims := map[string]interface{}{
+		"typ": "Bearer",
+		"iat": now.Unix(),
+		"exp": now.Add(validity).Unix(),
+	}
+
+	return encode(header) + "." + encode(claims) + "."
+}
+
+// search returns items matching the search query. Only conjunctions of
+// simple comparisons are supported, which is enough for queries generated by
+// the AMS client.
+func search(items []map[string]interface{}, query string) ([]map[string]interface{}, error) {
+	if strings.TrimSpace(query) == "" {
+		return items, nil
+	}
+
+	var predicates []func(map[string]interface{}) bool
+	for _, clause := range strings.Split(query, " and ") {
+		predicate, err := parseClause(clause)
+		if err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, predicate)
+	}
+
+	result := make([]map[string]interface{}, 0)
+	for _, item := range items {
+		matches := true
+		for _, predicate := range predicates {
+			if !predicate(item) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
+
+// parseClause converts one clause of the search query into a predicate
+func parseClause(clause string) (func(map[string]interface{}) bool, error) {
+	parts := clauseRegex.FindStringSubmatch(clause)
+	if parts == nil {
+		return nil, fmt.Errorf("unsupported search clause '%s'", clause)
+	}
+
+	field, operator, operand := parts[1], parts[2], parts[3]
+
+	switch operator {
+	case "=", "is":
+		value := unquote(operand)
+		return func(item map[string]interface{}) bool {
+			return fieldValue(item, field) == value
+		}, nil
+	case "!=":
+		value := unquote(operand)
+		return func(item map[string]interface{}) bool {
+			return fieldValue(item, field) != value
+		}, nil
+	case "in", "not in":
+		values, err := parseList(operand)
+		if err != nil {
+			return nil, err
+		}
+		negate := operator == "not in"
+		return func(item map[string]interface{}) bool {
+			_, found := values[fieldValue(item, field)]
+			return found != negate
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported search operator '%s'", operator)
+}
+
+// parseList parses list of values in form ('a','b')
+func parseList(operand string) (map[string]struct{}, error) {
+	if !strings.HasPrefix(operand, "(") || !strings.HasSuffix(operand, ")") {
+		return nil, fmt.Errorf("invalid list '%s'", operand)
+	}
+
+	values := make(map[string]struct{})
+	for _, value := range strings.Split(operand[1:len(operand)-1], ",") {
+		values[unquote(value)] = struct{}{}
+	}
+	return values, nil
+}
+
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+func fieldValue(item map[string]interface{}, field string) string {
+	value, found := item[field]
+	if !found || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// selectFields returns item containing just the requested fields (plus kind
+// and id, which are always returned by AMS API)
+func selectFields(item map[string]interface{}, fields string) map[string]interface{} {
+	if strings.TrimSpace(fields) == "" {
+		return item
+	}
+
+	selected := make(map[string]interface{})
+	for _, field := range append([]string{"kind", "id"}, strings.Split(fields, ",")...) {
+		// nested fields (like plan.id) select the whole top-level attribute
+		field = strings.SplitN(strings.TrimSpace(field), ".", 2)[0]
+		if value, found := item[field]; found {
+			selected[field] = value
+		}
+	}
+	return selected
+}
+
+func readPositiveIntParam(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if number <= 0 {
+		return 0, fmt.Errorf("positive number expected, got %d", number)
+	}
+	return number, nil
+}
+
+func sendError(writer http.ResponseWriter, status int, reason string) {
+	sendJSON(writer, status, map[string]interface{}{
+		"kind":   "Error",
+		"id":     strconv.Itoa(status),
+		"code":   fmt.Sprintf("ACCT-MGMT-%d", status),
+		"reason": reason,
+	})
+}
+
+func sendJSON(writer http.ResponseWriter, status int, body interface{}) {
+	writer.Header().Set(contentTypeHeader, jsonContentType)
+	writer.WriteHeader(status)
+
+	if err := json.NewEncoder(writer).Encode(body); err != nil {
+		log.Error().Err(err).Msg("unable to send response from fake AMS API")
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 Red Hat, Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakeams_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	utypes "github.com/RedHatInsights/insights-operator-utils/types"
+	"github.com/RedHatInsights/insights-results-smart-proxy/amsclient"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/fakeams"
+	"github.com/RedHatInsights/insights-results-smart-proxy/tests/helpers"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
+)
+
+const (
+	fixturePath = "fixture.yaml"
+
+	orgID        = types.OrgID(1)
+	activeID1    = types.ClusterName("34c3ecc5-624a-49a5-bab8-4fdc5e51a266")
+	activeID2    = types.ClusterName("74ae54aa-6577-4e80-85e7-697cb646ff37")
+	archivedID   = types.ClusterName("a7467445-8d6a-43cc-b82c-7007664bdf69")
+	otherOrgID   = types.ClusterName("ee7d2bf4-8933-4a3a-8634-3328fe806e08")
+	unknownOrgID = types.OrgID(42)
+)
+
+func clusterIDs(clusters []types.ClusterInfo) []types.ClusterName {
+	ids := make([]types.ClusterName, 0, len(clusters))
+	for _, cluster := range clusters {
+		ids = append(ids, cluster.ID)
+	}
+	return ids
+}
+
+// newClient starts the fake server and returns AMS client connected to it
+func newClient(t *testing.T, pageSize int) amsclient.AMSClient {
+	fixture, err := fakeams.LoadFixture(fixturePath)
+	helpers.FailOnError(t, err)
+
+	server := fakeams.NewServer(fixture)
+	t.Cleanup(server.Close)
+
+	client, err := amsclient.NewAMSClient(amsclient.Configuration{
+		URL:      server.URL,
+		Token:    fakeams.MakeAccessToken(time.Hour),
+		PageSize: pageSize,
+	})
+	helpers.FailOnError(t, err)
+
+	return client
+}
+
+func TestGetClustersForOrganizationDefaultFilter(t *testing.T) {
+	client := newClient(t, 100)
+
+	clusters, err := client.GetClustersForOrganization(orgID, nil, nil)
+	helpers.FailOnError(t, err)
+
+	assert.ElementsMatch(t, []types.ClusterName{activeID1, activeID2}, clusterIDs(clusters))
+}
+
+func TestGetClustersForOrganizationNoFilter(t *testing.T) {
+	// page size 1 checks that all pages are read
+	client := newClient(t, 1)
+
+	clusters, err := client.GetClustersForOrganization(orgID, nil, []string{})
+	helpers.FailOnError(t, err)
+
+	assert.ElementsMatch(t, []types.ClusterName{activeID1, activeID2, archivedID}, clusterIDs(clusters))
+}
+
+func TestGetClustersForOrganizationStatusFilter(t *testing.T) {
+	client := newClient(t, 100)
+
+	clusters, err := client.GetClustersForOrganization(orgID, []string{amsclient.StatusArchived}, []string{})
+	helpers.FailOnError(t, err)
+
+	assert.Equal(t, []types.ClusterName{archivedID}, clusterIDs(clusters))
+}
+
+func TestGetClustersForUnknownOrganization(t *testing.T) {
+	client := newClient(t, 100)
+
+	_, err := client.GetClustersForOrganization(unknownOrgID, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestGetClusterDetailsFromExternalClusterID(t *testing.T) {
+	client := newClient(t, 100)
+
+	cluster := client.GetClusterDetailsFromExternalClusterID(activeID2)
+	assert.Equal(t, types.ClusterInfo{
+		ID:            activeID2,
+		DisplayName:   "managed cluster 2",
+		Managed:       true,
+		Status:        "Active",
+		Version:       "4.12.5",
+		Product:       amsclient.ProductROSA,
+		CloudProvider: "aws",
+		Region:        "eu-west-1",
+		CreatedAt:     "2023-02-03T04:05:06Z",
+	}, cluster)
+}
+
+func TestGetSingleClusterInfoForOrganization(t *testing.T) {
+	client := newClient(t, 100)
+
+	cluster, err := client.GetSingleClusterInfoForOrganization(orgID, activeID1)
+	helpers.FailOnError(t, err)
+	assert.Equal(t, "cluster 1", cluster.DisplayName)
+
+	// cluster belongs to another organization
+	_, err = client.GetSingleClusterInfoForOrganization(orgID, otherOrgID)
+	assert.IsType(t, &utypes.ItemNotFoundError{}, err)
+}
+
+func TestTokenEndpoint(t *testing.T) {
+	fixture, err := fakeams.LoadFixture(fixturePath)
+	helpers.FailOnError(t, err)
+
+	server := fakeams.NewServer(fixture)
+	defer server.Close()
+
+	client, err := amsclient.NewAMSClient(amsclient.Configuration{
+		URL:          server.URL,
+		TokenURL:     server.URL + fakeams.TokenEndpoint,
+		ClientID:     "client",
+		ClientSecret: "secret",
+	})
+	helpers.FailOnError(t, err)
+
+	clusters, err := client.GetClustersForOrganization(orgID, nil, nil)
+	helpers.FailOnError(t, err)
+	assert.Len(t, clusters, 2)
+}
+
+func TestInvalidSearch(t *testing.T) {
+	fixture, err := fakeams.LoadFixture(fixturePath)
+	helpers.FailOnError(t, err)
+
+	server := fakeams.NewServer(fixture)
+	defer server.Close()
+
+	response, err := http.Get(server.URL + fakeams.SubscriptionsEndpoint + "?search=status+like+%27A%25%27")
+	helpers.FailOnError(t, err)
+	defer response.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
@@ -125,16 +125,14 @@ var (
 )
 
 // AssertAPIRequest function creates new server with provided
-// serverConfig, servicesConfig (you can leave them nil to use the default ones),
-// groupsChannel and errorChannel (can be set to nil as well)
+// serverConfig, servicesConfig and groupsStore (you can leave them nil to use
+// the default ones)
 // sends api request and checks api response (see docs for APIRequest and APIResponse)
 func AssertAPIRequest(
 	t testing.TB,
 	serverConfig *server.Configuration,
 	servicesConfig *services.Configuration,
-	groupsChannel chan []groups.Group,
-	errorFoundChannel chan bool,
-	errorChannel chan error,
+	groupsStore *services.GroupsStore,
 	request *helpers.APIRequest,
 	expectedResponse *helpers.APIResponse,
 ) {
@@ -147,9 +145,7 @@ func AssertAPIRequest(
 		t,
 		serverConfig,
 		servicesConfig,
-		groupsChannel,
-		errorFoundChannel,
-		errorChannel,
+		groupsStore,
 		serverConfig.APIv1Prefix,
 		request,
 		expectedResponse,
@@ -162,9 +158,7 @@ func AssertAPIv2Request(
 	t testing.TB,
 	serverConfig *server.Configuration,
 	servicesConfig *services.Configuration,
-	groupsChannel chan []groups.Group,
-	errorFoundChannel chan bool,
-	errorChannel chan error,
+	groupsStore *services.GroupsStore,
 	request *helpers.APIRequest,
 	expectedResponse *helpers.APIResponse,
 ) {
@@ -177,9 +171,7 @@ func AssertAPIv2Request(
 		t,
 		serverConfig,
 		servicesConfig,
-		groupsChannel,
-		errorFoundChannel,
-		errorChannel,
+		groupsStore,
 		serverConfig.APIv2Prefix,
 		request,
 		expectedResponse,
@@ -190,9 +182,7 @@ func assertAPIRequest(
 	t testing.TB,
 	serverConfig *server.Configuration,
 	servicesConfig *services.Configuration,
-	groupsChannel chan []groups.Group,
-	errorFoundChannel chan bool,
-	errorChannel chan error,
+	groupsStore *services.GroupsStore,
 	APIPrefix string,
 	request *helpers.APIRequest,
 	expectedResponse *helpers.APIResponse,
@@ -204,9 +194,7 @@ func assertAPIRequest(
 		servicesConfig,
 		nil, // AMS client
 		nil, // Redis client
-		groupsChannel,
-		errorFoundChannel,
-		errorChannel,
+		groupsStore,
 	)
 
 	// send the request to newly created REST API server and check its
@@ -221,9 +209,7 @@ func CreateHTTPServer(
 	servicesConfig *services.Configuration,
 	amsClient amsclient.AMSClient,
 	redis services.RedisInterface,
-	groupsChannel chan []groups.Group,
-	errorFoundChannel chan bool,
-	errorChannel chan error,
+	groupsStore *services.GroupsStore,
 ) *server.HTTPServer {
 	// if custom server configuration is not provided, use default one
 	if serverConfig == nil {
@@ -235,6 +221,12 @@ func CreateHTTPServer(
 		servicesConfig = &DefaultServicesConfig
 	}
 
+	// if custom groups store is not provided, use store with no groups
+	if groupsStore == nil {
+		groupsStore = services.NewGroupsStore()
+		groupsStore.Update([]groups.Group{}, nil)
+	}
+
 	content.SetContentDirectoryTimeout(servicesConfig.ContentDirectoryTimeout)
 
 	// create an instance of new REST API server with provided or default
@@ -244,8 +236,6 @@ func CreateHTTPServer(
 		*servicesConfig,
 		amsClient,
 		redis,
-		groupsChannel,
-		errorFoundChannel,
-		errorChannel,
+		groupsStore,
 	)
 }
@@ -15,10 +15,12 @@
 package helpers
 
 import (
+	"fmt"
 	"testing"
 
 	redisutils "github.com/RedHatInsights/insights-operator-utils/redis"
 	"github.com/RedHatInsights/insights-results-smart-proxy/services"
+	"github.com/RedHatInsights/insights-results-smart-proxy/types"
 
 	"github.com/go-redis/redismock/v9"
 )
@@ -55,3 +57,9 @@ func RedisExpectationsMet(t *testing.T, mock redismock.ClientMock) {
 		t.Error(err)
 	}
 }
+
+// ExpectRequestIDsIndexMissing expects that index of request IDs of the
+// cluster is read and it doesn't exist, so request IDs are found by SCAN
+func ExpectRequestIDsIndexMissing(mock redismock.ClientMock, orgID types.OrgID, clusterID types.ClusterName) {
+	mock.ExpectZRange(fmt.Sprintf(services.RequestIDsIndexKey, orgID, clusterID), 0, -1).SetVal([]string{})
+}
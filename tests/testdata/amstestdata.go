@@ -37,6 +37,19 @@ const (
 
 	// ActiveStatus default status for testing AMS clusters
 	ActiveStatus = "Active"
+
+	// ClusterVersion1 represents the OpenShift version of ClusterName1 reported by AMS
+	ClusterVersion1 = "4.13.0"
+	// ClusterPlan1 represents the AMS plan of ClusterName1
+	ClusterPlan1 = "MOA"
+	// ClusterProduct1 represents the product of ClusterName1
+	ClusterProduct1 = "ROSA"
+	// ClusterCloudProvider1 represents the cloud provider of ClusterName1
+	ClusterCloudProvider1 = "aws"
+	// ClusterRegion1 represents the cloud region of ClusterName1
+	ClusterRegion1 = "us-east-1"
+	// ClusterCreatedAt1 represents the creation time of ClusterName1 subscription
+	ClusterCreatedAt1 = "2023-01-02T03:04:05Z"
 )
 
 var (
@@ -86,6 +99,16 @@ var (
 				"id":                  "1YfQ9bR7LTDz24YzfFmaCdeB0sS",
 				"managed":             true,
 				"status":              ActiveStatus,
+				"metrics": []map[string]interface{}{
+					{"openshift_version": ClusterVersion1},
+				},
+				"plan": map[string]interface{}{
+					"kind": "Plan",
+					"id":   ClusterPlan1,
+				},
+				"cloud_provider_id": ClusterCloudProvider1,
+				"region_id":         ClusterRegion1,
+				"created_at":        ClusterCreatedAt1,
 			},
 			{
 				"display_name":        ClusterDisplayName2,
@@ -165,10 +188,15 @@ var (
 	// OKClustersForOrganization is the expected OK result of GetClustersForOrganization
 	OKClustersForOrganization []sptypes.ClusterInfo = []sptypes.ClusterInfo{
 		{
-			ID:          ClusterName1,
-			DisplayName: ClusterDisplayName1,
-			Managed:     true,
-			Status:      ActiveStatus,
+			ID:            ClusterName1,
+			DisplayName:   ClusterDisplayName1,
+			Managed:       true,
+			Status:        ActiveStatus,
+			Version:       ClusterVersion1,
+			Product:       ClusterProduct1,
+			CloudProvider: ClusterCloudProvider1,
+			Region:        ClusterRegion1,
+			CreatedAt:     ClusterCreatedAt1,
 		},
 		{
 			ID:          ClusterName2,
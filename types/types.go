@@ -45,6 +45,9 @@ type ClusterName = types.ClusterName
 // OrgID is a rename for types.OrgID
 type OrgID = types.OrgID
 
+// Version is a rename for types.Version
+type Version = types.Version
+
 // ImpactingFlag controls the behaviour of 'impacting' param on GET /rule/
 type ImpactingFlag int
 
@@ -71,6 +74,9 @@ type RuleWithContentResponse struct {
 	TemplateData    interface{}     `json:"extra_data"`
 	Tags            []string        `json:"tags"`
 	Impacted        Timestamp       `json:"impacted,omitempty"`
+	// RenderWarning describes problems with rendering of the templates
+	// when rendered content is requested
+	RenderWarning string `json:"render_warning,omitempty"`
 }
 
 // RecommendationContent is a rule content struct used for Insights Advisor,
@@ -220,6 +226,10 @@ type ClusterListView struct {
 	TotalHitCount   uint32            `json:"total_hit_count"`
 	HitsByTotalRisk map[int]int       `json:"hits_by_total_risk"`
 	Version         types.Version     `json:"cluster_version,omitempty"`
+	Product         string            `json:"product,omitempty"`
+	CloudProvider   string            `json:"cloud_provider,omitempty"`
+	Region          string            `json:"region,omitempty"`
+	CreatedAt       Timestamp         `json:"created_at,omitempty"`
 }
 
 // RuleRating structure with the rule identifier and the rating
@@ -250,12 +260,63 @@ type InfoResponse struct {
 	ContentService map[string]string `json:"ContentService"`
 }
 
+// DependencyStatus represents state of one dependency checked by /readyz
+// REST API endpoint
+type DependencyStatus struct {
+	Status   string `json:"status"`
+	Required bool   `json:"required"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReadinessResponse is a data structure returned by /readyz REST API endpoint
+type ReadinessResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// ContentVersion identifies one loaded version of the rule content
+type ContentVersion struct {
+	Hash     string    `json:"hash"`
+	LoadedAt time.Time `json:"loaded_at"`
+}
+
+// ContentDiff represents differences between two versions of the rule
+// content. Rules are identified by rule module, error keys by composite rule
+// ID ("rule.module|ERROR_KEY").
+type ContentDiff struct {
+	From             ContentVersion `json:"from"`
+	To               ContentVersion `json:"to"`
+	AddedRules       []RuleID       `json:"added_rules"`
+	RemovedRules     []RuleID       `json:"removed_rules"`
+	AddedErrorKeys   []RuleID       `json:"added_error_keys"`
+	RemovedErrorKeys []RuleID       `json:"removed_error_keys"`
+	ChangedErrorKeys []ErrorKeyDiff `json:"changed_error_keys"`
+}
+
+// ErrorKeyDiff represents changes of content of one error key
+type ErrorKeyDiff struct {
+	RuleID  RuleID        `json:"rule_id"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange represents change of one field of error key content
+type FieldChange struct {
+	Field    string      `json:"field"`
+	OldValue interface{} `json:"old_value"`
+	NewValue interface{} `json:"new_value"`
+}
+
 // ClusterInfo is a data structure containing some relevant cluster information
 type ClusterInfo struct {
-	ID          ClusterName `json:"cluster_id"`
-	DisplayName string      `json:"display_name"`
-	Managed     bool        `json:"managed"`
-	Status      string      `json:"status"`
+	ID            ClusterName `json:"cluster_id"`
+	DisplayName   string      `json:"display_name"`
+	Managed       bool        `json:"managed"`
+	Status        string      `json:"status"`
+	Version       Version     `json:"version,omitempty"`
+	Product       string      `json:"product,omitempty"`
+	CloudProvider string      `json:"cloud_provider,omitempty"`
+	Region        string      `json:"region,omitempty"`
+	CreatedAt     Timestamp   `json:"created_at,omitempty"`
 }
 
 // ClustersDetailData is the inner data structure for /clusters_detail
@@ -279,6 +340,21 @@ type RequestStatus struct {
 	Valid     bool   `json:"valid" redis:"-"`
 	Received  string `json:"received" redis:"received_timestamp"`
 	Processed string `json:"processed" redis:"processed_timestamp"`
+	// Latency is time between receiving and processing the request in
+	// seconds, it's computed from the timestamps
+	Latency *float64 `json:"latency,omitempty" redis:"-"`
+}
+
+// RequestProgress describes how far the processing of one request got. The
+// request is received when it's stored for the cluster, processed when the
+// processed timestamp is stored and its report is ready when rule hits are
+// stored.
+type RequestProgress struct {
+	Received    bool
+	Processed   bool
+	ReportReady bool
+	ReceivedAt  string
+	ProcessedAt string
 }
 
 // SimplifiedRuleHit structure represents one simplified rule hit for On Demand Data Gathering
@@ -288,3 +364,20 @@ type SimplifiedRuleHit struct {
 	Description string `json:"description"`
 	TotalRisk   int    `json:"total_risk"`
 }
+
+// ContentSearchResult represents one error key matching the full-text search
+// query
+type ContentSearchResult struct {
+	RuleID      RuleID                 `json:"rule_id"`
+	Description string                 `json:"description"`
+	TotalRisk   int                    `json:"total_risk"`
+	Score       float64                `json:"score"`
+	Snippets    []ContentSearchSnippet `json:"snippets"`
+}
+
+// ContentSearchSnippet represents part of the error key content field with
+// highlighted words matching the search query
+type ContentSearchSnippet struct {
+	Field string `json:"field"`
+	Text  string `json:"text"`
+}